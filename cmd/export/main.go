@@ -0,0 +1,102 @@
+// export 从运行中的服务导出新币对、开仓记录、成交、盈亏和持仓
+//
+//	go run cmd/export/main.go -dataset trades -format xlsx -from 2024-01-01 -to 2024-01-08 -o trades.xlsx
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"new_listing_trade/internal/export"
+)
+
+func main() {
+	server := flag.String("server", "http://localhost:8081", "服务地址")
+	token := flag.String("token", os.Getenv("NLT_API_TOKEN"), "API token（启用认证时需要read角色），默认读取环境变量 NLT_API_TOKEN")
+	dataset := flag.String("dataset", export.DatasetTrades, "数据集: "+strings.Join(export.Datasets, "/"))
+	format := flag.String("format", export.FormatCSV, "导出格式: csv/jsonl/xlsx")
+	from := flag.String("from", "", "开始时间（包含），支持毫秒时间戳、RFC3339或2006-01-02")
+	to := flag.String("to", "", "结束时间（不包含），格式同 -from")
+	symbol := flag.String("symbol", "", "币对")
+	account := flag.String("account", "", "币安账户")
+	output := flag.String("o", "", "输出文件，默认输出到标准输出")
+	flag.Parse()
+
+	if err := run(*server, *token, *dataset, *format, *output, url.Values{
+		"format":  {*format},
+		"from":    {*from},
+		"to":      {*to},
+		"symbol":  {*symbol},
+		"account": {*account},
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run 请求导出接口并写到输出文件
+func run(server, token, dataset, format, output string, params url.Values) error {
+	if err := export.ValidDataset(dataset); err != nil {
+		return err
+	}
+	if err := export.ValidFormat(format); err != nil {
+		return err
+	}
+	for key, values := range params {
+		if values[0] == "" {
+			params.Del(key)
+		}
+	}
+
+	requestURL := fmt.Sprintf("%s/api/export/%s?%s", strings.TrimRight(server, "/"), dataset, params.Encode())
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// 交易记录需要逐条查询交易所成交历史，可能耗时较长
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("写入失败: %w", err)
+	}
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "已导出 %s 到 %s\n", dataset, output)
+	}
+	return nil
+}
@@ -1,18 +1,26 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"new_listing_trade/internal/api"
 	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
 	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/notify"
 	"new_listing_trade/internal/service"
 )
 
 func main() {
 	// 解析命令行参数
 	configPath := flag.String("config", "config.yaml", "配置文件路径")
-	port := flag.String("port", "8081", "HTTP服务端口")
+	port := flag.String("port", "", "HTTP服务端口（默认使用配置文件中的 server.port，未配置时为8081）")
 	flag.Parse()
 
 	// 加载配置
@@ -29,8 +37,44 @@ func main() {
 	logger.Info("配置加载成功")
 	logger.Infof("日志级别: %s, 日志文件: %s", cfg.Log.Level, cfg.Log.File)
 
+	// 事件总线：监控服务、交易服务发布事件，通过 /api/events 推送
+	eventBus := events.NewBus(cfg.Server.EventBufferSize)
+
+	// 通知：按规则把事件推送到Telegram/Slack/钉钉/飞书/Webhook
+	dispatcher, err := notify.NewDispatcher(cfg.Notify)
+	if err != nil {
+		logger.Fatalf("初始化通知失败: %v", err)
+	}
+	notifyCtx, stopNotify := context.WithCancel(context.Background())
+	defer stopNotify()
+	go dispatcher.Run(notifyCtx, eventBus)
+	if len(cfg.Notify.Channels) > 0 {
+		logger.Infof("已启用通知，渠道数量: %d", len(cfg.Notify.Channels))
+	}
+
 	// 创建币对监控服务
-	monitor := service.NewSymbolMonitor()
+	monitor := service.NewSymbolMonitorWithConfig(cfg.Monitor)
+	monitor.SetEventBus(eventBus)
+
+	// 注册额外的新币来源
+	if cfg.ListingSources.Announcement.Enabled {
+		monitor.AddSource(service.NewAnnouncementSource(cfg.ListingSources.Announcement))
+		logger.Infof("已启用公告新币来源: %s", cfg.ListingSources.Announcement.URL)
+	}
+	if cfg.Bybit.WatchListings {
+		monitor.AddSource(service.NewExchangeListingSource(exchange.NewBybit(cfg.Bybit), cfg.Monitor.PollInterval))
+		logger.Info("已启用Bybit新合约监控")
+	}
+	if cfg.OKX.WatchListings {
+		monitor.AddSource(service.NewExchangeListingSource(exchange.NewOKX(cfg.OKX), cfg.Monitor.PollInterval))
+		logger.Info("已启用OKX新合约监控")
+	}
+	var webhookSource *service.WebhookSource
+	if cfg.ListingSources.Webhook.Enabled {
+		webhookSource = service.NewWebhookSource()
+		monitor.AddSource(webhookSource)
+		logger.Info("已启用webhook新币来源: POST /api/listings/webhook")
+	}
 
 	// 启动监控服务（会立即拉取一次数据）
 	if err := monitor.Start(); err != nil {
@@ -43,30 +87,111 @@ func main() {
 
 	// 创建交易服务（如果配置了API密钥）
 	var tradingService *service.TradingService
-	if cfg.Binance.APIKey != "" && cfg.Binance.SecretKey != "" {
+	if len(cfg.BinanceAccounts()) > 0 {
 		tradingService, err = service.NewTradingService(cfg)
 		if err != nil {
 			logger.Warnf("警告: 交易服务初始化失败: %v", err)
 			logger.Warn("交易功能将不可用，但监控功能仍可正常使用")
 		} else {
-			logger.Info("交易服务启动成功")
+			tradingService.SetEventBus(eventBus)
+			journal, err := service.NewTradeJournal(cfg.Journal.File)
+			if err != nil {
+				logger.Fatalf("加载交易日志失败: %v", err)
+			}
+			tradingService.SetTradeJournal(journal)
+			logger.Infof("交易服务启动成功，币安账户: %v", tradingService.AccountNames())
 		}
 	} else {
 		logger.Warn("警告: 未配置API密钥，交易功能不可用")
-		logger.Warn("如需使用交易功能，请在配置文件中设置 binance.api_key 和 binance.secret_key")
+		logger.Warn("如需使用交易功能，请在配置文件中设置 binance.api_key 和 binance.secret_key，或在 accounts 中添加启用的账户")
+	}
+
+	// 确定HTTP端口：命令行参数 > 配置文件 > 默认8081
+	listenPort := *port
+	if listenPort == "" {
+		listenPort = cfg.Server.Port
+	}
+	if listenPort == "" {
+		listenPort = "8081"
 	}
 
 	// 创建HTTP服务器
-	httpServer := api.NewServer(*port, monitor, tradingService)
+	httpServer := api.NewServer(listenPort, monitor, tradingService)
+	if webhookSource != nil {
+		httpServer.SetWebhookSource(webhookSource, cfg.ListingSources.Webhook.Token)
+	}
+	httpServer.SetEventBus(eventBus)
+
+	// 接口认证和CORS
+	httpServer.SetAllowedOrigins(cfg.Server.AllowedOrigins)
+	auth, err := api.NewAuthenticator(cfg.Server.Auth)
+	if err != nil {
+		logger.Fatalf("初始化接口认证失败: %v", err)
+	}
+	httpServer.SetAuth(auth)
+	if err := httpServer.SetTrustedProxies(cfg.Server.Auth.TrustedProxies); err != nil {
+		logger.Fatalf("设置信任的反向代理失败: %v", err)
+	}
+	if cfg.Server.Auth.Enabled {
+		logger.Infof("已启用接口认证，调用方数量: %d", len(cfg.Server.Auth.Clients))
+	} else {
+		logger.Warn("警告: 未启用接口认证，任何能访问端口的人都可以下单，建议配置 server.auth")
+	}
+	if len(cfg.Server.Auth.IPAllowlist) > 0 {
+		logger.Infof("已启用IP白名单: %v", cfg.Server.Auth.IPAllowlist)
+	}
+
+	// 配置热更新：POST /api/config/reload，以及可选的配置文件监听
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if tradingService != nil {
+		tradingService.StartBracketMonitor(watchCtx, cfg.Monitor.BracketPollInterval)
+		go service.NewTimeExitScheduler(tradingService).Run(watchCtx, cfg.Monitor.TimeExitInterval)
+		go service.NewStopManager(tradingService).Run(watchCtx, cfg.Monitor.StopRatchetInterval)
+		if cfg.Monitor.Watchdog.Enabled {
+			go service.NewPositionWatchdog(tradingService, cfg.Monitor.Watchdog).Run(watchCtx)
+			logger.Infof("已启用仓位保护检查（dry_run: %v, resize: %v）", cfg.Monitor.Watchdog.DryRun, cfg.Monitor.Watchdog.Resize)
+		}
+		reloader := service.NewConfigReloader(*configPath, tradingService)
+		httpServer.SetConfigReloader(reloader)
+		if cfg.Server.ConfigWatchInterval > 0 {
+			go reloader.Watch(watchCtx, cfg.Server.ConfigWatchInterval)
+			logger.Infof("已启用配置文件监听: %s，每%v检查一次", *configPath, cfg.Server.ConfigWatchInterval)
+		}
+	}
 
 	// 启动HTTP服务器（阻塞运行）
 	logger.Info("服务运行中...")
-	logger.Info("币对监控服务：每2分钟检查一次新币对")
+	logger.Infof("币对监控服务：每%v检查一次新币对，临近上线时每%v检查一次", cfg.Monitor.PollInterval, cfg.Monitor.FastPollInterval)
 	if tradingService != nil {
 		logger.Info("交易服务：已启用")
 	}
 
-	if err := httpServer.Start(); err != nil {
-		logger.Fatalf("HTTP服务器启动失败: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- httpServer.Start()
+	}()
+
+	// 等待退出信号，收到后停止监控并关闭HTTP服务器
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErr:
+		monitor.Stop()
+		if err != nil {
+			logger.Fatalf("HTTP服务器启动失败: %v", err)
+		}
+	case sig := <-quit:
+		logger.Infof("收到信号 %v，正在关闭服务...", sig)
+		stopWatch()
+		monitor.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logger.Errorf("关闭HTTP服务器失败: %v", err)
+		}
+		logger.Info("服务已关闭")
 	}
 }
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/logger"
+)
+
+// 签名认证请求头
+const (
+	HeaderAPIKey    = "X-API-Key"   // 调用方名称
+	HeaderTimestamp = "X-Timestamp" // 毫秒时间戳
+	HeaderSignature = "X-Signature" // hex(HMAC_SHA256(secret, timestamp + method + requestURI + body))
+)
+
+// signatureWindow 签名时间戳允许的最大偏差，防止重放
+const signatureWindow = 5 * time.Minute
+
+// contextClientKey gin上下文中保存调用方名称的key
+const contextClientKey = "api_client"
+
+// Authenticator HTTP接口认证
+type Authenticator struct {
+	enabled   bool
+	byName    map[string]config.APIClientConfig
+	allowlist []*net.IPNet
+}
+
+// NewAuthenticator 根据配置创建认证器
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	auth := &Authenticator{
+		enabled: cfg.Enabled,
+		byName:  make(map[string]config.APIClientConfig),
+	}
+	for _, client := range cfg.Clients {
+		auth.byName[client.Name] = client
+	}
+
+	for _, entry := range cfg.IPAllowlist {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("无效的IP白名单 %q: %w", entry, err)
+		}
+		auth.allowlist = append(auth.allowlist, ipNet)
+	}
+	return auth, nil
+}
+
+// ipAllowed 判断IP是否在白名单中（未配置白名单时允许所有IP）
+func (a *Authenticator) ipAllowed(ipStr string) bool {
+	if len(a.allowlist) == 0 {
+		return true
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range a.allowlist {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryTokenPaths 允许通过 ?access_token= 传递token的接口（浏览器的EventSource/WebSocket无法设置请求头）
+var queryTokenPaths = map[string]bool{
+	"/api/events":    true,
+	"/api/events/ws": true,
+}
+
+// authenticate 校验请求凭证，返回调用方
+func (a *Authenticator) authenticate(r *http.Request, body []byte) (*config.APIClientConfig, error) {
+	header := r.Header.Get("Authorization")
+	if token := r.URL.Query().Get("access_token"); header == "" && token != "" {
+		if r.Method != http.MethodGet || !queryTokenPaths[r.URL.Path] {
+			return nil, fmt.Errorf("只有事件推送接口允许通过查询参数传递token，请使用 Authorization 请求头")
+		}
+		header = "Bearer " + token
+	}
+	if header != "" {
+		token := strings.TrimPrefix(header, "Bearer ")
+		if token == header || token == "" {
+			return nil, fmt.Errorf("Authorization格式错误，应为 Bearer <token>")
+		}
+		for _, client := range a.byName {
+			if client.Token != "" && subtle.ConstantTimeCompare([]byte(client.Token), []byte(token)) == 1 {
+				client := client
+				return &client, nil
+			}
+		}
+		return nil, fmt.Errorf("token无效")
+	}
+
+	name := r.Header.Get(HeaderAPIKey)
+	if name == "" {
+		return nil, fmt.Errorf("缺少认证信息")
+	}
+	client, ok := a.byName[name]
+	if !ok || client.Secret == "" {
+		return nil, fmt.Errorf("调用方 %s 不存在或未配置签名密钥", name)
+	}
+
+	timestamp := r.Header.Get(HeaderTimestamp)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时间戳: %q", timestamp)
+	}
+	if skew := time.Since(time.UnixMilli(ts)); skew > signatureWindow || skew < -signatureWindow {
+		return nil, fmt.Errorf("时间戳超出允许范围（%v）", signatureWindow)
+	}
+
+	expected := Sign(client.Secret, timestamp, r.Method, r.URL.RequestURI(), body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(HeaderSignature))) {
+		return nil, fmt.Errorf("签名无效")
+	}
+	return &client, nil
+}
+
+// Sign 计算请求签名：hex(HMAC_SHA256(secret, timestamp + method + requestURI + body))
+func Sign(secret, timestamp, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + method + requestURI))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hasRole 判断角色是否满足要求（trader包含read权限）
+func hasRole(role, required string) bool {
+	if role == config.RoleTrader {
+		return true
+	}
+	return role == required
+}
+
+// ipAllowlistMiddleware IP白名单中间件
+func (s *Server) ipAllowlistMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.auth == nil || s.auth.ipAllowed(c.ClientIP()) {
+			c.Next()
+			return
+		}
+		logger.Warnf("拒绝访问: IP %s 不在白名单中, 路径: %s %s", c.ClientIP(), c.Request.Method, c.Request.URL.Path)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "IP不在白名单中",
+		})
+	}
+}
+
+// requireRole 认证中间件，要求调用方具有指定角色（未启用认证时放行）
+func (s *Server) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.auth == nil || !s.auth.enabled {
+			c.Next()
+			return
+		}
+
+		// 读取请求体用于校验签名，之后还原给后续处理函数
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "读取请求体失败: " + err.Error(),
+				})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		client, err := s.auth.authenticate(c.Request, body)
+		if err != nil {
+			logger.Warnf("认证失败: %v, IP: %s, 路径: %s %s", err, c.ClientIP(), c.Request.Method, c.Request.URL.Path)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "认证失败: " + err.Error(),
+			})
+			return
+		}
+
+		if !hasRole(client.Role, role) {
+			logger.Warnf("权限不足: 调用方 %s（角色 %s）访问 %s %s 需要角色 %s, IP: %s",
+				client.Name, client.Role, c.Request.Method, c.Request.URL.Path, role, c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("权限不足，需要角色 %s", role),
+			})
+			return
+		}
+
+		c.Set(contextClientKey, client.Name)
+		c.Next()
+	}
+}
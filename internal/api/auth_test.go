@@ -0,0 +1,181 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"new_listing_trade/internal/config"
+)
+
+func newAuthTestServer(t *testing.T, cfg config.AuthConfig) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	auth, err := NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("创建认证器失败: %v", err)
+	}
+	s := &Server{engine: newEngine(), auth: auth}
+	api := s.engine.Group("/api", s.ipAllowlistMiddleware())
+	api.GET("/events", s.requireRole(config.RoleRead), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(contextClientKey))
+	})
+	api.GET("/status", s.requireRole(config.RoleRead), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(contextClientKey))
+	})
+	api.POST("/orders", s.requireRole(config.RoleTrader), func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.String(http.StatusOK, string(body))
+	})
+	return s
+}
+
+func serve(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w
+}
+
+var testAuthConfig = config.AuthConfig{
+	Enabled: true,
+	Clients: []config.APIClientConfig{
+		{Name: "dashboard", Role: config.RoleRead, Token: "read-token"},
+		{Name: "bot", Role: config.RoleTrader, Token: "trade-token", Secret: "bot-secret"},
+	},
+}
+
+func TestBearerTokenRoles(t *testing.T) {
+	s := newAuthTestServer(t, testAuthConfig)
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/api/status", "", http.StatusUnauthorized},
+		{"GET", "/api/status", "wrong", http.StatusUnauthorized},
+		{"GET", "/api/status", "read-token", http.StatusOK},
+		{"POST", "/api/orders", "read-token", http.StatusForbidden},
+		{"GET", "/api/status", "trade-token", http.StatusOK},
+		{"POST", "/api/orders", "trade-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		if w := serve(s, req); w.Code != tt.want {
+			t.Errorf("%s %s token=%q: 状态码 %d，期望 %d", tt.method, tt.path, tt.token, w.Code, tt.want)
+		}
+	}
+}
+
+func TestHMACSignature(t *testing.T) {
+	s := newAuthTestServer(t, testAuthConfig)
+	body := `{"symbol":"ABCUSDT"}`
+
+	signed := func(ts time.Time, secret string) *http.Request {
+		timestamp := strconv.FormatInt(ts.UnixMilli(), 10)
+		req := httptest.NewRequest("POST", "/api/orders?dry=1", strings.NewReader(body))
+		req.Header.Set(HeaderAPIKey, "bot")
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(secret, timestamp, "POST", "/api/orders?dry=1", []byte(body)))
+		return req
+	}
+
+	w := serve(s, signed(time.Now(), "bot-secret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("正确的签名应通过认证，状态码 %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != body {
+		t.Errorf("请求体未还原给处理函数: %q", w.Body.String())
+	}
+
+	if w := serve(s, signed(time.Now(), "other-secret")); w.Code != http.StatusUnauthorized {
+		t.Errorf("错误的签名应返回401，实际: %d", w.Code)
+	}
+	if w := serve(s, signed(time.Now().Add(-10*time.Minute), "bot-secret")); w.Code != http.StatusUnauthorized {
+		t.Errorf("过期的时间戳应返回401，实际: %d", w.Code)
+	}
+}
+
+func TestIPAllowlist(t *testing.T) {
+	cfg := config.AuthConfig{IPAllowlist: []string{"10.0.0.0/8", "192.168.1.5"}}
+	s := newAuthTestServer(t, cfg)
+
+	for addr, want := range map[string]int{
+		"10.1.2.3:1234":    http.StatusOK,
+		"192.168.1.5:1234": http.StatusOK,
+		"192.168.1.6:1234": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/api/status", nil)
+		req.RemoteAddr = addr
+		if w := serve(s, req); w.Code != want {
+			t.Errorf("%s: 状态码 %d，期望 %d", addr, w.Code, want)
+		}
+	}
+}
+
+func TestIPAllowlistForwardedFor(t *testing.T) {
+	cfg := config.AuthConfig{IPAllowlist: []string{"10.0.0.0/8"}}
+	s := newAuthTestServer(t, cfg)
+
+	// 未配置信任的代理时，伪造的 X-Forwarded-For 不能绕过白名单
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	req.RemoteAddr = "203.0.113.9:1234"
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	if w := serve(s, req); w.Code != http.StatusForbidden {
+		t.Errorf("伪造 X-Forwarded-For 应返回403，实际: %d", w.Code)
+	}
+
+	// 来自信任的代理时按 X-Forwarded-For 取客户端IP
+	if err := s.SetTrustedProxies([]string{"203.0.113.0/24"}); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(s, req); w.Code != http.StatusOK {
+		t.Errorf("信任的代理转发的请求应按 X-Forwarded-For 判断，实际: %d", w.Code)
+	}
+}
+
+func TestQueryTokenOnlyForEvents(t *testing.T) {
+	s := newAuthTestServer(t, testAuthConfig)
+
+	if w := serve(s, httptest.NewRequest("GET", "/api/events?access_token=read-token", nil)); w.Code != http.StatusOK {
+		t.Errorf("事件推送接口应允许查询参数token，实际: %d", w.Code)
+	}
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/api/status?access_token=read-token", nil),
+		httptest.NewRequest("POST", "/api/orders?access_token=trade-token", nil),
+	} {
+		if w := serve(s, req); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s 不应接受查询参数token，实际: %d", req.Method, req.URL.Path, w.Code)
+		}
+	}
+
+	if got := redactAccessToken("/api/events?access_token=secret&types=error"); strings.Contains(got, "secret") {
+		t.Errorf("请求日志应隐去token: %s", got)
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{engine: gin.New(), allowedOrigins: []string{"https://dash.example.com"}}
+	s.engine.Use(s.corsMiddleware())
+	s.engine.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for origin, want := range map[string]string{
+		"https://dash.example.com": "https://dash.example.com",
+		"https://evil.example.com": "",
+	} {
+		req := httptest.NewRequest("GET", "/health", nil)
+		req.Header.Set("Origin", origin)
+		w := serve(s, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("Origin %s: Access-Control-Allow-Origin=%q，期望 %q", origin, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package binance
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,16 +23,37 @@ const (
 	FAPIExchangeInfoEndpoint = "/fapi/v1/exchangeInfo"
 	FAPIOrderEndpoint        = "/fapi/v1/order"
 	FAPITickerPriceEndpoint  = "/fapi/v1/ticker/price"
-	FAPIPositionRiskEndpoint = "/fapi/v2/positionRisk" // 持仓风险查询
+	FAPIBookTickerEndpoint   = "/fapi/v1/ticker/bookTicker" // 最优挂单
+	FAPIDepthEndpoint        = "/fapi/v1/depth"             // 盘口深度
+	FAPIPremiumIndexEndpoint = "/fapi/v1/premiumIndex"      // 标记价格和资金费率
+	FAPIFundingRateEndpoint  = "/fapi/v1/fundingRate"       // 历史资金费率
+	FAPIFundingInfoEndpoint  = "/fapi/v1/fundingInfo"       // 资金费率上下限和结算间隔
+	FAPIPositionRiskEndpoint = "/fapi/v2/positionRisk"      // 持仓风险查询
+	FAPIOpenOrdersEndpoint   = "/fapi/v1/openOrders"        // 当前挂单
+	FAPIUserTradesEndpoint   = "/fapi/v1/userTrades"        // 账户成交历史
+	FAPIIncomeEndpoint       = "/fapi/v1/income"            // 资金流水
 
 	// PAPI端点（统一账户U本位合约）
-	PAPIExchangeInfoEndpoint     = "/papi/v1/um/exchangeInfo"
-	PAPIOrderEndpoint            = "/papi/v1/um/order"
-	PAPITickerPriceEndpoint      = "/papi/v1/um/ticker/price"
-	PAPIConditionalOrderEndpoint = "/papi/v1/um/conditional/order" // 统一账户条件单接口
-	PAPIPositionRiskEndpoint     = "/papi/v1/um/positionRisk"      // 统一账户持仓风险查询
+	PAPIExchangeInfoEndpoint          = "/papi/v1/um/exchangeInfo"
+	PAPIOrderEndpoint                 = "/papi/v1/um/order"
+	PAPITickerPriceEndpoint           = "/papi/v1/um/ticker/price"
+	PAPIConditionalOrderEndpoint      = "/papi/v1/um/conditional/order"      // 统一账户条件单接口
+	PAPIPositionRiskEndpoint          = "/papi/v1/um/positionRisk"           // 统一账户持仓风险查询
+	PAPIOpenOrdersEndpoint            = "/papi/v1/um/openOrders"             // 统一账户当前挂单
+	PAPIConditionalOpenOrdersEndpoint = "/papi/v1/um/conditional/openOrders" // 统一账户当前条件单
+	PAPIUserTradesEndpoint            = "/papi/v1/um/userTrades"             // 统一账户成交历史
+	PAPIIncomeEndpoint                = "/papi/v1/um/income"                 // 统一账户资金流水
 )
 
+// ConditionalOrderTypes 统一账户条件单类型（撤单时需要走条件单接口）
+var ConditionalOrderTypes = map[string]bool{
+	"STOP":                 true,
+	"STOP_MARKET":          true,
+	"TAKE_PROFIT":          true,
+	"TAKE_PROFIT_MARKET":   true,
+	"TRAILING_STOP_MARKET": true,
+}
+
 // APIError 自定义API错误
 type APIError struct {
 	Code            int    `json:"code"`
@@ -57,36 +79,30 @@ type Client struct {
 // NewClient 创建新的币安期货API客户端（公开接口，默认fapi）
 func NewClient() *Client {
 	return &Client{
-		baseURL: BinanceFuturesBaseURL,
-		apiType: "fapi",
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    BinanceFuturesBaseURL,
+		apiType:    "fapi",
+		httpClient: newHTTPClient(),
 	}
 }
 
 // NewClientWithAuth 创建带认证的币安期货API客户端
 func NewClientWithAuth(apiKey, secretKey string) *Client {
 	return &Client{
-		baseURL:   BinanceFuturesBaseURL,
-		apiKey:    apiKey,
-		secretKey: secretKey,
-		apiType:   "fapi",
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    BinanceFuturesBaseURL,
+		apiKey:     apiKey,
+		secretKey:  secretKey,
+		apiType:    "fapi",
+		httpClient: newHTTPClient(),
 	}
 }
 
 // NewClientWithConfig 根据配置创建客户端
 func NewClientWithConfig(apiKey, secretKey, apiType, baseURL string) *Client {
 	client := &Client{
-		apiKey:    apiKey,
-		secretKey: secretKey,
-		apiType:   apiType,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		apiKey:     apiKey,
+		secretKey:  secretKey,
+		apiType:    apiType,
+		httpClient: newHTTPClient(),
 	}
 
 	// 设置baseURL
@@ -172,16 +188,30 @@ func (c *Client) handleHTTPError(statusCode int, body []byte) error {
 // NewClientWithBaseURL 使用自定义基础URL创建客户端
 func NewClientWithBaseURL(baseURL string) *Client {
 	return &Client{
-		baseURL: baseURL,
-		apiType: "fapi",
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    baseURL,
+		apiType:    "fapi",
+		httpClient: newHTTPClient(),
 	}
 }
 
+// ErrNotModified exchangeInfo自上次拉取后未发生变化（HTTP 304）
+var ErrNotModified = errors.New("交易所信息未变化")
+
+// CacheValidator HTTP缓存校验信息（ETag / Last-Modified），用于条件请求
+type CacheValidator struct {
+	ETag         string
+	LastModified string
+}
+
 // GetExchangeInfo 获取交易所信息（始终使用fapi接口，无论papi还是fapi）
 func (c *Client) GetExchangeInfo() (*models.ExchangeInfo, error) {
+	return c.GetExchangeInfoIfModified(nil)
+}
+
+// GetExchangeInfoIfModified 使用条件请求获取交易所信息
+// validator不为空时携带If-None-Match/If-Modified-Since，服务端返回304时返回ErrNotModified，
+// 成功时用响应头中的ETag/Last-Modified更新validator
+func (c *Client) GetExchangeInfoIfModified(validator *CacheValidator) (*models.ExchangeInfo, error) {
 	// exchangeInfo接口统一使用fapi，不需要区分papi/fapi
 	url := fmt.Sprintf("%s%s", BinanceFuturesBaseURL, FAPIExchangeInfoEndpoint)
 
@@ -191,6 +221,14 @@ func (c *Client) GetExchangeInfo() (*models.ExchangeInfo, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if validator != nil {
+		if validator.ETag != "" {
+			req.Header.Set("If-None-Match", validator.ETag)
+		}
+		if validator.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validator.LastModified)
+		}
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -198,6 +236,10 @@ func (c *Client) GetExchangeInfo() (*models.ExchangeInfo, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("读取响应失败: %w", err)
@@ -212,6 +254,11 @@ func (c *Client) GetExchangeInfo() (*models.ExchangeInfo, error) {
 		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
 
+	if validator != nil {
+		validator.ETag = resp.Header.Get("ETag")
+		validator.LastModified = resp.Header.Get("Last-Modified")
+	}
+
 	return &exchangeInfo, nil
 }
 
@@ -600,6 +647,171 @@ func (c *Client) GetTickerPrice(symbol string) (*models.TickerPrice, error) {
 	return &tickerPrice, nil
 }
 
+// GetBookTicker 获取指定交易对的买一/卖一价格（始终使用fapi接口）
+func (c *Client) GetBookTicker(symbol string) (*models.BookTicker, error) {
+	url := fmt.Sprintf("%s%s?symbol=%s", BinanceFuturesBaseURL, FAPIBookTickerEndpoint, symbol)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleHTTPError(resp.StatusCode, body)
+	}
+
+	var bookTicker models.BookTicker
+	if err := json.Unmarshal(body, &bookTicker); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	return &bookTicker, nil
+}
+
+// GetDepth 获取指定交易对的盘口深度（始终使用fapi接口）
+// limit: 档位数量，可选 5/10/20/50/100/500/1000，0使用默认值500
+func (c *Client) GetDepth(symbol string, limit int) (*models.Depth, error) {
+	url := fmt.Sprintf("%s%s?symbol=%s", BinanceFuturesBaseURL, FAPIDepthEndpoint, symbol)
+	if limit > 0 {
+		url += fmt.Sprintf("&limit=%d", limit)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleHTTPError(resp.StatusCode, body)
+	}
+
+	var depth models.Depth
+	if err := json.Unmarshal(body, &depth); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	return &depth, nil
+}
+
+// GetPremiumIndex 获取指定交易对的标记价格、资金费率和下次资金费结算时间（始终使用fapi接口）
+func (c *Client) GetPremiumIndex(symbol string) (*models.PremiumIndex, error) {
+	url := fmt.Sprintf("%s%s?symbol=%s", BinanceFuturesBaseURL, FAPIPremiumIndexEndpoint, symbol)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleHTTPError(resp.StatusCode, body)
+	}
+
+	var premiumIndex models.PremiumIndex
+	if err := json.Unmarshal(body, &premiumIndex); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	return &premiumIndex, nil
+}
+
+// GetFundingRateHistory 查询历史资金费率，按结算时间从早到晚返回（始终使用fapi接口）
+func (c *Client) GetFundingRateHistory(params *models.FundingRateParams) ([]models.FundingRate, error) {
+	query := make(map[string]string)
+	if params.Symbol != "" {
+		query["symbol"] = params.Symbol
+	}
+	setTimeRangeParams(query, params.StartTime, params.EndTime, params.Limit)
+
+	requestURL := fmt.Sprintf("%s%s?%s", BinanceFuturesBaseURL, FAPIFundingRateEndpoint, BuildQueryString(query))
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleHTTPError(resp.StatusCode, body)
+	}
+
+	var rates []models.FundingRate
+	if err := json.Unmarshal(body, &rates); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return rates, nil
+}
+
+// GetFundingInfo 查询调整过资金费率上下限或结算间隔的交易对（始终使用fapi接口）
+func (c *Client) GetFundingInfo() ([]models.FundingInfo, error) {
+	req, err := http.NewRequest(http.MethodGet, BinanceFuturesBaseURL+FAPIFundingInfoEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleHTTPError(resp.StatusCode, body)
+	}
+
+	var infos []models.FundingInfo
+	if err := json.Unmarshal(body, &infos); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return infos, nil
+}
+
 // GetPositionRisk 查询持仓风险信息
 // symbol: 交易对，留空则查询所有持仓
 func (c *Client) GetPositionRisk(symbol string) ([]models.PositionRisk, error) {
@@ -673,6 +885,205 @@ func (c *Client) GetPositionRisk(symbol string) ([]models.PositionRisk, error) {
 	return positionRisks, nil
 }
 
+// APIType 返回客户端的API类型（fapi或papi）
+func (c *Client) APIType() string {
+	return c.apiType
+}
+
+// CancelOrder 撤销普通订单
+func (c *Client) CancelOrder(symbol string, orderID int64) (*models.OrderResponse, error) {
+	params := map[string]string{
+		"symbol":  symbol,
+		"orderId": strconv.FormatInt(orderID, 10),
+	}
+
+	body, err := c.doSignedRequest(http.MethodDelete, c.getEndpoint("order"), params)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderResp models.OrderResponse
+	if err := json.Unmarshal(body, &orderResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return &orderResp, nil
+}
+
+// CancelConditionalOrder 撤销条件单（统一账户专用）
+func (c *Client) CancelConditionalOrder(symbol string, strategyID int64) (*models.ConditionalOrderResponse, error) {
+	if c.apiType != "papi" {
+		return nil, fmt.Errorf("条件单接口仅适用于统一账户(papi)，当前API类型: %s", c.apiType)
+	}
+
+	params := map[string]string{
+		"symbol":     symbol,
+		"strategyId": strconv.FormatInt(strategyID, 10),
+	}
+
+	body, err := c.doSignedRequest(http.MethodDelete, PAPIConditionalOrderEndpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var condResp models.ConditionalOrderResponse
+	if err := json.Unmarshal(body, &condResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return &condResp, nil
+}
+
+// GetOpenOrders 查询当前挂单，symbol留空查询全部
+func (c *Client) GetOpenOrders(symbol string) ([]models.OrderResponse, error) {
+	params := make(map[string]string)
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+
+	endpoint := FAPIOpenOrdersEndpoint
+	if c.apiType == "papi" {
+		endpoint = PAPIOpenOrdersEndpoint
+	}
+	body, err := c.doSignedRequest(http.MethodGet, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []models.OrderResponse
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return orders, nil
+}
+
+// GetConditionalOpenOrders 查询当前条件单（统一账户专用），symbol留空查询全部
+func (c *Client) GetConditionalOpenOrders(symbol string) ([]models.ConditionalOrderResponse, error) {
+	if c.apiType != "papi" {
+		return nil, fmt.Errorf("条件单接口仅适用于统一账户(papi)，当前API类型: %s", c.apiType)
+	}
+
+	params := make(map[string]string)
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	body, err := c.doSignedRequest(http.MethodGet, PAPIConditionalOpenOrdersEndpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []models.ConditionalOrderResponse
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return orders, nil
+}
+
+// GetUserTrades 查询账户成交历史（包含每笔成交的已实现盈亏和手续费）
+func (c *Client) GetUserTrades(params *models.UserTradesParams) ([]models.UserTrade, error) {
+	if params.Symbol == "" {
+		return nil, fmt.Errorf("查询成交历史必须指定symbol")
+	}
+
+	query := map[string]string{"symbol": params.Symbol}
+	setTimeRangeParams(query, params.StartTime, params.EndTime, params.Limit)
+	if params.FromID > 0 {
+		query["fromId"] = strconv.FormatInt(params.FromID, 10)
+	}
+
+	endpoint := FAPIUserTradesEndpoint
+	if c.apiType == "papi" {
+		endpoint = PAPIUserTradesEndpoint
+	}
+	body, err := c.doSignedRequest(http.MethodGet, endpoint, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []models.UserTrade
+	if err := json.Unmarshal(body, &trades); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return trades, nil
+}
+
+// GetIncomeHistory 查询资金流水（已实现盈亏、手续费、资金费等）
+func (c *Client) GetIncomeHistory(params *models.IncomeParams) ([]models.Income, error) {
+	query := make(map[string]string)
+	if params.Symbol != "" {
+		query["symbol"] = params.Symbol
+	}
+	if params.IncomeType != "" {
+		query["incomeType"] = params.IncomeType
+	}
+	setTimeRangeParams(query, params.StartTime, params.EndTime, params.Limit)
+
+	endpoint := FAPIIncomeEndpoint
+	if c.apiType == "papi" {
+		endpoint = PAPIIncomeEndpoint
+	}
+	body, err := c.doSignedRequest(http.MethodGet, endpoint, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var incomes []models.Income
+	if err := json.Unmarshal(body, &incomes); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return incomes, nil
+}
+
+// setTimeRangeParams 设置startTime/endTime/limit参数，零值不设置
+func setTimeRangeParams(query map[string]string, startTime, endTime int64, limit int) {
+	if startTime > 0 {
+		query["startTime"] = strconv.FormatInt(startTime, 10)
+	}
+	if endTime > 0 {
+		query["endTime"] = strconv.FormatInt(endTime, 10)
+	}
+	if limit > 0 {
+		query["limit"] = strconv.Itoa(limit)
+	}
+}
+
+// doSignedRequest 发送带签名的请求，返回响应体
+func (c *Client) doSignedRequest(method, endpoint string, params map[string]string) ([]byte, error) {
+	if c.apiKey == "" || c.secretKey == "" {
+		return nil, fmt.Errorf("API密钥和密钥未设置，请使用NewClientWithAuth创建客户端")
+	}
+
+	params["recvWindow"] = "10000" // 10秒窗口
+	params["timestamp"] = strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	// 构建查询字符串并签名
+	queryString := BuildQueryString(params)
+	signature := signQueryString(queryString, c.secretKey)
+	requestURL := fmt.Sprintf("%s%s?%s&signature=%s", c.baseURL, endpoint, queryString, signature)
+
+	httpReq, err := http.NewRequest(method, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	httpReq.Header.Set("X-MBX-APIKEY", c.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleHTTPError(resp.StatusCode, body)
+	}
+	return body, nil
+}
+
 // signQueryString 对查询字符串进行HMAC SHA256签名
 func signQueryString(queryString, secretKey string) string {
 	return SignQueryString(queryString, secretKey)
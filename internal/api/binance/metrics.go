@@ -0,0 +1,33 @@
+package binance
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"new_listing_trade/internal/metrics"
+)
+
+// metricsTransport 记录每个REST请求的耗时和状态码
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.BinanceRequestDuration.Observe(time.Since(start).Seconds(), req.URL.Path, status)
+	return resp, err
+}
+
+// newHTTPClient 创建带指标统计的HTTP客户端
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &metricsTransport{next: http.DefaultTransport},
+	}
+}
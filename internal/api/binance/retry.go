@@ -1,9 +1,12 @@
 package binance
 
 import (
+	"errors"
+	"net/http"
 	"time"
 
 	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/metrics"
 )
 
 // RetryConfig 重试配置
@@ -41,6 +44,7 @@ func RetryWithBackoff(fn func() error, config RetryConfig) error {
 			// 不是APIError，可能是网络错误，可以重试
 			if i < config.MaxRetries-1 {
 				logger.Warnf("请求失败，%v后重试: %v", delay, err)
+				metrics.BinanceRetries.Inc("network")
 				time.Sleep(delay)
 				delay *= 2
 				if delay > config.MaxDelay {
@@ -61,6 +65,7 @@ func RetryWithBackoff(fn func() error, config RetryConfig) error {
 		if apiErr.IsRetryable {
 			if i < config.MaxRetries-1 {
 				logger.Warnf("API错误（可重试），%v后重试: %v", delay, err)
+				metrics.BinanceRetries.Inc("api")
 				time.Sleep(delay)
 				delay *= 2
 				if delay > config.MaxDelay {
@@ -76,3 +81,13 @@ func RetryWithBackoff(fn func() error, config RetryConfig) error {
 
 	return lastErr
 }
+
+// IsRateLimited 是否为限流错误（HTTP 429/418，或请求/下单频率超限 -1003/-1015）
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusTeapot ||
+		apiErr.Code == -1003 || apiErr.Code == -1015
+}
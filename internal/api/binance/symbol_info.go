@@ -19,33 +19,86 @@ func GetSymbolInfo(exchangeInfo *models.ExchangeInfo, symbol string) (*models.Sy
 	return nil, fmt.Errorf("交易对 %s 不存在", symbol)
 }
 
+// GetFilter 获取交易对指定类型的过滤器，不存在时返回nil
+func GetFilter(symbolInfo *models.Symbol, filterType string) *models.Filter {
+	for i := range symbolInfo.Filters {
+		if symbolInfo.Filters[i].FilterType == filterType {
+			return &symbolInfo.Filters[i]
+		}
+	}
+	return nil
+}
+
+// IsMarketOrderType 是否为按市价成交的订单类型（MARKET、STOP_MARKET、TAKE_PROFIT_MARKET等），这类订单使用MARKET_LOT_SIZE
+func IsMarketOrderType(orderType string) bool {
+	return strings.HasSuffix(orderType, "MARKET")
+}
+
+// GetLotSizeFilter 获取订单类型对应的数量过滤器：市价类订单优先使用MARKET_LOT_SIZE中大于0的字段，其余沿用LOT_SIZE
+func GetLotSizeFilter(symbolInfo *models.Symbol, orderType string) models.Filter {
+	lot := models.Filter{FilterType: models.FilterTypeLotSize}
+	if filter := GetFilter(symbolInfo, models.FilterTypeLotSize); filter != nil {
+		lot = *filter
+	}
+	if !IsMarketOrderType(orderType) {
+		return lot
+	}
+	if filter := GetFilter(symbolInfo, models.FilterTypeMarketLotSize); filter != nil {
+		lot.FilterType = models.FilterTypeMarketLotSize
+		if positiveDecimal(filter.MinQty) {
+			lot.MinQty = filter.MinQty
+		}
+		if positiveDecimal(filter.MaxQty) {
+			lot.MaxQty = filter.MaxQty
+		}
+		if positiveDecimal(filter.StepSize) {
+			lot.StepSize = filter.StepSize
+		}
+	}
+	return lot
+}
+
+// positiveDecimal 字符串是否为大于0的数字
+func positiveDecimal(value string) bool {
+	f, err := strconv.ParseFloat(value, 64)
+	return err == nil && f > 0
+}
+
 // GetStepSize 获取交易对的stepSize（数量步进值）
 func GetStepSize(symbolInfo *models.Symbol) (float64, error) {
-	for _, filter := range symbolInfo.Filters {
-		if filter.FilterType == "LOT_SIZE" && filter.StepSize != "" {
-			stepSize, err := strconv.ParseFloat(filter.StepSize, 64)
-			if err != nil {
-				return 0, fmt.Errorf("解析stepSize失败: %w", err)
-			}
-			return stepSize, nil
-		}
+	return GetOrderStepSize(symbolInfo, "LIMIT")
+}
+
+// GetOrderStepSize 获取订单类型对应的stepSize
+func GetOrderStepSize(symbolInfo *models.Symbol, orderType string) (float64, error) {
+	lot := GetLotSizeFilter(symbolInfo, orderType)
+	if lot.StepSize == "" {
+		// 如果没有找到LOT_SIZE过滤器，返回默认值0.00000001（8位小数）
+		return 0.00000001, nil
 	}
-	// 如果没有找到LOT_SIZE过滤器，返回默认值0.00000001（8位小数）
-	return 0.00000001, nil
+	stepSize, err := strconv.ParseFloat(lot.StepSize, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析stepSize失败: %w", err)
+	}
+	return stepSize, nil
 }
 
 // GetMinQty 获取交易对的最小交易量
 func GetMinQty(symbolInfo *models.Symbol) (float64, error) {
-	for _, filter := range symbolInfo.Filters {
-		if filter.FilterType == "LOT_SIZE" && filter.MinQty != "" {
-			minQty, err := strconv.ParseFloat(filter.MinQty, 64)
-			if err != nil {
-				return 0, fmt.Errorf("解析minQty失败: %w", err)
-			}
-			return minQty, nil
-		}
+	return GetOrderMinQty(symbolInfo, "LIMIT")
+}
+
+// GetOrderMinQty 获取订单类型对应的最小交易量
+func GetOrderMinQty(symbolInfo *models.Symbol, orderType string) (float64, error) {
+	lot := GetLotSizeFilter(symbolInfo, orderType)
+	if lot.MinQty == "" {
+		return 0, nil
 	}
-	return 0, nil
+	minQty, err := strconv.ParseFloat(lot.MinQty, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析minQty失败: %w", err)
+	}
+	return minQty, nil
 }
 
 // AdjustQuantity 根据stepSize调整quantity精度
@@ -111,26 +164,26 @@ func getPrecision(stepSizeStr string) int {
 	return len(decimalPart)
 }
 
-// ValidateAndAdjustQuantity 验证并调整quantity，确保符合交易对规则
+// ValidateAndAdjustQuantity 验证并调整限价单的quantity，确保符合交易对的LOT_SIZE规则
 func ValidateAndAdjustQuantity(quantity float64, symbolInfo *models.Symbol) (string, error) {
-	minQty, err := GetMinQty(symbolInfo)
+	return ValidateAndAdjustOrderQuantity(quantity, "LIMIT", symbolInfo)
+}
+
+// ValidateAndAdjustOrderQuantity 按订单类型验证并调整quantity：市价类订单使用MARKET_LOT_SIZE，其余使用LOT_SIZE
+func ValidateAndAdjustOrderQuantity(quantity float64, orderType string, symbolInfo *models.Symbol) (string, error) {
+	minQty, err := GetOrderMinQty(symbolInfo, orderType)
 	if err != nil {
 		return "", err
 	}
 
-	stepSize, err := GetStepSize(symbolInfo)
+	stepSize, err := GetOrderStepSize(symbolInfo, orderType)
 	if err != nil {
 		return "", err
 	}
 
 	// 获取stepSize字符串（用于精度计算）
-	stepSizeStr := ""
-	for _, filter := range symbolInfo.Filters {
-		if filter.FilterType == "LOT_SIZE" && filter.StepSize != "" {
-			stepSizeStr = filter.StepSize
-			break
-		}
-	}
+	lot := GetLotSizeFilter(symbolInfo, orderType)
+	stepSizeStr := lot.StepSize
 	if stepSizeStr == "" {
 		stepSizeStr = "0.00000001" // 默认值
 	}
@@ -149,16 +202,67 @@ func ValidateAndAdjustQuantity(quantity float64, symbolInfo *models.Symbol) (str
 		adjusted = math.Ceil(minQty/stepSize) * stepSize
 	}
 
+	// 确保quantity <= maxQty（新币的MARKET_LOT_SIZE上限往往很低）
+	if positiveDecimal(lot.MaxQty) {
+		maxQty, _ := strconv.ParseFloat(lot.MaxQty, 64)
+		if adjusted > maxQty {
+			return "", fmt.Errorf("交易数量 %.8f 超过%s最大交易量 %s", adjusted, lot.FilterType, lot.MaxQty)
+		}
+	}
+
 	// 格式化quantity
 	quantityStr := FormatQuantity(adjusted, stepSize, stepSizeStr)
 
 	return quantityStr, nil
 }
 
+// CheckMinNotional 检查开仓单的名义价值（数量×价格）是否达到MIN_NOTIONAL，只减仓的平仓单不受该限制
+func CheckMinNotional(quantity, price float64, symbolInfo *models.Symbol) error {
+	filter := GetFilter(symbolInfo, models.FilterTypeMinNotional)
+	if filter == nil || !positiveDecimal(filter.Notional) {
+		return nil
+	}
+	minNotional, _ := strconv.ParseFloat(filter.Notional, 64)
+	if notional := quantity * price; notional < minNotional {
+		return fmt.Errorf("订单名义价值 %.4f 小于最小名义价值 %s", notional, filter.Notional)
+	}
+	return nil
+}
+
+// GetPercentPriceBounds 按PERCENT_PRICE计算相对标记价格的价格上下限，没有该过滤器时ok为false
+func GetPercentPriceBounds(markPrice float64, symbolInfo *models.Symbol) (lower, upper float64, ok bool) {
+	filter := GetFilter(symbolInfo, models.FilterTypePercentPrice)
+	if filter == nil {
+		return 0, 0, false
+	}
+	multiplierUp, _ := strconv.ParseFloat(filter.MultiplierUp, 64)
+	multiplierDown, _ := strconv.ParseFloat(filter.MultiplierDown, 64)
+	if multiplierUp <= 0 && multiplierDown <= 0 {
+		return 0, 0, false
+	}
+	upper = math.Inf(1)
+	if multiplierUp > 0 {
+		upper = markPrice * multiplierUp
+	}
+	return markPrice * multiplierDown, upper, true
+}
+
+// CheckPercentPrice 检查价格是否在PERCENT_PRICE允许的范围内（标记价格 × multiplierDown ~ 标记价格 × multiplierUp）
+func CheckPercentPrice(price, markPrice float64, symbolInfo *models.Symbol) error {
+	lower, upper, ok := GetPercentPriceBounds(markPrice, symbolInfo)
+	if !ok || markPrice <= 0 {
+		return nil
+	}
+	if price < lower || price > upper {
+		return fmt.Errorf("价格 %.8f 超出PERCENT_PRICE范围 %.8f ~ %.8f（标记价格 %.8f）", price, lower, upper, markPrice)
+	}
+	return nil
+}
+
 // GetTickSize 获取交易对的tickSize（价格步进值）
 func GetTickSize(symbolInfo *models.Symbol) (float64, error) {
 	for _, filter := range symbolInfo.Filters {
-		if filter.FilterType == "PRICE_FILTER" && filter.TickSize != "" {
+		if filter.FilterType == models.FilterTypePrice && filter.TickSize != "" {
 			tickSize, err := strconv.ParseFloat(filter.TickSize, 64)
 			if err != nil {
 				return 0, fmt.Errorf("解析tickSize失败: %w", err)
@@ -173,7 +277,7 @@ func GetTickSize(symbolInfo *models.Symbol) (float64, error) {
 // GetMinPrice 获取交易对的最小价格
 func GetMinPrice(symbolInfo *models.Symbol) (float64, error) {
 	for _, filter := range symbolInfo.Filters {
-		if filter.FilterType == "PRICE_FILTER" && filter.MinPrice != "" {
+		if filter.FilterType == models.FilterTypePrice && filter.MinPrice != "" {
 			minPrice, err := strconv.ParseFloat(filter.MinPrice, 64)
 			if err != nil {
 				return 0, fmt.Errorf("解析minPrice失败: %w", err)
@@ -225,7 +329,7 @@ func ValidateAndAdjustPrice(price float64, symbolInfo *models.Symbol) (string, e
 	// 获取tickSize字符串（用于精度计算）
 	tickSizeStr := ""
 	for _, filter := range symbolInfo.Filters {
-		if filter.FilterType == "PRICE_FILTER" && filter.TickSize != "" {
+		if filter.FilterType == models.FilterTypePrice && filter.TickSize != "" {
 			tickSizeStr = filter.TickSize
 			break
 		}
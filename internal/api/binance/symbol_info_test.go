@@ -0,0 +1,89 @@
+package binance
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"new_listing_trade/internal/models"
+)
+
+// testSymbolJSON 币安期货exchangeInfo中的交易对（新币的MARKET_LOT_SIZE上限远小于LOT_SIZE）
+const testSymbolJSON = `{
+	"symbol": "NEWUSDT",
+	"status": "TRADING",
+	"filters": [
+		{"filterType": "PRICE_FILTER", "minPrice": "0.000100", "maxPrice": "200", "tickSize": "0.000100"},
+		{"filterType": "LOT_SIZE", "minQty": "1", "maxQty": "10000000", "stepSize": "1"},
+		{"filterType": "MARKET_LOT_SIZE", "minQty": "1", "maxQty": "500", "stepSize": "1"},
+		{"filterType": "MAX_NUM_ORDERS", "limit": 200},
+		{"filterType": "MAX_NUM_ALGO_ORDERS", "limit": 10},
+		{"filterType": "MIN_NOTIONAL", "notional": "5"},
+		{"filterType": "PERCENT_PRICE", "multiplierUp": "1.0500", "multiplierDown": "0.9500", "multiplierDecimal": "4"}
+	]
+}`
+
+func testSymbol(t *testing.T) *models.Symbol {
+	t.Helper()
+	var symbol models.Symbol
+	if err := json.Unmarshal([]byte(testSymbolJSON), &symbol); err != nil {
+		t.Fatalf("解析交易对失败: %v", err)
+	}
+	return &symbol
+}
+
+func TestParseFilters(t *testing.T) {
+	symbol := testSymbol(t)
+	if f := GetFilter(symbol, models.FilterTypeMaxNumAlgoOrders); f == nil || f.Limit != 10 {
+		t.Errorf("应解析最大条件单数: %+v", f)
+	}
+	if f := GetFilter(symbol, models.FilterTypeMinNotional); f == nil || f.Notional != "5" {
+		t.Errorf("应解析最小名义价值: %+v", f)
+	}
+	if f := GetFilter(symbol, models.FilterTypePercentPrice); f == nil || f.MultiplierUp != "1.0500" || f.MultiplierDown != "0.9500" {
+		t.Errorf("应解析PERCENT_PRICE: %+v", f)
+	}
+	if lot := GetLotSizeFilter(symbol, "STOP_MARKET"); lot.FilterType != models.FilterTypeMarketLotSize || lot.MaxQty != "500" {
+		t.Errorf("市价止损单应使用MARKET_LOT_SIZE: %+v", lot)
+	}
+}
+
+func TestValidateAndAdjustOrderQuantity(t *testing.T) {
+	symbol := testSymbol(t)
+
+	if qty, err := ValidateAndAdjustOrderQuantity(12.7, "MARKET", symbol); err != nil || qty != "12" {
+		t.Errorf("市价单应按stepSize向下取整为12，实际: %s, %v", qty, err)
+	}
+	if _, err := ValidateAndAdjustOrderQuantity(600, "MARKET", symbol); err == nil || !strings.Contains(err.Error(), "MARKET_LOT_SIZE") {
+		t.Errorf("市价单数量超过MARKET_LOT_SIZE上限应返回错误，实际: %v", err)
+	}
+	if qty, err := ValidateAndAdjustQuantity(600, symbol); err != nil || qty != "600" {
+		t.Errorf("限价单应使用LOT_SIZE上限，实际: %s, %v", qty, err)
+	}
+}
+
+func TestCheckMinNotional(t *testing.T) {
+	symbol := testSymbol(t)
+	if err := CheckMinNotional(4, 1, symbol); err == nil {
+		t.Error("名义价值4小于5应返回错误")
+	}
+	if err := CheckMinNotional(5, 1, symbol); err != nil {
+		t.Errorf("名义价值达到5不应返回错误: %v", err)
+	}
+}
+
+func TestCheckPercentPrice(t *testing.T) {
+	symbol := testSymbol(t)
+	if err := CheckPercentPrice(1.04, 1, symbol); err != nil {
+		t.Errorf("1.04在范围 0.95~1.05 内: %v", err)
+	}
+	if err := CheckPercentPrice(1.06, 1, symbol); err == nil {
+		t.Error("1.06超过上限1.05应返回错误")
+	}
+	if err := CheckPercentPrice(0.94, 1, symbol); err == nil {
+		t.Error("0.94低于下限0.95应返回错误")
+	}
+	if err := CheckPercentPrice(100, 1, &models.Symbol{}); err != nil {
+		t.Errorf("没有PERCENT_PRICE过滤器时不检查: %v", err)
+	}
+}
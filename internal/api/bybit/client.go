@@ -0,0 +1,232 @@
+package bybit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// BybitBaseURL Bybit V5 API基础URL
+	BybitBaseURL = "https://api.bybit.com"
+
+	// V5端点（USDT永续使用category=linear）
+	InstrumentsInfoEndpoint = "/v5/market/instruments-info"
+	TickersEndpoint         = "/v5/market/tickers"
+	OrderCreateEndpoint     = "/v5/order/create"
+	OrderCancelEndpoint     = "/v5/order/cancel"
+	PositionListEndpoint    = "/v5/position/list"
+
+	// CategoryLinear USDT/USDC永续合约
+	CategoryLinear = "linear"
+
+	defaultRecvWindow = "10000" // 10秒窗口
+)
+
+// APIError Bybit接口错误（retCode非0）
+type APIError struct {
+	RetCode    int
+	RetMsg     string
+	StatusCode int // HTTP状态码
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Bybit API错误: retCode=%d, retMsg=%s, status=%d", e.RetCode, e.RetMsg, e.StatusCode)
+}
+
+// Client Bybit V5 API客户端
+type Client struct {
+	baseURL    string
+	apiKey     string
+	secretKey  string
+	httpClient *http.Client
+}
+
+// NewClient 创建Bybit客户端，baseURL留空使用生产环境
+func NewClient(apiKey, secretKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = BybitBaseURL
+	}
+	return &Client{
+		baseURL:   baseURL,
+		apiKey:    apiKey,
+		secretKey: secretKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// GetInstruments 获取所有USDT永续合约信息（自动翻页）
+func (c *Client) GetInstruments() ([]Instrument, error) {
+	var instruments []Instrument
+	cursor := ""
+	for {
+		query := url.Values{}
+		query.Set("category", CategoryLinear)
+		query.Set("limit", "1000")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		var result instrumentsResult
+		if err := c.doRequest(http.MethodGet, InstrumentsInfoEndpoint, query, nil, false, &result); err != nil {
+			return nil, err
+		}
+		instruments = append(instruments, result.List...)
+
+		if result.NextPageCursor == "" {
+			break
+		}
+		cursor = result.NextPageCursor
+	}
+	return instruments, nil
+}
+
+// GetTicker 获取指定合约的行情
+func (c *Client) GetTicker(symbol string) (*Ticker, error) {
+	query := url.Values{}
+	query.Set("category", CategoryLinear)
+	query.Set("symbol", symbol)
+
+	var result tickersResult
+	if err := c.doRequest(http.MethodGet, TickersEndpoint, query, nil, false, &result); err != nil {
+		return nil, err
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("合约 %s 无行情数据", symbol)
+	}
+	return &result.List[0], nil
+}
+
+// CreateOrder 下单（市价单或条件单）
+func (c *Client) CreateOrder(req *OrderRequest) (*OrderResult, error) {
+	if req.Category == "" {
+		req.Category = CategoryLinear
+	}
+
+	var result OrderResult
+	if err := c.doRequest(http.MethodPost, OrderCreateEndpoint, nil, req, true, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelOrder 撤单，orderID和orderLinkID二选一
+func (c *Client) CancelOrder(symbol, orderID, orderLinkID string) (*OrderResult, error) {
+	req := &CancelOrderRequest{
+		Category:    CategoryLinear,
+		Symbol:      symbol,
+		OrderID:     orderID,
+		OrderLinkID: orderLinkID,
+	}
+
+	var result OrderResult
+	if err := c.doRequest(http.MethodPost, OrderCancelEndpoint, nil, req, true, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetPositions 查询USDT永续持仓，symbol留空查询全部
+func (c *Client) GetPositions(symbol string) ([]Position, error) {
+	query := url.Values{}
+	query.Set("category", CategoryLinear)
+	if symbol != "" {
+		query.Set("symbol", symbol)
+	} else {
+		query.Set("settleCoin", "USDT")
+	}
+
+	var result positionsResult
+	if err := c.doRequest(http.MethodGet, PositionListEndpoint, query, nil, true, &result); err != nil {
+		return nil, err
+	}
+	return result.List, nil
+}
+
+// doRequest 发送请求并解析result字段
+// 签名规则：HMAC_SHA256(timestamp + apiKey + recvWindow + payload)，GET的payload为查询字符串，POST为JSON请求体
+func (c *Client) doRequest(method, endpoint string, query url.Values, body interface{}, signed bool, out interface{}) error {
+	if signed && (c.apiKey == "" || c.secretKey == "") {
+		return fmt.Errorf("Bybit API密钥未设置")
+	}
+
+	queryString := ""
+	if query != nil {
+		queryString = query.Encode()
+	}
+
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+	}
+
+	requestURL := c.baseURL + endpoint
+	if queryString != "" {
+		requestURL += "?" + queryString
+	}
+
+	httpReq, err := http.NewRequest(method, requestURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if signed {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		payload := queryString
+		if method != http.MethodGet {
+			payload = string(bodyBytes)
+		}
+		httpReq.Header.Set("X-BAPI-API-KEY", c.apiKey)
+		httpReq.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+		httpReq.Header.Set("X-BAPI-RECV-WINDOW", defaultRecvWindow)
+		httpReq.Header.Set("X-BAPI-SIGN", sign(timestamp+c.apiKey+defaultRecvWindow+payload, c.secretKey))
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var envelope response
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("API返回错误状态码 %d: %s", resp.StatusCode, string(respBody))
+	}
+	if resp.StatusCode != http.StatusOK || envelope.RetCode != 0 {
+		return &APIError{RetCode: envelope.RetCode, RetMsg: envelope.RetMsg, StatusCode: resp.StatusCode}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(envelope.Result, out); err != nil {
+			return fmt.Errorf("解析响应失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// sign HMAC SHA256签名（十六进制）
+func sign(payload, secretKey string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
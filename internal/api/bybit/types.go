@@ -0,0 +1,108 @@
+package bybit
+
+import "encoding/json"
+
+// response Bybit V5统一响应格式
+type response struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+	Time    int64           `json:"time"`
+}
+
+// Instrument 合约信息
+type Instrument struct {
+	Symbol       string `json:"symbol"`
+	ContractType string `json:"contractType"` // LinearPerpetual/LinearFutures
+	Status       string `json:"status"`       // Trading/PreLaunch/Settling/Closed
+	BaseCoin     string `json:"baseCoin"`
+	QuoteCoin    string `json:"quoteCoin"`
+	LaunchTime   string `json:"launchTime"` // 上线时间（毫秒时间戳字符串）
+	PriceFilter  struct {
+		MinPrice string `json:"minPrice"`
+		MaxPrice string `json:"maxPrice"`
+		TickSize string `json:"tickSize"`
+	} `json:"priceFilter"`
+	LotSizeFilter struct {
+		MaxOrderQty         string `json:"maxOrderQty"`
+		MinOrderQty         string `json:"minOrderQty"`
+		QtyStep             string `json:"qtyStep"`
+		MaxMktOrderQty      string `json:"maxMktOrderQty"`
+		MinNotionalValue    string `json:"minNotionalValue"`
+		PostOnlyMaxOrderQty string `json:"postOnlyMaxOrderQty"`
+	} `json:"lotSizeFilter"`
+}
+
+type instrumentsResult struct {
+	Category       string       `json:"category"`
+	List           []Instrument `json:"list"`
+	NextPageCursor string       `json:"nextPageCursor"`
+}
+
+// Ticker 行情
+type Ticker struct {
+	Symbol      string `json:"symbol"`
+	LastPrice   string `json:"lastPrice"`
+	MarkPrice   string `json:"markPrice"`
+	Bid1Price   string `json:"bid1Price"`
+	Ask1Price   string `json:"ask1Price"`
+	FundingRate string `json:"fundingRate"`
+}
+
+type tickersResult struct {
+	Category string   `json:"category"`
+	List     []Ticker `json:"list"`
+}
+
+// OrderRequest 下单请求
+type OrderRequest struct {
+	Category         string `json:"category"`
+	Symbol           string `json:"symbol"`
+	Side             string `json:"side"`      // Buy/Sell
+	OrderType        string `json:"orderType"` // Market/Limit
+	Qty              string `json:"qty"`
+	Price            string `json:"price,omitempty"`
+	TriggerPrice     string `json:"triggerPrice,omitempty"`     // 条件单触发价格
+	TriggerDirection int    `json:"triggerDirection,omitempty"` // 1: 价格上涨触发 2: 价格下跌触发
+	TriggerBy        string `json:"triggerBy,omitempty"`        // LastPrice/MarkPrice/IndexPrice
+	PositionIdx      int    `json:"positionIdx"`                // 0: 单向持仓 1: 双向多 2: 双向空
+	ReduceOnly       bool   `json:"reduceOnly,omitempty"`
+	CloseOnTrigger   bool   `json:"closeOnTrigger,omitempty"`
+	OrderLinkID      string `json:"orderLinkId,omitempty"` // 用户自定义订单号
+}
+
+// CancelOrderRequest 撤单请求
+type CancelOrderRequest struct {
+	Category    string `json:"category"`
+	Symbol      string `json:"symbol"`
+	OrderID     string `json:"orderId,omitempty"`
+	OrderLinkID string `json:"orderLinkId,omitempty"`
+}
+
+// OrderResult 下单/撤单结果
+type OrderResult struct {
+	OrderID     string `json:"orderId"`
+	OrderLinkID string `json:"orderLinkId"`
+}
+
+// Position 持仓
+type Position struct {
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"` // Buy/Sell，空仓时为空
+	Size          string `json:"size"`
+	AvgPrice      string `json:"avgPrice"`
+	MarkPrice     string `json:"markPrice"`
+	PositionValue string `json:"positionValue"`
+	UnrealisedPnl string `json:"unrealisedPnl"`
+	LiqPrice      string `json:"liqPrice"`
+	Leverage      string `json:"leverage"`
+	TradeMode     int    `json:"tradeMode"` // 0: 全仓 1: 逐仓
+	PositionIdx   int    `json:"positionIdx"`
+	PositionIM    string `json:"positionIM"`
+	UpdatedTime   string `json:"updatedTime"`
+}
+
+type positionsResult struct {
+	Category string     `json:"category"`
+	List     []Position `json:"list"`
+}
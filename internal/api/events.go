@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/logger"
+)
+
+// sseHeartbeatInterval SSE心跳间隔，防止代理断开空闲连接
+const sseHeartbeatInterval = 15 * time.Second
+
+// SetEventBus 设置事件总线，启用 GET /api/events 和 GET /api/events/ws
+func (s *Server) SetEventBus(bus *events.Bus) {
+	s.eventBus = bus
+}
+
+// subscribeEvents 根据请求参数订阅事件
+// types: 逗号分隔的事件类型；last_event_id 或 Last-Event-ID 请求头: 从该事件之后续传
+func (s *Server) subscribeEvents(c *gin.Context) (*events.Subscription, []events.Event, bool) {
+	if s.eventBus == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "事件推送未启用",
+		})
+		return nil, nil, false
+	}
+
+	lastIDStr := c.Query("last_event_id")
+	if lastIDStr == "" {
+		lastIDStr = c.GetHeader("Last-Event-ID")
+	}
+	var lastID uint64
+	if lastIDStr != "" {
+		var err error
+		lastID, err = strconv.ParseUint(lastIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("无效的事件ID: %s", lastIDStr),
+			})
+			return nil, nil, false
+		}
+	}
+
+	sub, replay := s.eventBus.Subscribe(events.ParseFilter(c.Query("types")), lastID)
+	return sub, replay, true
+}
+
+// handleEventsSSE 以Server-Sent Events推送事件
+func (s *Server) handleEventsSSE(c *gin.Context) {
+	sub, replay, ok := s.subscribeEvents(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, e := range replay {
+		writeSSE(c.Writer, e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-s.closing:
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			writeSSE(c.Writer, e)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeSSE 输出单个SSE事件
+func writeSSE(w http.ResponseWriter, e events.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		logger.Warnf("序列化事件失败: %v", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+// handleEventsWebSocket 以WebSocket推送事件（每条消息为一个JSON事件）
+func (s *Server) handleEventsWebSocket(c *gin.Context) {
+	sub, replay, ok := s.subscribeEvents(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	server := websocket.Server{
+		Handshake: s.checkWebSocketOrigin,
+		Handler: func(conn *websocket.Conn) {
+			// 客户端不需要发送消息，读取只用于检测连接关闭
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard string
+				for websocket.Message.Receive(conn, &discard) == nil {
+				}
+			}()
+
+			for _, e := range replay {
+				if err := websocket.JSON.Send(conn, e); err != nil {
+					return
+				}
+			}
+			for {
+				select {
+				case <-closed:
+					return
+				case <-s.closing:
+					return
+				case e, ok := <-sub.C:
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(conn, e); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkWebSocketOrigin 校验WebSocket请求的Origin（未配置allowed_origins或非浏览器请求时放行）
+func (s *Server) checkWebSocketOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" || len(s.allowedOrigins) == 0 {
+		return nil
+	}
+	for _, allowed := range s.allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return nil
+		}
+	}
+	logger.Warnf("拒绝WebSocket连接: Origin %s 不在允许列表中, IP: %s", origin, req.RemoteAddr)
+	return fmt.Errorf("origin %s 不允许", origin)
+}
+
+// handleRecentEvents 返回最近的事件（?types= 过滤类型，?limit= 最多返回条数，默认50）
+func (s *Server) handleRecentEvents(c *gin.Context) {
+	if s.eventBus == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "事件推送未启用",
+		})
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "无效的limit: " + limitStr,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	c.JSON(http.StatusOK, s.eventBus.Recent(events.ParseFilter(c.Query("types")), limit))
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"new_listing_trade/internal/events"
+)
+
+func newEventsTestServer(bus *events.Bus) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	s := &Server{engine: gin.New(), eventBus: bus, closing: make(chan struct{})}
+	s.engine.GET("/api/events", s.handleEventsSSE)
+	s.engine.GET("/api/events/ws", s.handleEventsWebSocket)
+	return httptest.NewServer(s.engine)
+}
+
+func TestEventsSSEResume(t *testing.T) {
+	bus := events.NewBus(10)
+	bus.Publish(events.Event{Type: events.TypeListingDiscovered, Symbol: "AAAUSDT"})
+	bus.Publish(events.Event{Type: events.TypeOrderPlaced, Symbol: "BBBUSDT"})
+	bus.Publish(events.Event{Type: events.TypeError, Symbol: "CCCUSDT"})
+
+	ts := newEventsTestServer(bus)
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/api/events?types=order_placed,error", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("连接SSE失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type不正确: %s", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("读取SSE失败: %v", err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != "id: 3" || lines[1] != "event: error" || !strings.Contains(lines[2], `"symbol":"CCCUSDT"`) {
+		t.Errorf("续传事件不正确: %v", lines)
+	}
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	bus := events.NewBus(10)
+	ts := newEventsTestServer(bus)
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/events/ws?types=stop_loss_triggered"
+	conn, err := websocket.Dial(wsURL, "", ts.URL)
+	if err != nil {
+		t.Fatalf("连接WebSocket失败: %v", err)
+	}
+	defer conn.Close()
+
+	bus.Publish(events.Event{Type: events.TypeOrderPlaced, Symbol: "AAAUSDT"})
+	bus.Publish(events.Event{Type: events.TypeStopLossTriggered, Symbol: "BBBUSDT"})
+
+	var e events.Event
+	if err := websocket.JSON.Receive(conn, &e); err != nil {
+		t.Fatalf("接收事件失败: %v", err)
+	}
+	if e.Type != events.TypeStopLossTriggered || e.Symbol != "BBBUSDT" || e.ID != 2 {
+		t.Errorf("收到的事件不正确: %+v", e)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"new_listing_trade/internal/export"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/service"
+)
+
+// handleExport 导出数据集 GET /api/export/:dataset?format=csv|jsonl|xlsx
+// 支持 account/symbol/from/to 筛选，参数含义与 /api/trades 相同
+func (s *Server) handleExport(c *gin.Context) {
+	dataset := c.Param("dataset")
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if err := export.ValidDataset(dataset); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err := export.ValidFormat(format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	table, ok := s.buildExportTable(c, dataset)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.%s", dataset, time.Now().Format("20060102_150405"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+	if err := export.Write(c.Writer, format, table); err != nil {
+		logger.Errorf("导出 %s 失败: %v", dataset, err)
+	}
+}
+
+// buildExportTable 查询数据集，出错时已写入错误响应
+func (s *Server) buildExportTable(c *gin.Context, dataset string) (*export.Table, bool) {
+	// 新币对来自监控服务，不需要交易服务
+	if dataset == export.DatasetListings {
+		return s.buildListingsTable(c)
+	}
+
+	if !s.requireTradingService(c) {
+		return nil, false
+	}
+	query, ok := s.bindTradeQuery(c)
+	if !ok {
+		return nil, false
+	}
+
+	table, err := s.queryExportTable(dataset, query)
+	if err != nil {
+		logger.Errorf("导出 %s 失败: %v", dataset, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "导出失败: " + err.Error(),
+		})
+		return nil, false
+	}
+	return table, true
+}
+
+// buildListingsTable 按币对和上线时间筛选新币对
+func (s *Server) buildListingsTable(c *gin.Context) (*export.Table, bool) {
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return nil, false
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return nil, false
+	}
+	listings := export.FilterListings(s.symbolMonitor.GetNewListings(), strings.ToUpper(c.Query("symbol")), from, to)
+	return export.ListingsTable(listings), true
+}
+
+// queryExportTable 从交易服务查询需要导出的数据
+func (s *Server) queryExportTable(dataset string, query service.TradeQuery) (*export.Table, error) {
+	switch dataset {
+	case export.DatasetOrders:
+		entries, err := s.tradingService.GetJournalEntries(query)
+		if err != nil {
+			return nil, err
+		}
+		return export.OrdersTable(entries), nil
+	case export.DatasetFills, export.DatasetTrades:
+		records, err := s.tradingService.GetTrades(query)
+		if err != nil {
+			return nil, err
+		}
+		if dataset == export.DatasetFills {
+			return export.FillsTable(records), nil
+		}
+		return export.TradesTable(records), nil
+	default: // positions
+		positionQuery := service.PositionQuery{Account: query.Account}
+		if query.Symbol != "" {
+			positionQuery.Symbols = []string{query.Symbol}
+		}
+		result, err := s.tradingService.GetPositions(positionQuery)
+		if err != nil {
+			return nil, err
+		}
+		return export.PositionsTable(result.Positions), nil
+	}
+}
@@ -0,0 +1,250 @@
+package okx
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// OKXBaseURL OKX V5 API基础URL
+	OKXBaseURL = "https://www.okx.com"
+
+	// V5端点
+	InstrumentsEndpoint      = "/api/v5/public/instruments"
+	TickerEndpoint           = "/api/v5/market/ticker"
+	OrderEndpoint            = "/api/v5/trade/order"
+	CancelOrderEndpoint      = "/api/v5/trade/cancel-order"
+	AlgoOrderEndpoint        = "/api/v5/trade/order-algo"
+	CancelAlgoOrdersEndpoint = "/api/v5/trade/cancel-algos"
+	PositionsEndpoint        = "/api/v5/account/positions"
+
+	// InstTypeSwap 永续合约
+	InstTypeSwap = "SWAP"
+)
+
+// APIError OKX接口错误（code非0）
+type APIError struct {
+	Code       string
+	Msg        string
+	StatusCode int // HTTP状态码
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("OKX API错误: code=%s, msg=%s, status=%d", e.Code, e.Msg, e.StatusCode)
+}
+
+// Client OKX V5 API客户端
+type Client struct {
+	baseURL    string
+	apiKey     string
+	secretKey  string
+	passphrase string
+	httpClient *http.Client
+}
+
+// NewClient 创建OKX客户端，baseURL留空使用生产环境
+func NewClient(apiKey, secretKey, passphrase, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = OKXBaseURL
+	}
+	return &Client{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		secretKey:  secretKey,
+		passphrase: passphrase,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// GetInstruments 获取所有永续合约信息
+func (c *Client) GetInstruments() ([]Instrument, error) {
+	query := url.Values{}
+	query.Set("instType", InstTypeSwap)
+
+	var instruments []Instrument
+	if err := c.doRequest(http.MethodGet, InstrumentsEndpoint, query, nil, false, &instruments); err != nil {
+		return nil, err
+	}
+	return instruments, nil
+}
+
+// GetTicker 获取指定合约的行情
+func (c *Client) GetTicker(instID string) (*Ticker, error) {
+	query := url.Values{}
+	query.Set("instId", instID)
+
+	var tickers []Ticker
+	if err := c.doRequest(http.MethodGet, TickerEndpoint, query, nil, false, &tickers); err != nil {
+		return nil, err
+	}
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("合约 %s 无行情数据", instID)
+	}
+	return &tickers[0], nil
+}
+
+// PlaceOrder 下单
+func (c *Client) PlaceOrder(req *OrderRequest) (*OrderResult, error) {
+	var results []OrderResult
+	if err := c.doRequest(http.MethodPost, OrderEndpoint, nil, req, true, &results); err != nil {
+		return nil, err
+	}
+	return firstOrderResult(results)
+}
+
+// CancelOrder 撤销普通订单
+func (c *Client) CancelOrder(instID, ordID, clOrdID string) (*OrderResult, error) {
+	req := map[string]string{"instId": instID}
+	if ordID != "" {
+		req["ordId"] = ordID
+	}
+	if clOrdID != "" {
+		req["clOrdId"] = clOrdID
+	}
+
+	var results []OrderResult
+	if err := c.doRequest(http.MethodPost, CancelOrderEndpoint, nil, req, true, &results); err != nil {
+		return nil, err
+	}
+	return firstOrderResult(results)
+}
+
+// PlaceAlgoOrder 下策略委托（止盈止损条件单）
+func (c *Client) PlaceAlgoOrder(req *AlgoOrderRequest) (*AlgoOrderResult, error) {
+	var results []AlgoOrderResult
+	if err := c.doRequest(http.MethodPost, AlgoOrderEndpoint, nil, req, true, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("策略委托响应为空")
+	}
+	if results[0].SCode != "" && results[0].SCode != "0" {
+		return nil, &APIError{Code: results[0].SCode, Msg: results[0].SMsg, StatusCode: http.StatusOK}
+	}
+	return &results[0], nil
+}
+
+// CancelAlgoOrder 撤销策略委托
+func (c *Client) CancelAlgoOrder(instID, algoID string) error {
+	req := []map[string]string{{"instId": instID, "algoId": algoID}}
+
+	var results []AlgoOrderResult
+	if err := c.doRequest(http.MethodPost, CancelAlgoOrdersEndpoint, nil, req, true, &results); err != nil {
+		return err
+	}
+	if len(results) > 0 && results[0].SCode != "" && results[0].SCode != "0" {
+		return &APIError{Code: results[0].SCode, Msg: results[0].SMsg, StatusCode: http.StatusOK}
+	}
+	return nil
+}
+
+// GetPositions 查询永续持仓，instID留空查询全部
+func (c *Client) GetPositions(instID string) ([]Position, error) {
+	query := url.Values{}
+	query.Set("instType", InstTypeSwap)
+	if instID != "" {
+		query.Set("instId", instID)
+	}
+
+	var positions []Position
+	if err := c.doRequest(http.MethodGet, PositionsEndpoint, query, nil, true, &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// firstOrderResult 取批量结果中的第一条并检查sCode
+func firstOrderResult(results []OrderResult) (*OrderResult, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("订单响应为空")
+	}
+	if results[0].SCode != "" && results[0].SCode != "0" {
+		return nil, &APIError{Code: results[0].SCode, Msg: results[0].SMsg, StatusCode: http.StatusOK}
+	}
+	return &results[0], nil
+}
+
+// doRequest 发送请求并解析data字段
+// 签名规则：Base64(HMAC_SHA256(timestamp + method + requestPath + body))，requestPath包含查询字符串
+func (c *Client) doRequest(method, endpoint string, query url.Values, body interface{}, signed bool, out interface{}) error {
+	if signed && (c.apiKey == "" || c.secretKey == "" || c.passphrase == "") {
+		return fmt.Errorf("OKX API密钥未设置")
+	}
+
+	requestPath := endpoint
+	if query != nil && len(query) > 0 {
+		requestPath += "?" + query.Encode()
+	}
+
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+	}
+
+	httpReq, err := http.NewRequest(method, c.baseURL+requestPath, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if signed {
+		timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+		httpReq.Header.Set("OK-ACCESS-KEY", c.apiKey)
+		httpReq.Header.Set("OK-ACCESS-SIGN", sign(timestamp+method+requestPath+string(bodyBytes), c.secretKey))
+		httpReq.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+		httpReq.Header.Set("OK-ACCESS-PASSPHRASE", c.passphrase)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var envelope response
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("API返回错误状态码 %d: %s", resp.StatusCode, string(respBody))
+	}
+	if resp.StatusCode != http.StatusOK || envelope.Code != "0" {
+		apiErr := &APIError{Code: envelope.Code, Msg: envelope.Msg, StatusCode: resp.StatusCode}
+		// 批量接口的具体错误在data[].sMsg中
+		var results []OrderResult
+		if json.Unmarshal(envelope.Data, &results) == nil && len(results) > 0 && results[0].SMsg != "" {
+			apiErr.Code, apiErr.Msg = results[0].SCode, results[0].SMsg
+		}
+		return apiErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("解析响应失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// sign HMAC SHA256签名（Base64）
+func sign(payload, secretKey string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(payload))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
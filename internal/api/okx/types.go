@@ -0,0 +1,100 @@
+package okx
+
+import "encoding/json"
+
+// response OKX V5统一响应格式
+type response struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Instrument 合约信息
+type Instrument struct {
+	InstID    string `json:"instId"`    // 例如 BTC-USDT-SWAP
+	InstType  string `json:"instType"`  // SWAP
+	Uly       string `json:"uly"`       // 标的指数，例如 BTC-USDT
+	SettleCcy string `json:"settleCcy"` // 结算币种
+	CtVal     string `json:"ctVal"`     // 合约面值（每张合约对应的币数量）
+	CtValCcy  string `json:"ctValCcy"`
+	CtType    string `json:"ctType"` // linear/inverse
+	State     string `json:"state"`  // live/suspend/preopen/test
+	ListTime  string `json:"listTime"`
+	TickSz    string `json:"tickSz"`
+	LotSz     string `json:"lotSz"` // 下单数量精度（张）
+	MinSz     string `json:"minSz"` // 最小下单数量（张）
+	MaxMktSz  string `json:"maxMktSz"`
+	Lever     string `json:"lever"`
+}
+
+// Ticker 行情
+type Ticker struct {
+	InstID string `json:"instId"`
+	Last   string `json:"last"`
+	BidPx  string `json:"bidPx"`
+	AskPx  string `json:"askPx"`
+	Ts     string `json:"ts"`
+}
+
+// OrderRequest 下单请求
+type OrderRequest struct {
+	InstID     string `json:"instId"`
+	TdMode     string `json:"tdMode"`            // cross/isolated
+	Side       string `json:"side"`              // buy/sell
+	PosSide    string `json:"posSide,omitempty"` // net/long/short
+	OrdType    string `json:"ordType"`           // market/limit/ioc/post_only
+	Sz         string `json:"sz"`                // 数量（张）
+	Px         string `json:"px,omitempty"`
+	ReduceOnly bool   `json:"reduceOnly,omitempty"`
+	ClOrdID    string `json:"clOrdId,omitempty"`
+}
+
+// OrderResult 下单/撤单结果
+type OrderResult struct {
+	OrdID   string `json:"ordId"`
+	ClOrdID string `json:"clOrdId"`
+	SCode   string `json:"sCode"`
+	SMsg    string `json:"sMsg"`
+}
+
+// AlgoOrderRequest 策略委托请求（conditional单向止盈止损）
+type AlgoOrderRequest struct {
+	InstID          string `json:"instId"`
+	TdMode          string `json:"tdMode"`
+	Side            string `json:"side"`
+	PosSide         string `json:"posSide,omitempty"`
+	OrdType         string `json:"ordType"` // conditional
+	Sz              string `json:"sz,omitempty"`
+	CloseFraction   string `json:"closeFraction,omitempty"` // "1" 表示全部平仓
+	ReduceOnly      bool   `json:"reduceOnly,omitempty"`
+	SlTriggerPx     string `json:"slTriggerPx,omitempty"`
+	SlOrdPx         string `json:"slOrdPx,omitempty"` // -1 表示市价
+	SlTriggerPxType string `json:"slTriggerPxType,omitempty"`
+	TpTriggerPx     string `json:"tpTriggerPx,omitempty"`
+	TpOrdPx         string `json:"tpOrdPx,omitempty"`
+	TpTriggerPxType string `json:"tpTriggerPxType,omitempty"` // last/mark/index
+	AlgoClOrdID     string `json:"algoClOrdId,omitempty"`
+}
+
+// AlgoOrderResult 策略委托结果
+type AlgoOrderResult struct {
+	AlgoID      string `json:"algoId"`
+	AlgoClOrdID string `json:"algoClOrdId"`
+	SCode       string `json:"sCode"`
+	SMsg        string `json:"sMsg"`
+}
+
+// Position 持仓
+type Position struct {
+	InstID      string `json:"instId"`
+	PosSide     string `json:"posSide"` // net/long/short
+	Pos         string `json:"pos"`     // 持仓数量（张），单向持仓模式下空仓为负数
+	AvgPx       string `json:"avgPx"`
+	MarkPx      string `json:"markPx"`
+	Upl         string `json:"upl"`
+	LiqPx       string `json:"liqPx"`
+	Lever       string `json:"lever"`
+	MgnMode     string `json:"mgnMode"` // cross/isolated
+	NotionalUsd string `json:"notionalUsd"`
+	UTime       string `json:"uTime"`
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/service"
+)
+
+// PositionRequest 平仓/撤销止盈止损单请求
+type PositionRequest struct {
+	Account      string `json:"account,omitempty"`       // 币安账户，留空使用主账户
+	Symbol       string `json:"symbol"`                  // 币对
+	PositionSide string `json:"position_side,omitempty"` // 双向持仓时指定LONG/SHORT，留空表示所有方向
+}
+
+// requireTradingService 检查交易服务是否可用
+func (s *Server) requireTradingService(c *gin.Context) bool {
+	if s.tradingService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "交易服务未初始化，请检查配置文件中的API密钥设置",
+		})
+		return false
+	}
+	return true
+}
+
+// bindPositionRequest 解析平仓/撤单请求
+func bindPositionRequest(c *gin.Context) (*PositionRequest, bool) {
+	var req PositionRequest
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "读取请求体失败: " + err.Error(),
+		})
+		return nil, false
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "解析JSON失败: " + err.Error(),
+		})
+		return nil, false
+	}
+	if req.Symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "symbol不能为空",
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+// handleGetPositions 按条件查询持仓及汇总
+// 查询参数: account, sign(positive/negative), symbol(逗号分隔), min_notional,
+// sort(pnl/pnl_percent/notional/liquidation_distance), order(asc/desc)
+func (s *Server) handleGetPositions(c *gin.Context) {
+	if !s.requireTradingService(c) {
+		return
+	}
+
+	query := service.PositionQuery{
+		Account: c.Query("account"),
+		Sign:    c.Query("sign"),
+		SortBy:  c.Query("sort"),
+		Order:   c.Query("order"),
+	}
+	if !s.tradingService.HasAccount(query.Account) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "账户 " + query.Account + " 不存在或未启用",
+		})
+		return
+	}
+	for _, symbol := range strings.Split(c.Query("symbol"), ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			query.Symbols = append(query.Symbols, symbol)
+		}
+	}
+	if v := c.Query("min_notional"); v != "" {
+		minNotional, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "min_notional格式错误: " + v,
+			})
+			return
+		}
+		query.MinNotional = minNotional
+	}
+	if err := query.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := s.tradingService.GetPositions(query)
+	if err != nil {
+		logger.Errorf("查询持仓失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "查询持仓失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleClosePosition 市价平仓并撤销该币对的止盈止损单
+func (s *Server) handleClosePosition(c *gin.Context) {
+	if !s.requireTradingService(c) {
+		return
+	}
+	req, ok := bindPositionRequest(c)
+	if !ok {
+		return
+	}
+
+	orders, err := s.tradingService.ClosePosition(req.Account, req.Symbol, req.PositionSide)
+	if err != nil {
+		logger.Errorf("平仓失败: %s: %v", req.Symbol, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "平仓失败: " + err.Error(),
+			"orders":  orders,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "平仓成功",
+		"orders":  orders,
+	})
+}
+
+// handleCancelBrackets 撤销某个币对的止盈止损单，双向持仓时可按持仓方向撤销
+func (s *Server) handleCancelBrackets(c *gin.Context) {
+	if !s.requireTradingService(c) {
+		return
+	}
+	req, ok := bindPositionRequest(c)
+	if !ok {
+		return
+	}
+
+	canceled, err := s.tradingService.CancelBrackets(req.Account, req.Symbol, req.PositionSide)
+	if err != nil {
+		logger.Errorf("撤销止盈止损单失败: %s: %v", req.Symbol, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success":  false,
+			"message":  "撤销止盈止损单失败: " + err.Error(),
+			"canceled": canceled,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "撤销止盈止损单成功",
+		"canceled": canceled,
+	})
+}
+
+// ProtectionRepairRequest 补建止损/止盈单请求
+type ProtectionRepairRequest struct {
+	Account string `json:"account,omitempty"` // 币安账户，留空表示所有账户
+	Resize  bool   `json:"resize,omitempty"`  // 同时按持仓数量重建数量不一致的条件单
+}
+
+// handleGetProtection 检查持仓的止损/止盈单（只报告，不修复），支持 ?account=
+func (s *Server) handleGetProtection(c *gin.Context) {
+	if !s.requireTradingService(c) {
+		return
+	}
+	s.respondProtection(c, service.ProtectionOptions{Account: c.Query("account")})
+}
+
+// handleRepairProtection 补建缺少的止损/止盈单
+func (s *Server) handleRepairProtection(c *gin.Context) {
+	if !s.requireTradingService(c) {
+		return
+	}
+	var req ProtectionRepairRequest
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "读取请求体失败: " + err.Error(),
+		})
+		return
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "解析JSON失败: " + err.Error(),
+			})
+			return
+		}
+	}
+	s.respondProtection(c, service.ProtectionOptions{Account: req.Account, Repair: true, Resize: req.Resize})
+}
+
+// respondProtection 执行检查并返回有问题的持仓
+func (s *Server) respondProtection(c *gin.Context, opts service.ProtectionOptions) {
+	if !s.tradingService.HasAccount(opts.Account) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "账户 " + opts.Account + " 不存在或未启用",
+		})
+		return
+	}
+
+	positions, err := s.tradingService.CheckProtection(opts)
+	if err != nil {
+		logger.Errorf("仓位保护检查失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success":   false,
+			"message":   "仓位保护检查失败: " + err.Error(),
+			"positions": positions,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"count":     len(positions),
+		"positions": positions,
+	})
+}
@@ -1,15 +1,26 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
 	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/metrics"
 	"new_listing_trade/internal/models"
 	"new_listing_trade/internal/service"
 )
@@ -20,6 +31,15 @@ type Server struct {
 	tradingService *service.TradingService
 	port           string
 	engine         *gin.Engine
+	httpServer     *http.Server
+	webhookSource  *service.WebhookSource  // webhook新币来源（未启用时为nil）
+	webhookToken   string                  // webhook校验token
+	configReloader *service.ConfigReloader // 配置热更新（交易服务未启用时为nil）
+	auth           *Authenticator          // 接口认证（未设置时不校验）
+	allowedOrigins []string                // CORS允许的来源，为空时允许所有来源
+	eventBus       *events.Bus             // 事件总线（未设置时不提供事件推送）
+	closing        chan struct{}           // 关闭时通知SSE/WebSocket长连接退出
+	closeOnce      sync.Once
 }
 
 // NewServer 创建新的HTTP服务器
@@ -27,30 +47,109 @@ func NewServer(port string, symbolMonitor *service.SymbolMonitor, tradingService
 	// 设置为release模式（生产环境）或debug模式（开发环境）
 	gin.SetMode(gin.ReleaseMode)
 
-	engine := gin.Default()
-
-	// 添加CORS中间件（如果需要）
-	engine.Use(corsMiddleware())
+	engine := newEngine()
 
 	server := &Server{
 		symbolMonitor:  symbolMonitor,
 		tradingService: tradingService,
 		port:           port,
 		engine:         engine,
+		closing:        make(chan struct{}),
 	}
 
+	// 添加CORS中间件
+	engine.Use(server.corsMiddleware())
+
 	// 注册路由
 	server.registerRoutes()
 
 	return server
 }
 
+// newEngine 创建gin引擎：请求日志隐去查询参数中的access_token；默认不信任任何代理，
+// 客户端IP取TCP连接的对端地址，忽略 X-Forwarded-For 等请求头（防止伪造IP绕过白名单）
+func newEngine() *gin.Engine {
+	engine := gin.New()
+	engine.Use(gin.LoggerWithFormatter(requestLogFormatter), gin.Recovery())
+	_ = engine.SetTrustedProxies(nil)
+	return engine
+}
+
+// requestLogFormatter 请求日志格式（与gin默认格式相同），隐去查询参数中的access_token
+func requestLogFormatter(param gin.LogFormatterParams) string {
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		redactAccessToken(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// redactAccessToken 把路径中 access_token 查询参数的值替换为 REDACTED
+func redactAccessToken(path string) string {
+	idx := strings.IndexByte(path, '?')
+	if idx < 0 || !strings.Contains(path[idx:], "access_token=") {
+		return path
+	}
+	query, err := url.ParseQuery(path[idx+1:])
+	if err != nil {
+		return path[:idx] + "?REDACTED"
+	}
+	query.Set("access_token", "REDACTED")
+	return path[:idx+1] + query.Encode()
+}
+
+// SetTrustedProxies 设置信任的反向代理（IP或网段），只有来自这些地址的请求才按 X-Forwarded-For 取客户端IP
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	return s.engine.SetTrustedProxies(proxies)
+}
+
+// SetWebhookSource 设置webhook新币来源，启用 POST /api/listings/webhook
+func (s *Server) SetWebhookSource(source *service.WebhookSource, token string) {
+	s.webhookSource = source
+	s.webhookToken = token
+}
+
+// SetConfigReloader 设置配置重载器，启用 POST /api/config/reload
+func (s *Server) SetConfigReloader(reloader *service.ConfigReloader) {
+	s.configReloader = reloader
+}
+
+// SetAuth 设置接口认证，启用角色校验和IP白名单
+func (s *Server) SetAuth(auth *Authenticator) {
+	s.auth = auth
+}
+
+// SetAllowedOrigins 设置CORS允许的来源，为空时允许所有来源
+func (s *Server) SetAllowedOrigins(origins []string) {
+	s.allowedOrigins = origins
+}
+
 // corsMiddleware CORS中间件
-func corsMiddleware() gin.HandlerFunc {
+func (s *Server) corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		if len(s.allowedOrigins) == 0 {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Writer.Header().Add("Vary", "Origin")
+			origin := c.Request.Header.Get("Origin")
+			for _, allowed := range s.allowedOrigins {
+				if origin != "" && (allowed == "*" || allowed == origin) {
+					c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+					break
+				}
+			}
+		}
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", strings.Join([]string{
+			"Content-Type", "Authorization", HeaderAPIKey, HeaderTimestamp, HeaderSignature,
+		}, ", "))
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -63,21 +162,56 @@ func corsMiddleware() gin.HandlerFunc {
 
 // registerRoutes 注册路由
 func (s *Server) registerRoutes() {
-	api := s.engine.Group("/api")
+	api := s.engine.Group("/api", s.ipAllowlistMiddleware())
 	{
-		api.POST("/simulate/new-listing", s.handleSimulateNewListing)
-		api.GET("/status", s.handleStatus)
-		api.GET("/new-listings", s.handleGetNewListings)
-		api.GET("/symbols", s.handleGetSymbols)
-		api.GET("/positions/negative", s.handleGetNegativePositions)
+		// webhook使用独立的token校验
+		api.POST("/listings/webhook", s.handleListingWebhook)
+
+		// 只读接口
+		read := api.Group("", s.requireRole(config.RoleRead))
+		read.GET("/status", s.handleStatus)
+		read.GET("/new-listings", s.handleGetNewListings)
+		read.GET("/symbols", s.handleGetSymbols)
+		read.GET("/positions", s.handleGetPositions)
+		read.GET("/positions/negative", s.handleGetNegativePositions)
+		read.GET("/positions/protection", s.handleGetProtection)
+		read.GET("/trades", s.handleGetTrades)
+		read.GET("/pnl/summary", s.handlePnLSummary)
+		read.GET("/export/:dataset", s.handleExport)
+		read.GET("/strategy/resolve", s.handleResolveStrategy)
+		read.GET("/events", s.handleEventsSSE)
+		read.GET("/events/ws", s.handleEventsWebSocket)
+		read.GET("/events/recent", s.handleRecentEvents)
+
+		// 交易接口（下单、平仓、修改配置）
+		trader := api.Group("", s.requireRole(config.RoleTrader))
+		trader.POST("/simulate/new-listing", s.handleSimulateNewListing)
+		trader.POST("/config/reload", s.handleReloadConfig)
+		trader.POST("/positions/close", s.handleClosePosition)
+		trader.POST("/brackets/cancel", s.handleCancelBrackets)
+		trader.POST("/positions/protection/repair", s.handleRepairProtection)
 	}
 
+	// 网页面板
+	s.registerUI()
+
+	// Prometheus指标（启用认证时需要read角色，可在抓取配置中设置bearer token）
+	s.engine.GET("/metrics", s.ipAllowlistMiddleware(), s.requireRole(config.RoleRead), s.handleMetrics)
+
 	// 健康检查
 	s.engine.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 }
 
+// handleMetrics 以Prometheus文本格式输出指标，输出前刷新持仓指标
+func (s *Server) handleMetrics(c *gin.Context) {
+	if s.tradingService != nil {
+		s.tradingService.RefreshPositionMetrics()
+	}
+	metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
 // Start 启动HTTP服务器
 func (s *Server) Start() error {
 	logger.Infof("HTTP服务器启动在端口 %s", s.port)
@@ -86,43 +220,100 @@ func (s *Server) Start() error {
 	logger.Info("  GET  /api/status - 获取服务状态")
 	logger.Info("  GET  /api/new-listings - 获取新币对列表")
 	logger.Info("  GET  /api/symbols - 获取所有币对")
-	logger.Info("  GET  /api/positions/negative - 查询收益为负的仓位（已排序）")
+	logger.Info("  GET  /api/positions - 查询持仓及汇总（支持 ?account= ?sign= ?symbol= ?min_notional= ?sort= ?order=）")
+	logger.Info("  POST /api/positions/close - 市价平仓并撤销止盈止损单")
+	logger.Info("  POST /api/brackets/cancel - 撤销币对的止盈止损单")
+	logger.Info("  GET  /api/positions/negative - 查询收益为负的仓位（已排序，支持 ?account=）")
+	logger.Info("  GET  /api/positions/protection - 检查持仓是否缺少止损/止盈单（支持 ?account=）")
+	logger.Info("  POST /api/positions/protection/repair - 补建缺少的止损/止盈单")
+	logger.Info("  GET  /api/trades - 交易记录及已实现盈亏（支持 ?account= ?symbol= ?from= ?to=）")
+	logger.Info("  GET  /api/pnl/summary - 已实现盈亏汇总（支持 ?account= ?symbol= ?from= ?to=）")
+	logger.Info("  GET  /api/export/:dataset - 导出 listings/orders/fills/trades/positions（?format=csv|jsonl|xlsx）")
+	logger.Info("  POST /api/listings/webhook - 接收外部推送的新币")
+	logger.Info("  GET  /api/strategy/resolve?symbol= - 查看币对匹配的策略规则")
+	logger.Info("  POST /api/config/reload - 重新加载配置文件（热更新交易配置）")
+	logger.Info("  GET  /api/events - 实时事件推送（SSE，支持 ?types= 和 Last-Event-ID）")
+	logger.Info("  GET  /api/events/ws - 实时事件推送（WebSocket）")
+	logger.Info("  GET  /api/events/recent - 最近的事件（支持 ?types= 和 ?limit=）")
+	logger.Info("  GET  /ui/ - 网页面板")
+	logger.Info("  GET  /metrics - Prometheus指标")
 	logger.Info("  GET  /health - 健康检查")
 
-	return s.engine.Run(":" + s.port)
+	s.httpServer = &http.Server{
+		Addr:    ":" + s.port,
+		Handler: s.engine,
+	}
+	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 优雅关闭HTTP服务器
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.closing) })
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
 }
 
 // SimulateNewListingRequest 模拟新币上线请求（支持单个或批量）
 type SimulateNewListingRequest struct {
 	Symbols      []string `json:"symbols"`                 // 币对列表，例如 ["BTCUSDT", "ETHUSDT"]
 	NotionalUSDT string   `json:"notional_usdt,omitempty"` // USDT金额，留空使用配置默认值
+	Exchange     string   `json:"exchange,omitempty"`      // 下单交易所：binance/bybit/okx，留空使用币安
+	Accounts     []string `json:"accounts,omitempty"`      // 币安账户列表，留空表示所有已启用账户
 }
 
 // SimulateNewListingResponse 模拟新币上线响应
 type SimulateNewListingResponse struct {
-	Success  bool               `json:"success"`
-	Message  string             `json:"message"`
-	Results  []BatchOrderResult `json:"results,omitempty"`   // 批量处理结果
-	Symbol   string             `json:"symbol,omitempty"`    // 单个币对结果（兼容旧接口）
-	OrderSet *OrderSetResponse  `json:"order_set,omitempty"` // 单个币对订单（兼容旧接口）
+	Success     bool               `json:"success"`
+	Message     string             `json:"message"`
+	Unprotected bool               `json:"unprotected,omitempty"` // 有账户的仓位没有止损（unprotected/unwind_failed），需要人工处理
+	Results     []BatchOrderResult `json:"results,omitempty"`     // 批量处理结果
+	Symbol      string             `json:"symbol,omitempty"`      // 单个币对结果（兼容旧接口）
+	OrderSet    *OrderSetResponse  `json:"order_set,omitempty"`   // 单个币对订单（兼容旧接口）
 }
 
 // BatchOrderResult 批量订单结果
 type BatchOrderResult struct {
-	Symbol   string            `json:"symbol"`
-	Success  bool              `json:"success"`
-	Message  string            `json:"message"`
-	OrderSet *OrderSetResponse `json:"order_set,omitempty"`
+	Symbol      string                  `json:"symbol"`
+	Success     bool                    `json:"success"`
+	Message     string                  `json:"message"`
+	Unprotected bool                    `json:"unprotected,omitempty"` // 有账户的仓位没有止损（unprotected/unwind_failed），需要人工处理
+	OrderSet    *OrderSetResponse       `json:"order_set,omitempty"`   // 第一个下单成功的账户的订单（兼容旧接口）
+	Accounts    []AccountOrderResult    `json:"accounts,omitempty"`    // 各币安账户的下单结果
+	Liquidity   *service.LiquidityCheck `json:"liquidity,omitempty"`   // 非币安交易所因流动性检查未通过跳过开仓时的测量值
+}
+
+// AccountOrderResult 单个账户的下单结果
+type AccountOrderResult struct {
+	Account   string                  `json:"account"`
+	Success   bool                    `json:"success"`
+	Message   string                  `json:"message"`
+	OrderSet  *OrderSetResponse       `json:"order_set,omitempty"`
+	Liquidity *service.LiquidityCheck `json:"liquidity,omitempty"` // 因流动性检查未通过跳过开仓时的测量值
 }
 
 // OrderSetResponse 订单集合响应
 type OrderSetResponse struct {
-	Symbol          string                `json:"symbol"`
-	SellOrder       *models.OrderResponse `json:"sell_order,omitempty"` // 做空卖单
-	StopLossOrder   *models.OrderResponse `json:"stop_loss_order,omitempty"`
-	TakeProfitOrder *models.OrderResponse `json:"take_profit_order,omitempty"`
-	StopLossError   string                `json:"stop_loss_error,omitempty"`
-	TakeProfitError string                `json:"take_profit_error,omitempty"`
+	Symbol           string                  `json:"symbol"`
+	Exchange         string                  `json:"exchange,omitempty"`
+	Account          string                  `json:"account,omitempty"`
+	Direction        string                  `json:"direction,omitempty"`  // 开仓方向 SHORT/LONG
+	Rule             string                  `json:"rule,omitempty"`       // 生效的策略规则
+	SellOrder        *models.OrderResponse   `json:"sell_order,omitempty"` // 开仓单（做空为卖单，做多为买单）
+	StopLossOrder    *models.OrderResponse   `json:"stop_loss_order,omitempty"`
+	TakeProfitOrder  *models.OrderResponse   `json:"take_profit_order,omitempty"`
+	TakeProfitOrders []*models.OrderResponse `json:"take_profit_orders,omitempty"` // 分档止盈单（按档从近到远）
+	StopLossError    string                  `json:"stop_loss_error,omitempty"`
+	TakeProfitError  string                  `json:"take_profit_error,omitempty"`
+	Status           string                  `json:"status,omitempty"`       // 保护状态 protected/no_stop_loss/unprotected/unwound/unwind_failed/slippage_unwound
+	UnwindOrder      *models.OrderResponse   `json:"unwind_order,omitempty"` // 止损单创建失败后的市价平仓单
+	UnwindError      string                  `json:"unwind_error,omitempty"`
+	Liquidity        *service.LiquidityCheck `json:"liquidity,omitempty"` // 开仓前的流动性检查结果
+	Funding          *service.FundingCheck   `json:"funding,omitempty"`   // 开仓前的资金费估算
 }
 
 // handleSimulateNewListing 处理模拟新币上线请求（支持批量）
@@ -170,32 +361,33 @@ func (s *Server) handleSimulateNewListing(c *gin.Context) {
 
 	results := make([]BatchOrderResult, 0, len(symbols))
 	for _, symbol := range symbols {
-		result := s.processSingleSymbol(symbol, req.NotionalUSDT)
-		results = append(results, BatchOrderResult{
-			Symbol:   result.Symbol,
-			Success:  result.Success,
-			Message:  result.Message,
-			OrderSet: result.OrderSet,
-		})
+		results = append(results, s.processSingleSymbol(symbol, &req))
 	}
 
-	// 统计成功和失败数量
-	successCount := 0
+	// 统计成功和失败数量，有仓位没有止损时整体视为失败
+	successCount, unprotected := 0, false
 	for _, r := range results {
 		if r.Success {
 			successCount++
 		}
+		unprotected = unprotected || r.Unprotected
 	}
 
+	message := fmt.Sprintf("批量处理完成: 成功 %d/%d", successCount, len(results))
+	if unprotected {
+		message += "，有仓位没有止损，请立即人工处理"
+	}
 	c.JSON(http.StatusOK, SimulateNewListingResponse{
-		Success: successCount > 0,
-		Message: fmt.Sprintf("批量处理完成: 成功 %d/%d", successCount, len(results)),
-		Results: results,
+		Success:     successCount > 0 && !unprotected,
+		Message:     message,
+		Unprotected: unprotected,
+		Results:     results,
 	})
 }
 
 // processSingleSymbol 处理单个币对的下单流程
-func (s *Server) processSingleSymbol(symbol, notionalUSDT string) BatchOrderResult {
+// 币安下单会并行分发到所有（或请求指定的）账户，其他交易所只下单一次
+func (s *Server) processSingleSymbol(symbol string, req *SimulateNewListingRequest) BatchOrderResult {
 	// 模拟新币上线：添加到监控服务的新币对列表
 	onboardDate := time.Now().UnixMilli()
 	added := s.symbolMonitor.AddNewListing(symbol, onboardDate)
@@ -204,20 +396,32 @@ func (s *Server) processSingleSymbol(symbol, notionalUSDT string) BatchOrderResu
 	}
 
 	// 检查是否已经下单过
-	if listing, exists := s.symbolMonitor.GetNewListing(symbol); exists && listing.IsOrdered {
+	listing, exists := s.symbolMonitor.GetNewListing(symbol)
+	if exists && listing.IsOrdered {
 		return BatchOrderResult{
 			Symbol:  symbol,
 			Success: false,
 			Message: "币对 " + symbol + " 已经下单过了",
 		}
 	}
+	if exists {
+		onboardDate = listing.OnboardDate
+	}
+
+	// 按策略规则解析交易参数
+	strategy := s.tradingService.ResolveStrategy(symbol, onboardDate)
+	if strategy.Rule != "" {
+		logger.Infof("币对 %s 匹配策略规则: %s, 方向: %s", symbol, strategy.Rule, strategy.Direction)
+	}
 
 	// 执行交易流程
 	logger.Infof("模拟新币上线: %s, 开始执行交易流程...", symbol)
-	orderSet, err := s.tradingService.CreateOrdersWithStopLossAndTakeProfit(symbol, notionalUSDT)
+	if req.Exchange != "" && req.Exchange != exchange.NameBinance {
+		return s.processOnExchange(strategy, req)
+	}
 
+	accountResults, err := s.tradingService.CreateOrdersForAccounts(strategy, req.NotionalUSDT, req.Accounts)
 	if err != nil {
-		logger.Errorf("交易流程执行失败: %v", err)
 		return BatchOrderResult{
 			Symbol:  symbol,
 			Success: false,
@@ -225,24 +429,116 @@ func (s *Server) processSingleSymbol(symbol, notionalUSDT string) BatchOrderResu
 		}
 	}
 
-	// 标记为已下单
-	if s.symbolMonitor != nil {
-		s.symbolMonitor.MarkAsOrdered(symbol)
+	result := BatchOrderResult{
+		Symbol:   symbol,
+		Accounts: make([]AccountOrderResult, 0, len(accountResults)),
 	}
+	successCount, enteredCount, unprotectedCount := 0, 0, 0
+	for _, accountResult := range accountResults {
+		if accountResult.Err != nil {
+			result.Accounts = append(result.Accounts, AccountOrderResult{
+				Account:   accountResult.Account,
+				Success:   false,
+				Message:   "交易流程执行失败: " + accountResult.Err.Error(),
+				Liquidity: skippedLiquidity(accountResult.Err),
+			})
+			continue
+		}
 
-	// 构建响应
-	orderSetResp := &OrderSetResponse{
-		Symbol: orderSet.Symbol,
+		// 开仓成功但因止损单创建失败已平仓的账户不计为成功
+		enteredCount++
+		orderSet := accountResult.OrderSet
+		orderSetResp := newOrderSetResponse(orderSet)
+		if orderSet.Open() {
+			successCount++
+			if result.OrderSet == nil {
+				result.OrderSet = orderSetResp
+			}
+		}
+		if unprotected(orderSet) {
+			unprotectedCount++
+		}
+		result.Accounts = append(result.Accounts, AccountOrderResult{
+			Account:  accountResult.Account,
+			Success:  orderSet.Open() && !unprotected(orderSet),
+			Message:  orderSetMessage(orderSet),
+			OrderSet: orderSetResp,
+		})
+	}
+
+	if enteredCount == 0 {
+		result.Message = fmt.Sprintf("交易流程执行失败: %d 个账户均下单失败", len(accountResults))
+		return result
+	}
+
+	// 标记为已下单（已开仓后平掉的也标记，避免重复开仓）
+	s.symbolMonitor.MarkAsOrdered(symbol)
+
+	if successCount == 0 {
+		result.Message = fmt.Sprintf("%d 个账户的开仓均已市价平仓（止损单创建失败或开仓滑点超过上限）", enteredCount)
+		return result
+	}
+	if unprotectedCount > 0 {
+		result.Unprotected = true
+		result.Message = fmt.Sprintf("已在 %d/%d 个账户创建订单，但 %d 个账户的仓位没有止损，请立即人工处理", successCount, len(accountResults), unprotectedCount)
+		return result
+	}
+	result.Success = true
+	result.Message = fmt.Sprintf("新币上线模拟成功，已在 %d/%d 个账户创建订单", successCount, len(accountResults))
+	return result
+}
+
+// processOnExchange 在非币安交易所下单
+func (s *Server) processOnExchange(strategy *service.Strategy, req *SimulateNewListingRequest) BatchOrderResult {
+	symbol := strategy.Symbol
+	orderSet, err := s.tradingService.CreateOrdersOnExchange(req.Exchange, strategy, req.NotionalUSDT)
+	if err != nil {
+		logger.Errorf("交易流程执行失败: %v", err)
+		return BatchOrderResult{
+			Symbol:    symbol,
+			Success:   false,
+			Message:   "交易流程执行失败: " + err.Error(),
+			Liquidity: skippedLiquidity(err),
+		}
 	}
 
-	if orderSet.SellOrder != nil {
-		orderSetResp.SellOrder = orderSet.SellOrder
+	// 标记为已下单（已开仓后平掉的也标记，避免重复开仓）
+	s.symbolMonitor.MarkAsOrdered(symbol)
+
+	message := "新币上线模拟成功，已创建订单"
+	if orderSet.Status != service.OrderSetProtected && orderSet.Status != service.OrderSetNoStopLoss {
+		message = orderSetMessage(orderSet)
 	}
-	if orderSet.StopLossOrder != nil {
-		orderSetResp.StopLossOrder = orderSet.StopLossOrder
+	return BatchOrderResult{
+		Symbol:      symbol,
+		Success:     orderSet.Open() && !unprotected(orderSet),
+		Message:     message,
+		Unprotected: unprotected(orderSet),
+		OrderSet:    newOrderSetResponse(orderSet),
 	}
-	if orderSet.TakeProfitOrder != nil {
-		orderSetResp.TakeProfitOrder = orderSet.TakeProfitOrder
+}
+
+// unprotected 仓位已开但没有止损（止损单创建失败后保留仓位或平仓失败）
+func unprotected(orderSet *service.OrderSet) bool {
+	return orderSet.Status == service.OrderSetUnprotected || orderSet.Status == service.OrderSetUnwindFailed
+}
+
+// newOrderSetResponse 构建订单集合响应
+func newOrderSetResponse(orderSet *service.OrderSet) *OrderSetResponse {
+	orderSetResp := &OrderSetResponse{
+		Symbol:           orderSet.Symbol,
+		Exchange:         orderSet.Exchange,
+		Account:          orderSet.Account,
+		Direction:        orderSet.Direction,
+		Rule:             orderSet.Rule,
+		SellOrder:        orderSet.SellOrder,
+		StopLossOrder:    orderSet.StopLossOrder,
+		TakeProfitOrder:  orderSet.TakeProfitOrder,
+		TakeProfitOrders: orderSet.TakeProfitOrders,
+		Status:           orderSet.Status,
+		UnwindOrder:      orderSet.UnwindOrder,
+		Liquidity:        orderSet.Liquidity,
+		Funding:          orderSet.Funding,
 	}
 	if orderSet.StopLossError != nil {
 		orderSetResp.StopLossError = orderSet.StopLossError.Error()
@@ -250,12 +546,34 @@ func (s *Server) processSingleSymbol(symbol, notionalUSDT string) BatchOrderResu
 	if orderSet.TakeProfitError != nil {
 		orderSetResp.TakeProfitError = orderSet.TakeProfitError.Error()
 	}
+	if orderSet.UnwindError != nil {
+		orderSetResp.UnwindError = orderSet.UnwindError.Error()
+	}
+	return orderSetResp
+}
 
-	return BatchOrderResult{
-		Symbol:   symbol,
-		Success:  true,
-		Message:  "新币上线模拟成功，已创建订单",
-		OrderSet: orderSetResp,
+// skippedLiquidity 因流动性检查未通过跳过开仓时返回检查结果
+func skippedLiquidity(err error) *service.LiquidityCheck {
+	var skipErr *service.LiquiditySkipError
+	if errors.As(err, &skipErr) {
+		return skipErr.Check
+	}
+	return nil
+}
+
+// orderSetMessage 账户下单结果的说明，止损单创建失败时说明仓位的处理结果
+func orderSetMessage(orderSet *service.OrderSet) string {
+	switch orderSet.Status {
+	case service.OrderSetSlippageUnwound:
+		return "开仓滑点超过上限，已市价平仓"
+	case service.OrderSetUnwound:
+		return "止损单创建失败，已市价平仓"
+	case service.OrderSetUnwindFailed:
+		return "止损单创建失败且市价平仓失败，仓位没有止损，请立即人工处理"
+	case service.OrderSetUnprotected:
+		return "已创建订单，但止损单创建失败，仓位没有止损"
+	default:
+		return "已创建订单"
 	}
 }
 
@@ -266,9 +584,18 @@ func (s *Server) handleStatus(c *gin.Context) {
 		"new_listing_count": s.symbolMonitor.GetNewListingCount(),
 		"last_update_time":  s.symbolMonitor.GetLastUpdateTime().Format("2006-01-02 15:04:05"),
 		"trading_enabled":   s.tradingService != nil,
+		"accounts":          s.accountNames(),
 	})
 }
 
+// accountNames 返回已启用的币安账户名称
+func (s *Server) accountNames() []string {
+	if s.tradingService == nil {
+		return []string{}
+	}
+	return s.tradingService.AccountNames()
+}
+
 // handleGetNewListings 获取新币对列表
 func (s *Server) handleGetNewListings(c *gin.Context) {
 	listings := s.symbolMonitor.GetNewListings()
@@ -292,8 +619,18 @@ func (s *Server) handleGetNegativePositions(c *gin.Context) {
 		return
 	}
 
+	// 按账户筛选（?account=），留空查询所有账户
+	account := c.Query("account")
+	if !s.tradingService.HasAccount(account) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "账户 " + account + " 不存在或未启用",
+		})
+		return
+	}
+
 	// 查询负收益仓位
-	result, err := s.tradingService.GetNegativePositions()
+	result, err := s.tradingService.GetNegativePositions(account)
 	if err != nil {
 		logger.Errorf("查询负收益仓位失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -305,3 +642,112 @@ func (s *Server) handleGetNegativePositions(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// handleListingWebhook 接收外部推送的新币
+func (s *Server) handleListingWebhook(c *gin.Context) {
+	if s.webhookSource == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "webhook来源未启用，请在配置文件中设置 listing_sources.webhook.enabled",
+		})
+		return
+	}
+
+	token := c.GetHeader("X-Webhook-Token")
+	if s.webhookToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.webhookToken)) != 1 {
+		logger.Warnf("webhook token校验失败，来源IP: %s", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "webhook token无效",
+		})
+		return
+	}
+
+	var payload service.WebhookListingPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "解析JSON失败: " + err.Error(),
+		})
+		return
+	}
+
+	count, err := s.webhookSource.Ingest(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("已接收 %d 个币对", count),
+	})
+}
+
+// handleResolveStrategy 解析币对生效的策略规则及匹配过程
+// 参数: symbol（必需），onboard_date（可选，毫秒时间戳，留空使用新币列表中的上线时间）
+func (s *Server) handleResolveStrategy(c *gin.Context) {
+	if s.tradingService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "交易服务未初始化，请检查配置文件中的API密钥设置",
+		})
+		return
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(c.Query("symbol")))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请提供币对(symbol)",
+		})
+		return
+	}
+
+	var onboardDate int64
+	if value := c.Query("onboard_date"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "无效的上线时间(onboard_date): " + value,
+			})
+			return
+		}
+		onboardDate = parsed
+	} else if listing, exists := s.symbolMonitor.GetNewListing(symbol); exists {
+		onboardDate = listing.OnboardDate
+	}
+
+	c.JSON(http.StatusOK, s.tradingService.ResolveStrategy(symbol, onboardDate))
+}
+
+// handleReloadConfig 重新加载配置文件，热更新交易配置
+func (s *Server) handleReloadConfig(c *gin.Context) {
+	if s.configReloader == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "交易服务未初始化，无法热更新配置",
+		})
+		return
+	}
+
+	result, err := s.configReloader.Reload()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "配置热更新失败，保留当前配置: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"message":          "配置热更新完成: " + result.String(),
+		"applied":          result.Applied,
+		"restart_required": result.RestartRequired,
+	})
+}
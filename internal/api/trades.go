@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+	"new_listing_trade/internal/service"
+)
+
+// TradesResponse 交易记录响应
+type TradesResponse struct {
+	TotalCount int                  `json:"total_count"`
+	Trades     []models.TradeRecord `json:"trades"` // 按开仓时间排序
+}
+
+// parseTimeParam 解析时间参数，支持毫秒时间戳、RFC3339和日期（本地时区，例如2024-01-01）
+func parseTimeParam(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ms, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t.UnixMilli(), nil
+	}
+	return 0, fmt.Errorf("时间格式错误: %s（支持毫秒时间戳、RFC3339或2006-01-02）", value)
+}
+
+// bindTradeQuery 解析交易记录查询参数 account/symbol/from/to
+func (s *Server) bindTradeQuery(c *gin.Context) (service.TradeQuery, bool) {
+	query := service.TradeQuery{
+		Account: c.Query("account"),
+		Symbol:  strings.ToUpper(c.Query("symbol")),
+	}
+	if !s.tradingService.HasAccount(query.Account) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "账户 " + query.Account + " 不存在或未启用",
+		})
+		return query, false
+	}
+
+	var err error
+	if query.From, err = parseTimeParam(c.Query("from")); err == nil {
+		query.To, err = parseTimeParam(c.Query("to"))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return query, false
+	}
+	return query, true
+}
+
+// handleGetTrades 查询交易记录（开仓单、平仓成交及已实现盈亏）
+func (s *Server) handleGetTrades(c *gin.Context) {
+	if !s.requireTradingService(c) {
+		return
+	}
+	query, ok := s.bindTradeQuery(c)
+	if !ok {
+		return
+	}
+
+	trades, err := s.tradingService.GetTrades(query)
+	if err != nil {
+		logger.Errorf("查询交易记录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "查询交易记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, TradesResponse{
+		TotalCount: len(trades),
+		Trades:     trades,
+	})
+}
+
+// handlePnLSummary 汇总时间范围内开仓的已实现盈亏
+func (s *Server) handlePnLSummary(c *gin.Context) {
+	if !s.requireTradingService(c) {
+		return
+	}
+	query, ok := s.bindTradeQuery(c)
+	if !ok {
+		return
+	}
+
+	summary, err := s.tradingService.GetPnLSummary(query)
+	if err != nil {
+		logger.Errorf("汇总盈亏失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "汇总盈亏失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
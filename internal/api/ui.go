@@ -0,0 +1,27 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uiFiles 内置的网页面板（单页应用，通过 /api 接口获取数据）
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// registerUI 注册网页面板 /ui
+// 页面本身不需要认证，启用认证时在页面中填写token，接口请求会携带 Authorization 请求头
+func (s *Server) registerUI() {
+	sub, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err) // 内置文件，不会出错
+	}
+	s.engine.StaticFS("/ui", http.FS(sub))
+	s.engine.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/ui/")
+	})
+}
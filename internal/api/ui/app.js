@@ -0,0 +1,290 @@
+// 新币交易面板：轮询状态/新币对/持仓，通过SSE接收实时事件
+(function () {
+  'use strict';
+
+  const TOKEN_KEY = 'nlt_token';
+  const ORDER_EVENT_TYPES = 'order_placed,order_filled,stop_loss_triggered,take_profit_triggered,bracket_failed,bracket_drift,stop_moved,position_closed,risk_limit_breached,error';
+  const EVENT_LABELS = {
+    listing_discovered: '发现新币',
+    order_placed: '下单',
+    order_filled: '成交',
+    stop_loss_triggered: '止损触发',
+    take_profit_triggered: '止盈触发',
+    bracket_failed: '止盈止损单创建失败',
+    bracket_drift: '仓位保护异常',
+    stop_moved: '移动止损',
+    position_closed: '平仓',
+    risk_limit_breached: '风控告警',
+    error: '错误',
+  };
+
+  const $ = (id) => document.getElementById(id);
+  let listings = {};
+  let eventSource = null;
+
+  function token() {
+    return localStorage.getItem(TOKEN_KEY) || '';
+  }
+
+  async function api(method, path, body) {
+    const headers = { 'Content-Type': 'application/json' };
+    if (token()) headers.Authorization = 'Bearer ' + token();
+    const resp = await fetch(path, {
+      method,
+      headers,
+      body: body ? JSON.stringify(body) : undefined,
+    });
+    const data = await resp.json().catch(() => ({}));
+    if (!resp.ok) {
+      throw new Error(data.message || ('HTTP ' + resp.status));
+    }
+    return data;
+  }
+
+  function toast(message, isError) {
+    const el = $('toast');
+    el.textContent = message;
+    el.className = 'toast' + (isError ? ' error' : '');
+    clearTimeout(toast.timer);
+    toast.timer = setTimeout(() => el.classList.add('hidden'), 5000);
+  }
+
+  function escapeHTML(value) {
+    return String(value === undefined || value === null ? '' : value)
+      .replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+  }
+
+  function formatTime(value) {
+    if (!value) return '-';
+    const date = typeof value === 'number' ? new Date(value) : new Date(value);
+    if (isNaN(date.getTime()) || date.getFullYear() < 2000) return '-';
+    return date.toLocaleString('zh-CN', { hour12: false });
+  }
+
+  function formatCountdown(ms) {
+    if (ms <= 0) return '已上线';
+    const total = Math.floor(ms / 1000);
+    const days = Math.floor(total / 86400);
+    const hours = Math.floor((total % 86400) / 3600);
+    const minutes = Math.floor((total % 3600) / 60);
+    const seconds = total % 60;
+    const pad = (n) => String(n).padStart(2, '0');
+    return (days > 0 ? days + '天 ' : '') + pad(hours) + ':' + pad(minutes) + ':' + pad(seconds);
+  }
+
+  function pnlClass(value) {
+    return value > 0 ? 'profit' : value < 0 ? 'loss' : '';
+  }
+
+  async function loadStatus() {
+    const status = await api('GET', '/api/status');
+    $('status').textContent = '币对 ' + status.symbol_count + ' · 新币 ' + status.new_listing_count +
+      ' · 更新于 ' + status.last_update_time + ' · 交易' + (status.trading_enabled ? '已启用' : '未启用');
+
+    const select = $('account');
+    if (select.options.length === 1) {
+      (status.accounts || []).forEach((name) => {
+        const option = document.createElement('option');
+        option.value = name;
+        option.textContent = name;
+        select.appendChild(option);
+      });
+    }
+  }
+
+  async function loadListings() {
+    listings = await api('GET', '/api/new-listings');
+    renderListings();
+  }
+
+  function renderListings() {
+    const rows = Object.values(listings).sort((a, b) => (b.OnboardDate || 0) - (a.OnboardDate || 0));
+    $('listing-count').textContent = rows.length + ' 个';
+    $('listings').innerHTML = rows.map((l) => '<tr>' +
+      '<td>' + escapeHTML(l.Symbol) + '</td>' +
+      '<td>' + formatTime(l.OnboardDate) + '</td>' +
+      '<td>' + formatTime(l.FoundTime) + '</td>' +
+      '<td>' + escapeHTML(l.Source) + '</td>' +
+      '<td>' + (l.IsOrdered ? '<span class="profit">是</span>' : '<span class="muted">否</span>') + '</td>' +
+      '<td>' + formatTime(l.OrderTime) + '</td>' +
+      '</tr>').join('');
+    renderUpcoming();
+  }
+
+  function renderUpcoming() {
+    const now = Date.now();
+    const rows = Object.values(listings)
+      .filter((l) => l.OnboardDate > now)
+      .sort((a, b) => a.OnboardDate - b.OnboardDate);
+    $('upcoming-count').textContent = rows.length + ' 个';
+    $('upcoming').innerHTML = rows.map((l) => '<tr>' +
+      '<td>' + escapeHTML(l.Symbol) + '</td>' +
+      '<td>' + formatTime(l.OnboardDate) + '</td>' +
+      '<td class="num">' + formatCountdown(l.OnboardDate - now) + '</td>' +
+      '<td>' + escapeHTML(l.Source) + '</td>' +
+      '<td class="num">' + Number(l.Confidence || 0).toFixed(2) + '</td>' +
+      '<td>' + (l.IsOrdered ? '<span class="muted">已下单</span>' :
+        '<button class="danger" data-action="entry" data-symbol="' + escapeHTML(l.Symbol) + '">开仓</button>') + '</td>' +
+      '</tr>').join('');
+  }
+
+  async function loadPositions() {
+    const account = $('account').value;
+    let result;
+    try {
+      result = await api('GET', '/api/positions?sort=pnl' + (account ? '&account=' + encodeURIComponent(account) : ''));
+    } catch (err) {
+      $('position-summary').textContent = err.message;
+      $('positions').innerHTML = '';
+      return;
+    }
+    const positions = result.positions || [];
+    const summary = result.summary || {};
+    const totalPnl = summary.total_unrealized_profit || 0;
+    $('position-summary').innerHTML = positions.length + ' 个（盈利 ' + (summary.profitable_count || 0) +
+      ' / 亏损 ' + (summary.losing_count || 0) + '），名义价值 ' + (summary.total_notional || 0).toFixed(2) +
+      ' USDT，未实现盈亏 <span class="' + pnlClass(totalPnl) + '">' + totalPnl.toFixed(2) + '</span> USDT';
+    $('positions').innerHTML = '';
+      return;
+    }
+    const positions = result.positions || [];
+    const totalPnl = positions.reduce((sum, p) => sum + p.unrealized_profit, 0);
+    $('position-summary').innerHTML = positions.length + ' 个，未实现盈亏 <span class="' + pnlClass(totalPnl) + '">' +
+      totalPnl.toFixed(2) + '</span> USDT';
+    $('positions').innerHTML = positions.map((p) => {
+      const side = p.position_side === 'BOTH' ? (p.position_amt < 0 ? '空' : '多') : p.position_side;
+      const data = ' data-symbol="' + escapeHTML(p.symbol) + '" data-account="' + escapeHTML(p.account) +
+        '" data-side="' + escapeHTML(p.position_side === 'BOTH' ? '' : p.position_side) + '"';
+      return '<tr>' +
+        '<td>' + escapeHTML(p.account) + '</td>' +
+        '<td>' + escapeHTML(p.symbol) + '</td>' +
+        '<td>' + escapeHTML(side) + '</td>' +
+        '<td class="num">' + p.position_amt + '</td>' +
+        '<td class="num">' + p.entry_price + '</td>' +
+        '<td class="num">' + p.mark_price + '</td>' +
+        '<td class="num">' + (p.liquidation_price || '-') + '</td>' +
+        '<td class="num' + (p.distance_to_liquidation_percent < 10 ? ' loss' : '') + '">' +
+        (p.distance_to_liquidation_percent === undefined ? '-' : p.distance_to_liquidation_percent.toFixed(2) + '%') + '</td>' +
+        '<td class="num">' + p.notional.toFixed(2) + '</td>' +
+        '<td class="num ' + pnlClass(p.unrealized_profit) + '">' + p.unrealized_profit.toFixed(4) + '</td>' +
+        '<td class="num ' + pnlClass(p.profit_percent) + '">' + p.profit_percent.toFixed(2) + '%</td>' +
+        '<td><button class="danger" data-action="close"' + data + '>平仓</button> ' +
+        '<button data-action="cancel-brackets"' + data + '>撤销止盈止损</button></td>' +
+        '</tr>';
+    }).join('');
+  }
+
+  async function loadOrders() {
+    let recent;
+    try {
+      recent = await api('GET', '/api/events/recent?limit=50&types=' + ORDER_EVENT_TYPES);
+    } catch (err) {
+      return;
+    }
+    $('orders').innerHTML = '';
+    recent.forEach(prependOrder);
+  }
+
+  function prependOrder(e) {
+    const row = document.createElement('tr');
+    row.innerHTML = '<td>' + formatTime(e.time) + '</td>' +
+      '<td class="' + (e.type === 'error' || e.type === 'bracket_failed' || e.type === 'bracket_drift' || e.type === 'risk_limit_breached' ? 'loss' : '') + '">' + escapeHTML(EVENT_LABELS[e.type] || e.type) + '</td>' +
+      '<td>' + escapeHTML(e.account || e.exchange) + '</td>' +
+      '<td>' + escapeHTML(e.symbol) + '</td>' +
+      '<td>' + escapeHTML(e.message) + '</td>';
+    const body = $('orders');
+    body.insertBefore(row, body.firstChild);
+    while (body.children.length > 50) body.removeChild(body.lastChild);
+  }
+
+  function connectEvents() {
+    if (eventSource) eventSource.close();
+    const query = '?types=listing_discovered,' + ORDER_EVENT_TYPES + (token() ? '&access_token=' + encodeURIComponent(token()) : '');
+    eventSource = new EventSource('/api/events' + query);
+    eventSource.onopen = () => {
+      $('stream-state').textContent = '实时推送已连接';
+      $('stream-state').className = 'badge ok';
+    };
+    eventSource.onerror = () => {
+      $('stream-state').textContent = '实时推送重连中';
+      $('stream-state').className = 'badge';
+    };
+    ORDER_EVENT_TYPES.split(',').forEach((type) => {
+      eventSource.addEventListener(type, (msg) => {
+        const e = JSON.parse(msg.data);
+        prependOrder(e);
+        if (type !== 'order_placed') toast(e.message, type === 'error');
+        loadPositions();
+      });
+    });
+    eventSource.addEventListener('listing_discovered', (msg) => {
+      const e = JSON.parse(msg.data);
+      toast(e.message);
+      loadListings();
+    });
+  }
+
+  async function handleAction(button) {
+    const symbol = button.dataset.symbol;
+    const account = button.dataset.account || '';
+    try {
+      if (button.dataset.action === 'entry') {
+        if (!confirm('确认对 ' + symbol + ' 开仓？')) return;
+        const result = await api('POST', '/api/simulate/new-listing', { symbols: [symbol] });
+        toast(result.message || '开仓请求已提交');
+        loadListings();
+      } else if (button.dataset.action === 'close') {
+        if (!confirm('确认市价平掉 ' + account + ' 的 ' + symbol + ' 仓位？')) return;
+        const result = await api('POST', '/api/positions/close', { account, symbol, position_side: button.dataset.side });
+        toast(result.message);
+      } else if (button.dataset.action === 'cancel-brackets') {
+        if (!confirm('确认撤销 ' + account + ' 的 ' + symbol + ' 所有止盈止损单？')) return;
+        const result = await api('POST', '/api/brackets/cancel', { account, symbol });
+        toast(result.message + '（' + (result.canceled || []).length + ' 个）');
+      }
+      loadPositions();
+    } catch (err) {
+      toast(err.message, true);
+    }
+  }
+
+  function refresh() {
+    Promise.all([loadStatus(), loadListings(), loadPositions()]).catch((err) => toast(err.message, true));
+  }
+
+  document.addEventListener('click', (e) => {
+    const button = e.target.closest('button[data-action]');
+    if (button) handleAction(button);
+  });
+
+  $('entry-form').addEventListener('submit', async (e) => {
+    e.preventDefault();
+    const symbol = $('entry-symbol').value.trim().toUpperCase();
+    if (!symbol || !confirm('确认对 ' + symbol + ' 开仓？')) return;
+    try {
+      const result = await api('POST', '/api/simulate/new-listing', {
+        symbols: [symbol],
+        notional_usdt: $('entry-notional').value.trim(),
+      });
+      toast(result.message || '开仓请求已提交');
+      loadListings();
+    } catch (err) {
+      toast(err.message, true);
+    }
+  });
+
+  $('token').value = token();
+  $('save-token').addEventListener('click', () => {
+    localStorage.setItem(TOKEN_KEY, $('token').value.trim());
+    connectEvents();
+    refresh();
+    loadOrders();
+  });
+  $('account').addEventListener('change', loadPositions);
+
+  refresh();
+  loadOrders();
+  connectEvents();
+  setInterval(renderUpcoming, 1000);
+  setInterval(refresh, 15000);
+})();
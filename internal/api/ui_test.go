@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUIServed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{engine: gin.New()}
+	s.registerUI()
+
+	w := serve(s, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/ui/" {
+		t.Fatalf("根路径应重定向到 /ui/，实际: %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	w = serve(s, httptest.NewRequest(http.MethodGet, "/ui/", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "新币交易面板") {
+		t.Errorf("/ui/ 应返回面板页面，实际: %d", w.Code)
+	}
+
+	w = serve(s, httptest.NewRequest(http.MethodGet, "/ui/app.js", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/ui/app.js 应返回脚本，实际: %d", w.Code)
+	}
+}
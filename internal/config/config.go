@@ -1,18 +1,154 @@
 package config
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 // Config 应用配置
 type Config struct {
-	Binance BinanceConfig `yaml:"binance"`
-	Trading TradingConfig `yaml:"trading"`
-	Log     LogConfig     `yaml:"log"`
+	Binance        BinanceConfig        `yaml:"binance"`
+	Accounts       []AccountConfig      `yaml:"accounts,omitempty"`
+	Bybit          BybitConfig          `yaml:"bybit"`
+	OKX            OKXConfig            `yaml:"okx"`
+	Trading        TradingConfig        `yaml:"trading"`
+	Monitor        MonitorConfig        `yaml:"monitor"`
+	ListingSources ListingSourcesConfig `yaml:"listing_sources"`
+	Server         ServerConfig         `yaml:"server"`
+	Journal        JournalConfig        `yaml:"journal"`
+	Notify         NotifyConfig         `yaml:"notify"`
+	Log            LogConfig            `yaml:"log"`
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Port                string        `yaml:"port,omitempty"`                  // HTTP服务端口，命令行 -port 优先，默认8081
+	ConfigWatchInterval time.Duration `yaml:"config_watch_interval,omitempty"` // 检查配置文件变更的间隔（例如："5s"），0表示不监听
+	AllowedOrigins      []string      `yaml:"allowed_origins,omitempty"`       // CORS允许的来源，留空允许所有来源（*）
+	EventBufferSize     int           `yaml:"event_buffer_size,omitempty"`     // 保留的历史事件数量（用于断线续传），默认1000
+	Auth                AuthConfig    `yaml:"auth"`
+}
+
+// API角色
+const (
+	RoleRead   = "read"   // 只读：状态、新币列表、持仓等查询接口
+	RoleTrader = "trader" // 交易：下单、平仓、热更新配置等接口（包含只读权限）
+)
+
+// AuthConfig HTTP接口认证配置
+type AuthConfig struct {
+	Enabled     bool              `yaml:"enabled"`                // 是否启用认证（/health 和 webhook 接口除外）
+	Clients     []APIClientConfig `yaml:"clients,omitempty"`      // 调用方列表
+	IPAllowlist []string          `yaml:"ip_allowlist,omitempty"` // 允许访问的IP或网段（CIDR），留空不限制
+	// 信任的反向代理（IP或网段），只有来自这些地址的请求才按 X-Forwarded-For 取客户端IP；留空时不信任任何代理
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
+}
+
+// APIClientConfig API调用方
+// 认证方式二选一：Authorization: Bearer <token>，或使用secret对请求签名（HMAC_SHA256）
+type APIClientConfig struct {
+	Name       string `yaml:"name"`                  // 调用方名称，签名认证时放在请求头 X-API-Key 中
+	Role       string `yaml:"role"`                  // 角色 read/trader
+	Token      string `yaml:"token,omitempty"`       // Bearer token
+	Secret     string `yaml:"secret,omitempty"`      // 签名密钥
+	TokenFile  string `yaml:"token_file,omitempty"`  // 可选，从文件读取token
+	SecretFile string `yaml:"secret_file,omitempty"` // 可选，从文件读取secret
 }
 
 // BinanceConfig 币安API配置
 type BinanceConfig struct {
-	APIKey    string `yaml:"api_key"`
-	SecretKey string `yaml:"secret_key"`
-	APIType   string `yaml:"api_type,omitempty"` // API类型: fapi (U本位合约) 或 papi (统一账户)，默认fapi
-	BaseURL   string `yaml:"base_url,omitempty"` // 可选，默认使用生产环境
+	APIKey        string `yaml:"api_key"`
+	SecretKey     string `yaml:"secret_key"`
+	APIKeyFile    string `yaml:"api_key_file,omitempty"`    // 可选，从文件读取api_key（优先于api_key）
+	SecretKeyFile string `yaml:"secret_key_file,omitempty"` // 可选，从文件读取secret_key（优先于secret_key）
+	APIType       string `yaml:"api_type,omitempty"`        // API类型: fapi (U本位合约) 或 papi (统一账户)，默认fapi
+	BaseURL       string `yaml:"base_url,omitempty"`        // 可选，默认使用生产环境
+}
+
+// DefaultAccountName binance配置块对应的隐式账户名称
+const DefaultAccountName = "default"
+
+// AccountConfig 币安子账户配置，下单时按账户并行分发
+type AccountConfig struct {
+	Name               string  `yaml:"name"`                          // 账户名称，用于 ?account= 筛选
+	Enabled            bool    `yaml:"enabled"`                       // 是否启用
+	APIKey             string  `yaml:"api_key"`                       // API密钥
+	SecretKey          string  `yaml:"secret_key"`                    // 密钥
+	APIKeyFile         string  `yaml:"api_key_file,omitempty"`        // 可选，从文件读取api_key
+	SecretKeyFile      string  `yaml:"secret_key_file,omitempty"`     // 可选，从文件读取secret_key
+	APIType            string  `yaml:"api_type,omitempty"`            // API类型: fapi 或 papi，默认fapi
+	BaseURL            string  `yaml:"base_url,omitempty"`            // 可选，默认使用生产环境
+	NotionalMultiplier float64 `yaml:"notional_multiplier,omitempty"` // 下单金额倍数，0表示1倍
+}
+
+// BinanceConfig 转换为币安API配置
+func (a AccountConfig) BinanceConfig() BinanceConfig {
+	return BinanceConfig{
+		APIKey:    a.APIKey,
+		SecretKey: a.SecretKey,
+		APIType:   a.APIType,
+		BaseURL:   a.BaseURL,
+	}
+}
+
+// BinanceAccounts 返回所有已启用且配置了密钥的币安账户
+// binance配置块填写了密钥时作为名为default的账户排在最前（accounts中已有同名账户时忽略）
+func (c *Config) BinanceAccounts() []AccountConfig {
+	accounts := make([]AccountConfig, 0, len(c.Accounts)+1)
+
+	hasDefault := false
+	for _, account := range c.Accounts {
+		if account.Name == DefaultAccountName {
+			hasDefault = true
+		}
+	}
+	if !hasDefault && c.Binance.APIKey != "" && c.Binance.SecretKey != "" {
+		accounts = append(accounts, AccountConfig{
+			Name:               DefaultAccountName,
+			Enabled:            true,
+			APIKey:             c.Binance.APIKey,
+			SecretKey:          c.Binance.SecretKey,
+			APIType:            c.Binance.APIType,
+			BaseURL:            c.Binance.BaseURL,
+			NotionalMultiplier: 1,
+		})
+	}
+
+	for _, account := range c.Accounts {
+		if !account.Enabled || account.APIKey == "" || account.SecretKey == "" {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// BybitConfig Bybit API配置（USDT永续）
+type BybitConfig struct {
+	Enabled       bool   `yaml:"enabled"`                   // 是否启用Bybit交易
+	APIKey        string `yaml:"api_key"`                   // API密钥
+	SecretKey     string `yaml:"secret_key"`                // 密钥
+	APIKeyFile    string `yaml:"api_key_file,omitempty"`    // 可选，从文件读取api_key
+	SecretKeyFile string `yaml:"secret_key_file,omitempty"` // 可选，从文件读取secret_key
+	BaseURL       string `yaml:"base_url,omitempty"`        // 可选，默认使用生产环境
+	WatchListings bool   `yaml:"watch_listings,omitempty"`  // 是否监控Bybit新上线合约
+}
+
+// OKXConfig OKX API配置（USDT永续）
+type OKXConfig struct {
+	Enabled        bool   `yaml:"enabled"`                   // 是否启用OKX交易
+	APIKey         string `yaml:"api_key"`                   // API密钥
+	SecretKey      string `yaml:"secret_key"`                // 密钥
+	Passphrase     string `yaml:"passphrase"`                // API密码短语
+	APIKeyFile     string `yaml:"api_key_file,omitempty"`    // 可选，从文件读取api_key
+	SecretKeyFile  string `yaml:"secret_key_file,omitempty"` // 可选，从文件读取secret_key
+	PassphraseFile string `yaml:"passphrase_file,omitempty"` // 可选，从文件读取passphrase
+	BaseURL        string `yaml:"base_url,omitempty"`        // 可选，默认使用生产环境
+	WatchListings  bool   `yaml:"watch_listings,omitempty"`  // 是否监控OKX新上线合约
 }
 
 // TradingConfig 交易配置
@@ -21,22 +157,242 @@ type TradingConfig struct {
 	StopLoss   StopLossConfig   `yaml:"stop_loss"`
 	TakeProfit TakeProfitConfig `yaml:"take_profit"`
 	// 订单配置
-	DefaultNotional string `yaml:"default_notional"` // 默认下单USDT金额（例如："10"表示10 USDT）
-	PositionSide    string `yaml:"position_side"`    // 持仓方向 BOTH/LONG/SHORT
+	DefaultNotional string      `yaml:"default_notional"`    // 默认下单USDT金额（例如："10"表示10 USDT）
+	PositionSide    string      `yaml:"position_side"`       // 持仓方向 BOTH/LONG/SHORT（双向持仓模式下按开仓方向自动取LONG/SHORT）
+	Direction       string      `yaml:"direction,omitempty"` // 开仓方向 SHORT/LONG，默认SHORT
+	Entry           EntryConfig `yaml:"entry,omitempty"`     // 开仓方式和滑点保护
+	// 按持仓时间平仓
+	MaxHoldingDuration time.Duration `yaml:"max_holding_duration,omitempty"` // 开仓后最长持仓时间，超过后市价平仓并撤销止盈止损单，0表示不限制
+	PartialExits       []PartialExit `yaml:"partial_exits,omitempty"`        // 持仓期间按时间分批平仓
+	// 资金费率过滤和结算前平仓
+	Funding FundingConfig `yaml:"funding,omitempty"`
+	// 按币对覆盖的策略规则，按顺序匹配，第一条匹配的规则生效
+	Rules []StrategyRule `yaml:"rules,omitempty"`
+}
+
+// StrategyRule 策略规则：匹配条件 + 覆盖的交易参数（留空表示沿用默认配置）
+type StrategyRule struct {
+	Name string `yaml:"name"` // 规则名称
+
+	// 匹配条件（均为可选，同时设置时需全部满足）
+	Symbol       string `yaml:"symbol,omitempty"`        // 币对通配符，例如 "*DOGE*"、"1000*USDT"
+	SymbolRegex  string `yaml:"symbol_regex,omitempty"`  // 币对正则表达式，例如 "^(PEPE|SHIB)"
+	OnboardHours []int  `yaml:"onboard_hours,omitempty"` // 上线时间所在小时（UTC，0-23）
+
+	// 覆盖参数
+	Notional          string   `yaml:"notional,omitempty"`            // 下单USDT金额
+	StopLossPercent   *float64 `yaml:"stop_loss_percent,omitempty"`   // 止损百分比，0表示不设止损
+	TakeProfitPercent *float64 `yaml:"take_profit_percent,omitempty"` // 止盈百分比，0表示不设止盈
+	WorkingType       string   `yaml:"working_type,omitempty"`        // 止盈止损触发类型 MARK_PRICE/CONTRACT_PRICE
+	Direction         string   `yaml:"direction,omitempty"`           // 开仓方向 SHORT/LONG
+
+	MaxHoldingDuration *time.Duration `yaml:"max_holding_duration,omitempty"` // 最长持仓时间，0表示不限制
+	PartialExits       []PartialExit  `yaml:"partial_exits,omitempty"`        // 分批平仓检查点，设置后替换默认配置
+}
+
+// PartialExit 分批平仓检查点：开仓后经过After时，市价平掉开仓数量的Percent
+type PartialExit struct {
+	After   time.Duration `yaml:"after"`   // 开仓后经过的时间（例如："1h"）
+	Percent float64       `yaml:"percent"` // 本次平掉开仓数量的百分比（例如：50 表示50%）
+}
+
+// FundingConfig 资金费率：开仓前按预计持仓时间估算资金费成本，持仓期间在结算前平掉需要支付高额资金费的仓位
+// 资金费率均按百分比填写（例如 0.5 表示0.5%）
+type FundingConfig struct {
+	MaxCostPercent     float64       `yaml:"max_cost_percent,omitempty"`     // 预计持仓期间需要支付的资金费上限（占开仓金额的百分比），超过时跳过开仓，0表示不检查
+	ExpectedHolding    time.Duration `yaml:"expected_holding,omitempty"`     // 预计持仓时间，0表示使用最长持仓时间（未设置时为24小时）
+	CloseBeforeFunding time.Duration `yaml:"close_before_funding,omitempty"` // 距下次结算不足该时间且需要支付的资金费率达到 close_min_rate 时市价平仓，0表示不启用
+	CloseMinRate       float64       `yaml:"close_min_rate,omitempty"`       // 结算前平仓的资金费率阈值（百分比），0表示只要需要支付资金费就平仓
+}
+
+// 开仓方式
+const (
+	EntryModeMarket        = "market"           // 市价单（默认）
+	EntryModeLimitIOC      = "limit_ioc_at_bps" // IOC限价单，价格为买一/卖一价向成交方向偏移limit_bps个基点
+	EntryModePostOnlyChase = "post_only_chase"  // 只做maker的GTX限价单，价格变化时撤单按新的买一/卖一价重挂
+)
+
+// 开仓滑点超过上限时的处理方式
+const (
+	SlippageActionClose = "close" // 市价平掉开仓单（默认）
+	SlippageActionWarn  = "warn"  // 保留仓位，只发送告警
+)
+
+// EntryConfig 开仓方式：新币上线初期盘口很薄，市价单可能严重滑点，可以改用限价单开仓
+type EntryConfig struct {
+	Mode           string        `yaml:"mode,omitempty"`             // market/limit_ioc_at_bps/post_only_chase，默认market
+	LimitBps       float64       `yaml:"limit_bps,omitempty"`        // limit_ioc_at_bps：相对买一/卖一价的偏移（基点，1bp=0.01%）
+	ChaseInterval  time.Duration `yaml:"chase_interval,omitempty"`   // post_only_chase：检查成交和重挂的间隔，默认1秒
+	ChaseTimeout   time.Duration `yaml:"chase_timeout,omitempty"`    // post_only_chase：最长等待时间，超时后撤单，默认30秒
+	MaxSlippageBps float64       `yaml:"max_slippage_bps,omitempty"` // 成交均价相对下单前参考价格的最大滑点（基点），0表示不检查
+	OnSlippage     string        `yaml:"on_slippage,omitempty"`      // 滑点超过上限时的处理方式 close/warn，默认close
+
+	Slicing   EntrySlicingConfig `yaml:"slicing,omitempty"`   // 大额开仓拆单
+	Liquidity LiquidityConfig    `yaml:"liquidity,omitempty"` // 开仓前的盘口流动性检查
 }
 
+// 盘口深度不足时的处理方式
+const (
+	LiquidityActionSkip     = "skip"     // 跳过开仓（默认）
+	LiquidityActionDownsize = "downsize" // 按深度减少开仓金额
+)
+
+// LiquidityConfig 开仓前的盘口流动性检查：深度不足或价差过大时跳过开仓或减少开仓金额
+type LiquidityConfig struct {
+	DepthBps         float64 `yaml:"depth_bps,omitempty"`          // 统计对手方向最优价（做空为买一价）多少基点以内的深度，默认50
+	MinDepthMultiple float64 `yaml:"min_depth_multiple,omitempty"` // 深度（USDT）至少为开仓金额的倍数，0表示不检查深度
+	MaxSpreadBps     float64 `yaml:"max_spread_bps,omitempty"`     // 买一卖一价差上限（基点），超过时跳过开仓，0表示不检查
+	Action           string  `yaml:"action,omitempty"`             // 深度不足时的处理方式 skip/downsize，默认skip
+}
+
+// EntrySlicingConfig 大额开仓拆单（TWAP/冰山）：开仓金额达到min_notional时拆成多个子单，在duration内依次下单
+type EntrySlicingConfig struct {
+	MinNotional     float64       `yaml:"min_notional,omitempty"`       // 开仓金额（USDT）达到该值时拆单，0表示不拆单
+	Slices          int           `yaml:"slices,omitempty"`             // 子单数量，默认5
+	Duration        time.Duration `yaml:"duration,omitempty"`           // 第一个到最后一个子单的时间跨度，默认30秒
+	SizeJitter      float64       `yaml:"size_jitter,omitempty"`        // 子单金额随机浮动的百分比（0~100），例如20表示±20%
+	TimeJitter      float64       `yaml:"time_jitter,omitempty"`        // 下单间隔随机浮动的百分比（0~100）
+	MaxPriceMoveBps float64       `yaml:"max_price_move_bps,omitempty"` // 价格相对第一个子单前的参考价格向不利方向变动超过该基点数时停止拆单，0表示不限制
+}
+
+// 止损单创建失败时的处理方式
+const (
+	StopLossOnFailureClose = "close" // 市价平掉开仓单（默认）
+	StopLossOnFailureKeep  = "keep"  // 保留仓位，只发送告警
+)
+
 // StopLossConfig 止损配置
 type StopLossConfig struct {
-	Enabled     bool    `yaml:"enabled"`      // 是否启用止损
-	Percent     float64 `yaml:"percent"`      // 止损百分比（例如：2.0 表示2%）
-	WorkingType string  `yaml:"working_type"` // 触发类型 MARK_PRICE/CONTRACT_PRICE
+	Enabled     bool    `yaml:"enabled"`              // 是否启用止损
+	Percent     float64 `yaml:"percent"`              // 止损百分比（例如：2.0 表示2%）
+	WorkingType string  `yaml:"working_type"`         // 触发类型 MARK_PRICE/CONTRACT_PRICE
+	Retries     *int    `yaml:"retries,omitempty"`    // 止损单创建失败后的重试次数，默认2，0表示不重试
+	OnFailure   string  `yaml:"on_failure,omitempty"` // 重试后仍失败时的处理方式 close/keep，默认close
+
+	Ratchet StopRatchetConfig `yaml:"ratchet,omitempty"` // 浮盈后移动止损
+}
+
+// StopRatchetConfig 移动止损：浮盈达到阈值后把止损移到开仓价，之后按阶梯锁定利润（止损只会收紧，不会放宽）
+type StopRatchetConfig struct {
+	BreakevenPercent float64    `yaml:"breakeven_percent,omitempty"` // 浮盈达到该百分比时止损移到开仓价，0表示不移到开仓价
+	Steps            []StopStep `yaml:"steps,omitempty"`             // 阶梯，按浮盈从小到大排列
+}
+
+// StopStep 移动止损阶梯：浮盈达到ProfitPercent时，止损移到锁定LockPercent利润的价格
+type StopStep struct {
+	ProfitPercent float64 `yaml:"profit_percent"` // 浮盈百分比（按开仓价计算）
+	LockPercent   float64 `yaml:"lock_percent"`   // 锁定的利润百分比，必须小于profit_percent
 }
 
 // TakeProfitConfig 止盈配置
 type TakeProfitConfig struct {
-	Enabled     bool    `yaml:"enabled"`      // 是否启用止盈
-	Percent     float64 `yaml:"percent"`      // 止盈百分比（例如：5.0 表示5%）
-	WorkingType string  `yaml:"working_type"` // 触发类型 MARK_PRICE/CONTRACT_PRICE
+	Enabled     bool              `yaml:"enabled"`          // 是否启用止盈
+	Percent     float64           `yaml:"percent"`          // 止盈百分比（例如：5.0 表示5%）
+	WorkingType string            `yaml:"working_type"`     // 触发类型 MARK_PRICE/CONTRACT_PRICE
+	Ladder      []TakeProfitLevel `yaml:"ladder,omitempty"` // 分档止盈，设置后替代percent，每档为只减仓的止盈单
+}
+
+// TakeProfitLevel 分档止盈的一档
+type TakeProfitLevel struct {
+	Percent float64     `yaml:"percent" json:"percent"` // 止盈百分比
+	Size    SizePercent `yaml:"size" json:"size"`       // 平掉开仓数量的比例，各档之和为100
+}
+
+// SizePercent 百分比，yaml中可以写作 40 或 "40%"
+type SizePercent float64
+
+// UnmarshalYAML 解析百分比，允许带%后缀
+func (p *SizePercent) UnmarshalYAML(value *yaml.Node) error {
+	text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value.Value), "%"))
+	parsed, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("第 %d 行: 无效的百分比 %q", value.Line, value.Value)
+	}
+	*p = SizePercent(parsed)
+	return nil
+}
+
+// MonitorConfig 币对监控配置
+type MonitorConfig struct {
+	PollInterval        time.Duration  `yaml:"poll_interval"`                   // 常规轮询间隔（例如："30s"），默认2分钟
+	FastPollInterval    time.Duration  `yaml:"fast_poll_interval"`              // 临近上线时的快速轮询间隔（例如："3s"）
+	FastPollWindow      time.Duration  `yaml:"fast_poll_window"`                // 距离OnboardDate多久以内切换为快速轮询（例如："10m"）
+	BracketPollInterval time.Duration  `yaml:"bracket_poll_interval,omitempty"` // 止盈止损单状态轮询间隔，默认10秒
+	Watchdog            WatchdogConfig `yaml:"watchdog,omitempty"`              // 仓位保护检查
+	TimeExitInterval    time.Duration  `yaml:"time_exit_interval,omitempty"`    // 按持仓时间平仓的检查间隔，默认30秒
+	StopRatchetInterval time.Duration  `yaml:"stop_ratchet_interval,omitempty"` // 移动止损的检查间隔，默认10秒
+}
+
+// WatchdogConfig 仓位保护检查：定期对比持仓和挂单，补建缺少的止损/止盈单
+type WatchdogConfig struct {
+	Enabled  bool          `yaml:"enabled"`            // 是否启用
+	Interval time.Duration `yaml:"interval,omitempty"` // 检查间隔，默认1分钟
+	DryRun   bool          `yaml:"dry_run,omitempty"`  // 只报告问题，不补建
+	Resize   bool          `yaml:"resize,omitempty"`   // 条件单数量与持仓数量不一致时撤销并按持仓数量重建
+}
+
+// ListingSourcesConfig 额外的新币来源配置（exchangeInfo轮询始终启用）
+type ListingSourcesConfig struct {
+	Announcement AnnouncementSourceConfig `yaml:"announcement"`
+	Webhook      WebhookSourceConfig      `yaml:"webhook"`
+}
+
+// AnnouncementSourceConfig 公告来源配置
+type AnnouncementSourceConfig struct {
+	Enabled      bool          `yaml:"enabled"`       // 是否启用公告解析
+	URL          string        `yaml:"url"`           // 公告列表地址（JSON接口或RSS）
+	Format       string        `yaml:"format"`        // 格式: json/rss，默认json
+	PollInterval time.Duration `yaml:"poll_interval"` // 拉取间隔（例如："30s"）
+}
+
+// WebhookSourceConfig webhook推送来源配置
+type WebhookSourceConfig struct {
+	Enabled bool   `yaml:"enabled"` // 是否启用 POST /api/listings/webhook
+	Token   string `yaml:"token"`   // 校验请求头 X-Webhook-Token，启用时必填
+}
+
+// JournalConfig 交易日志配置
+type JournalConfig struct {
+	File string `yaml:"file,omitempty"` // 开仓记录文件（JSON Lines，追加写入），留空只保存在内存中，重启后丢失
+}
+
+// 通知渠道类型
+const (
+	NotifyTelegram = "telegram"
+	NotifySlack    = "slack"
+	NotifyDingTalk = "dingtalk"
+	NotifyFeishu   = "feishu"
+	NotifyWebhook  = "webhook"
+)
+
+// NotifyConfig 通知配置：事件按规则路由到通知渠道
+type NotifyConfig struct {
+	Channels  []NotifyChannelConfig `yaml:"channels,omitempty"`
+	Rules     []NotifyRule          `yaml:"rules,omitempty"`     // 路由规则，留空时所有事件发送到所有渠道
+	Templates map[string]string     `yaml:"templates,omitempty"` // 按事件类型自定义消息模板（text/template），key为事件类型或 default
+}
+
+// NotifyChannelConfig 通知渠道
+type NotifyChannelConfig struct {
+	Name           string            `yaml:"name"`                      // 渠道名称，规则中引用
+	Type           string            `yaml:"type"`                      // telegram/slack/dingtalk/feishu/webhook
+	URL            string            `yaml:"url,omitempty"`             // Webhook地址；telegram为API地址，默认 https://api.telegram.org
+	BotToken       string            `yaml:"bot_token,omitempty"`       // telegram机器人token
+	BotTokenFile   string            `yaml:"bot_token_file,omitempty"`  // 可选，从文件读取bot_token
+	ChatID         string            `yaml:"chat_id,omitempty"`         // telegram会话ID
+	Secret         string            `yaml:"secret,omitempty"`          // 钉钉/飞书加签密钥
+	SecretFile     string            `yaml:"secret_file,omitempty"`     // 可选，从文件读取secret
+	Headers        map[string]string `yaml:"headers,omitempty"`         // webhook额外请求头
+	RateLimit      int               `yaml:"rate_limit,omitempty"`      // 每分钟最多发送的消息数，默认20，超出的消息丢弃并在下一条消息中提示
+	RequestTimeout time.Duration     `yaml:"request_timeout,omitempty"` // 单次请求超时，默认10s
+}
+
+// NotifyRule 路由规则：事件类型、账户、币对都匹配时发送到指定渠道，各条件留空表示不限
+type NotifyRule struct {
+	Events   []string `yaml:"events,omitempty"`   // 事件类型
+	Accounts []string `yaml:"accounts,omitempty"` // 账户
+	Symbols  []string `yaml:"symbols,omitempty"`  // 币对
+	Channels []string `yaml:"channels"`           // 渠道名称
 }
 
 // LogConfig 日志配置
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadExampleConfig(t *testing.T) {
+	cfg, err := LoadConfig("../../config.yaml.example")
+	if err != nil {
+		t.Fatalf("示例配置应能通过校验: %v", err)
+	}
+	if cfg.Monitor.PollInterval != 2*time.Minute {
+		t.Errorf("poll_interval解析不正确: %v", cfg.Monitor.PollInterval)
+	}
+	if rule := cfg.Trading.Rules[0]; rule.MaxHoldingDuration == nil || *rule.MaxHoldingDuration != 6*time.Hour || rule.PartialExits[0].After != 2*time.Hour {
+		t.Errorf("规则的按持仓时间平仓配置解析不正确: %+v", rule)
+	}
+}
+
+func TestLoadTakeProfitLadder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "trading:\n  take_profit:\n    enabled: true\n    ladder:\n      - percent: 3\n        size: \"40%\"\n      - percent: 6\n        size: 60\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("分档止盈配置应能通过校验: %v", err)
+	}
+	if ladder := cfg.Trading.TakeProfit.Ladder; len(ladder) != 2 || ladder[0].Size != 40 || ladder[1].Size != 60 {
+		t.Errorf("size应支持 \"40%%\" 和 60 两种写法: %+v", ladder)
+	}
+}
+
+func TestLoadConfigUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("trading:\n  stop_los:\n    percent: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("未知字段应返回错误")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := GetDefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("默认配置应通过校验: %v", err)
+	}
+
+	cfg.Trading.PositionSide = "SHRT"
+	cfg.Trading.StopLoss.Percent = -1
+	cfg.Trading.DefaultNotional = "ten"
+	cfg.Accounts = []AccountConfig{{Name: "sub1", Enabled: true}}
+	cfg.Notify.Channels = []NotifyChannelConfig{{Name: "tg", Type: NotifyTelegram}}
+	cfg.Notify.Rules = []NotifyRule{{Channels: []string{"slack"}}}
+	cfg.Trading.MaxHoldingDuration = 2 * time.Hour
+	cfg.Trading.PartialExits = []PartialExit{{After: time.Hour, Percent: 60}, {After: 3 * time.Hour, Percent: 50}}
+	cfg.Trading.TakeProfit.Ladder = []TakeProfitLevel{{Percent: 5, Size: 50}, {Percent: 3, Size: 40}}
+	cfg.Trading.Entry = EntryConfig{Mode: EntryModeLimitIOC, LimitBps: 50, MaxSlippageBps: 30, Slicing: EntrySlicingConfig{MinNotional: 300, Slices: 1},
+		Liquidity: LiquidityConfig{MinDepthMultiple: 5, Action: "shrink"}}
+	cfg.Trading.Funding = FundingConfig{MaxCostPercent: -1}
+	cfg.ListingSources.Webhook = WebhookSourceConfig{Enabled: true}
+
+	err := cfg.Validate()
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("应返回ValidationError，实际: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, fieldErr := range validationErr {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{"trading.position_side", "trading.stop_loss.percent", "trading.default_notional", "accounts[0]",
+		"notify.channels[0]", "notify.rules[0].channels", "trading.partial_exits", "trading.partial_exits[1].after",
+		"trading.take_profit.ladder", "trading.take_profit.ladder[1].percent", "trading.entry.limit_bps",
+		"trading.entry.slicing.slices", "trading.entry.liquidity.action",
+		"trading.funding.max_cost_percent", "listing_sources.webhook.token"} {
+		if !fields[field] {
+			t.Errorf("缺少字段错误 %s，实际: %v", field, validationErr)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.Accounts = []AccountConfig{{Name: "sub-1", Enabled: true}}
+	cfg.Trading.Rules = []StrategyRule{{Name: "meme"}}
+
+	env := map[string]string{
+		"NLT_BINANCE_API_KEY":                      "key",
+		"NLT_TRADING_STOP_LOSS_PERCENT":            "3.5",
+		"NLT_TRADING_TAKE_PROFIT_ENABLED":          "false",
+		"NLT_MONITOR_POLL_INTERVAL":                "45s",
+		"NLT_ACCOUNTS_SUB_1_SECRET_KEY":            "sub-secret",
+		"NLT_TRADING_RULES_MEME_ONBOARD_HOURS":     "0, 8",
+		"NLT_TRADING_RULES_MEME_STOP_LOSS_PERCENT": "6",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	if err := cfg.ApplyEnvFrom(lookup); err != nil {
+		t.Fatalf("应用环境变量失败: %v", err)
+	}
+
+	if cfg.Binance.APIKey != "key" || cfg.Trading.StopLoss.Percent != 3.5 || cfg.Trading.TakeProfit.Enabled {
+		t.Errorf("环境变量覆盖不正确: %+v %+v", cfg.Binance, cfg.Trading)
+	}
+	if cfg.Monitor.PollInterval != 45*time.Second {
+		t.Errorf("时间间隔覆盖不正确: %v", cfg.Monitor.PollInterval)
+	}
+	if cfg.Accounts[0].SecretKey != "sub-secret" {
+		t.Errorf("账户密钥覆盖不正确: %+v", cfg.Accounts[0])
+	}
+	rule := cfg.Trading.Rules[0]
+	if len(rule.OnboardHours) != 2 || rule.OnboardHours[1] != 8 || rule.StopLossPercent == nil || *rule.StopLossPercent != 6 {
+		t.Errorf("规则覆盖不正确: %+v", rule)
+	}
+
+	env["NLT_TRADING_STOP_LOSS_PERCENT"] = "abc"
+	if err := cfg.ApplyEnvFrom(lookup); err == nil {
+		t.Error("无效的环境变量应返回错误")
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "api_key")
+	if err := os.WriteFile(keyFile, []byte("file-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := GetDefaultConfig()
+	cfg.Binance.APIKey = "plain"
+	cfg.Binance.APIKeyFile = keyFile
+	if err := cfg.LoadSecrets(); err != nil {
+		t.Fatalf("读取密钥文件失败: %v", err)
+	}
+	if cfg.Binance.APIKey != "file-key" {
+		t.Errorf("密钥文件应覆盖明文值: %q", cfg.Binance.APIKey)
+	}
+
+	cfg.Binance.SecretKeyFile = filepath.Join(dir, "missing")
+	if err := cfg.LoadSecrets(); err == nil {
+		t.Error("密钥文件不存在时应返回错误")
+	}
+}
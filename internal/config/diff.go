@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change 配置字段变更
+type Change struct {
+	Field           string `json:"field"` // yaml字段路径，例如 trading.stop_loss.percent
+	Old             string `json:"old"`
+	New             string `json:"new"`
+	RestartRequired bool   `json:"restart_required"` // 是否需要重启才能生效
+}
+
+// hotReloadablePrefixes 可以热更新的配置（其余配置修改后需要重启）
+var hotReloadablePrefixes = []string{"trading."}
+
+// IsHotReloadable 判断字段是否支持热更新
+func IsHotReloadable(field string) bool {
+	for _, prefix := range hotReloadablePrefixes {
+		if strings.HasPrefix(field+".", prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff 比较两份配置，返回所有变更的字段；密钥类字段的值会被隐藏
+func Diff(oldCfg, newCfg *Config) []Change {
+	var changes []Change
+	diffValue(reflect.ValueOf(*oldCfg), reflect.ValueOf(*newCfg), "", &changes)
+	return changes
+}
+
+// diffValue 递归比较结构体字段，列表整体比较
+func diffValue(oldV, newV reflect.Value, prefix string, changes *[]Change) {
+	t := oldV.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		field := name
+		if prefix != "" {
+			field = prefix + "." + name
+		}
+
+		oldF, newF := oldV.Field(i), newV.Field(i)
+		if oldF.Kind() == reflect.Struct && oldF.Type() != durationType {
+			diffValue(oldF, newF, field, changes)
+			continue
+		}
+		if reflect.DeepEqual(oldF.Interface(), newF.Interface()) {
+			continue
+		}
+
+		*changes = append(*changes, Change{
+			Field:           field,
+			Old:             displayValue(name, oldF),
+			New:             displayValue(name, newF),
+			RestartRequired: !IsHotReloadable(field),
+		})
+	}
+}
+
+// displayValue 格式化字段值，密钥类字段只显示是否设置
+func displayValue(name string, v reflect.Value) string {
+	if isSecretField(name) {
+		if v.IsZero() {
+			return ""
+		}
+		return "******"
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		if elem := v.Type().Elem(); elem.Kind() == reflect.Struct && hasSecretField(elem) {
+			// 列表项包含密钥（账户、API调用方），只显示名称
+			names := make([]string, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				names = append(names, v.Index(i).FieldByName("Name").String())
+			}
+			return fmt.Sprintf("%v", names)
+		}
+		return fmt.Sprintf("%+v", v.Interface())
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// hasSecretField 判断结构体是否包含密钥类字段
+func hasSecretField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if isSecretField(yamlName(t.Field(i))) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretField 判断是否为密钥类字段
+func isSecretField(name string) bool {
+	for _, keyword := range []string{"key", "secret", "passphrase", "token"} {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 环境变量覆盖的前缀
+// 变量名由yaml字段路径转为大写并以下划线连接，例如 trading.stop_loss.percent 对应 NLT_TRADING_STOP_LOSS_PERCENT
+// 列表项按name字段定位，例如 accounts 中 name 为 sub1 的 api_key 对应 NLT_ACCOUNTS_SUB1_API_KEY
+const EnvPrefix = "NLT"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ApplyEnv 使用进程环境变量覆盖配置
+func (c *Config) ApplyEnv() error {
+	return c.ApplyEnvFrom(os.LookupEnv)
+}
+
+// ApplyEnvFrom 使用lookup提供的环境变量覆盖配置
+func (c *Config) ApplyEnvFrom(lookup func(string) (string, bool)) error {
+	return applyEnv(reflect.ValueOf(c).Elem(), EnvPrefix, lookup)
+}
+
+// applyEnv 递归遍历结构体字段，按yaml标签拼接变量名
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlName(field)
+		if name == "" {
+			continue
+		}
+		key := prefix + "_" + envName(name)
+		fv := v.Field(i)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			if err := applyEnv(fv, key, lookup); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				item := fv.Index(j)
+				itemName := item.FieldByName("Name")
+				if !itemName.IsValid() || itemName.String() == "" {
+					continue
+				}
+				if err := applyEnv(item, key+"_"+envName(itemName.String()), lookup); err != nil {
+					return err
+				}
+			}
+		default:
+			value, ok := lookup(key)
+			if !ok {
+				continue
+			}
+			if err := setValue(fv, value); err != nil {
+				return fmt.Errorf("环境变量 %s 无效: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setValue 将字符串解析后写入字段
+func setValue(fv reflect.Value, value string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(fv.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		fv.Set(elem)
+	case reflect.Slice:
+		// 逗号分隔的列表，例如 NLT_TRADING_RULES_MEME_ONBOARD_HOURS=0,1
+		parts := strings.Split(value, ",")
+		slice := reflect.MakeSlice(fv.Type(), 0, len(parts))
+		for _, part := range parts {
+			elem := reflect.New(fv.Type().Elem()).Elem()
+			if err := setValue(elem, strings.TrimSpace(part)); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		fv.Set(slice)
+	default:
+		return fmt.Errorf("不支持的字段类型 %s", fv.Type())
+	}
+	return nil
+}
+
+// yamlName 返回字段的yaml名称，未设置或忽略的字段返回空
+func yamlName(field reflect.StructField) string {
+	tag := field.Tag.Get("yaml")
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// envName 转换为环境变量格式（大写，非字母数字替换为下划线）
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -1,13 +1,20 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"new_listing_trade/internal/logger"
 )
 
 // LoadConfig 加载配置文件
+// 加载顺序：YAML（不允许未知字段）-> NLT_* 环境变量覆盖 -> *_file 密钥文件 -> 校验
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -15,22 +22,28 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && err != io.EOF {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
 
+	if err := config.finalize(); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
 
-// LoadConfigOrCreateDefault 加载配置文件，如果不存在则创建默认配置
+// LoadConfigOrCreateDefault 加载配置文件，如果不存在则使用默认配置（不会写入文件）
+// 默认配置没有API密钥，可通过 NLT_BINANCE_API_KEY 等环境变量提供
 func LoadConfigOrCreateDefault(path string) (*Config, error) {
 	config, err := LoadConfig(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// 创建默认配置
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("配置文件 %s 不存在，使用默认配置（可参考 config.yaml.example 创建配置文件）", path)
 			defaultConfig := GetDefaultConfig()
-			if err := SaveConfig(path, defaultConfig); err != nil {
-				return nil, fmt.Errorf("创建默认配置文件失败: %w", err)
+			if err := defaultConfig.finalize(); err != nil {
+				return nil, err
 			}
 			return defaultConfig, nil
 		}
@@ -39,6 +52,17 @@ func LoadConfigOrCreateDefault(path string) (*Config, error) {
 	return config, nil
 }
 
+// finalize 应用环境变量覆盖、读取密钥文件并校验
+func (c *Config) finalize() error {
+	if err := c.ApplyEnv(); err != nil {
+		return err
+	}
+	if err := c.LoadSecrets(); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
 // SaveConfig 保存配置文件
 func SaveConfig(path string, config *Config) error {
 	data, err := yaml.Marshal(config)
@@ -65,6 +89,7 @@ func GetDefaultConfig() *Config {
 		Trading: TradingConfig{
 			DefaultNotional: "10", // 默认10 USDT
 			PositionSide:    "BOTH",
+			Direction:       "SHORT",
 			StopLoss: StopLossConfig{
 				Enabled:     true,
 				Percent:     2.0, // 2%止损
@@ -76,6 +101,22 @@ func GetDefaultConfig() *Config {
 				WorkingType: "MARK_PRICE",
 			},
 		},
+		Monitor: MonitorConfig{
+			PollInterval:     2 * time.Minute,  // 常规2分钟轮询
+			FastPollInterval: 3 * time.Second,  // 临近上线时3秒轮询
+			FastPollWindow:   10 * time.Minute, // 上线前10分钟内切换为快速轮询
+		},
+		ListingSources: ListingSourcesConfig{
+			Announcement: AnnouncementSourceConfig{
+				Enabled:      false,
+				URL:          "https://www.binance.com/bapi/composite/v1/public/cms/article/list/query?type=1&catalogId=48&pageNo=1&pageSize=20",
+				Format:       "json",
+				PollInterval: 30 * time.Second,
+			},
+		},
+		Server: ServerConfig{
+			Port: "8081",
+		},
 		Log: LogConfig{
 			Level:    "info",         // 默认info级别
 			File:     "logs/app.log", // 默认日志文件路径
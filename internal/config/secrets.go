@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// secretFile 从文件读取的密钥字段
+type secretFile struct {
+	field string  // 配置字段名，用于错误信息
+	file  string  // 文件路径
+	value *string // 读取结果写入的字段
+}
+
+// LoadSecrets 读取 *_file 指定的密钥文件，文件内容（去除首尾空白）覆盖配置文件中的明文值
+func (c *Config) LoadSecrets() error {
+	files := []secretFile{
+		{"binance.api_key_file", c.Binance.APIKeyFile, &c.Binance.APIKey},
+		{"binance.secret_key_file", c.Binance.SecretKeyFile, &c.Binance.SecretKey},
+		{"bybit.api_key_file", c.Bybit.APIKeyFile, &c.Bybit.APIKey},
+		{"bybit.secret_key_file", c.Bybit.SecretKeyFile, &c.Bybit.SecretKey},
+		{"okx.api_key_file", c.OKX.APIKeyFile, &c.OKX.APIKey},
+		{"okx.secret_key_file", c.OKX.SecretKeyFile, &c.OKX.SecretKey},
+		{"okx.passphrase_file", c.OKX.PassphraseFile, &c.OKX.Passphrase},
+	}
+	for i := range c.Accounts {
+		account := &c.Accounts[i]
+		files = append(files,
+			secretFile{fmt.Sprintf("accounts[%d].api_key_file", i), account.APIKeyFile, &account.APIKey},
+			secretFile{fmt.Sprintf("accounts[%d].secret_key_file", i), account.SecretKeyFile, &account.SecretKey},
+		)
+	}
+
+	for i := range c.Server.Auth.Clients {
+		client := &c.Server.Auth.Clients[i]
+		files = append(files,
+			secretFile{fmt.Sprintf("server.auth.clients[%d].token_file", i), client.TokenFile, &client.Token},
+			secretFile{fmt.Sprintf("server.auth.clients[%d].secret_file", i), client.SecretFile, &client.Secret},
+		)
+	}
+
+	for i := range c.Notify.Channels {
+		channel := &c.Notify.Channels[i]
+		files = append(files,
+			secretFile{fmt.Sprintf("notify.channels[%d].bot_token_file", i), channel.BotTokenFile, &channel.BotToken},
+			secretFile{fmt.Sprintf("notify.channels[%d].secret_file", i), channel.SecretFile, &channel.Secret},
+		)
+	}
+
+	for _, f := range files {
+		if f.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.file)
+		if err != nil {
+			return fmt.Errorf("%s: 读取密钥文件失败: %w", f.field, err)
+		}
+		*f.value = strings.TrimSpace(string(data))
+	}
+	return nil
+}
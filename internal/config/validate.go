@@ -0,0 +1,458 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string // yaml字段路径，例如 trading.stop_loss.percent
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError 配置校验错误（包含所有不合法的字段）
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+	return "配置校验失败: " + strings.Join(messages, "; ")
+}
+
+// validator 收集字段错误
+type validator struct {
+	errs ValidationError
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// oneOf 校验取值是否在可选值中（空值视为合法，使用默认值）
+func (v *validator) oneOf(field, value string, options ...string) {
+	if value == "" {
+		return
+	}
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.add(field, "无效的值 %q，可选值: %s", value, strings.Join(options, "/"))
+}
+
+// notional 校验USDT金额（空值视为合法）
+func (v *validator) notional(field, value string) {
+	if value == "" {
+		return
+	}
+	if n, err := strconv.ParseFloat(value, 64); err != nil || n <= 0 {
+		v.add(field, "必须为正数，当前值 %q", value)
+	}
+}
+
+// percent 校验百分比
+func (v *validator) percent(field string, value float64) {
+	if value < 0 || value >= 100 {
+		v.add(field, "必须在 0~100 之间，当前值 %g", value)
+	}
+}
+
+// Validate 校验配置，返回包含所有字段错误的 ValidationError
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("binance.api_type", c.Binance.APIType, "fapi", "papi")
+
+	names := make(map[string]bool)
+	for i, account := range c.Accounts {
+		field := fmt.Sprintf("accounts[%d]", i)
+		if account.Name == "" {
+			v.add(field+".name", "不能为空")
+		} else if names[account.Name] {
+			v.add(field+".name", "账户名称 %q 重复", account.Name)
+		}
+		names[account.Name] = true
+		v.oneOf(field+".api_type", account.APIType, "fapi", "papi")
+		if account.NotionalMultiplier < 0 {
+			v.add(field+".notional_multiplier", "不能为负数")
+		}
+		if account.Enabled && (account.APIKey == "" || account.SecretKey == "") {
+			v.add(field, "已启用的账户必须设置 api_key 和 secret_key")
+		}
+	}
+
+	if c.Bybit.Enabled && (c.Bybit.APIKey == "" || c.Bybit.SecretKey == "") {
+		v.add("bybit", "启用交易时必须设置 api_key 和 secret_key")
+	}
+	if c.OKX.Enabled && (c.OKX.APIKey == "" || c.OKX.SecretKey == "" || c.OKX.Passphrase == "") {
+		v.add("okx", "启用交易时必须设置 api_key、secret_key 和 passphrase")
+	}
+
+	c.Trading.validate(v)
+
+	if c.Monitor.PollInterval < 0 {
+		v.add("monitor.poll_interval", "不能为负数")
+	}
+	if c.Monitor.FastPollWindow > 0 && c.Monitor.FastPollInterval <= 0 {
+		v.add("monitor.fast_poll_interval", "设置了 fast_poll_window 时必须大于0")
+	}
+	if c.Monitor.BracketPollInterval < 0 {
+		v.add("monitor.bracket_poll_interval", "不能为负数")
+	}
+	if c.Monitor.Watchdog.Interval < 0 {
+		v.add("monitor.watchdog.interval", "不能为负数")
+	}
+	if c.Monitor.TimeExitInterval < 0 {
+		v.add("monitor.time_exit_interval", "不能为负数")
+	}
+	if c.Monitor.StopRatchetInterval < 0 {
+		v.add("monitor.stop_ratchet_interval", "不能为负数")
+	}
+
+	announcement := c.ListingSources.Announcement
+	if announcement.Enabled && announcement.URL == "" {
+		v.add("listing_sources.announcement.url", "启用公告来源时不能为空")
+	}
+	v.oneOf("listing_sources.announcement.format", announcement.Format, "json", "rss")
+	// webhook推送的币对会触发真实下单，不允许未认证的推送
+	if webhook := c.ListingSources.Webhook; webhook.Enabled && webhook.Token == "" {
+		v.add("listing_sources.webhook.token", "启用webhook来源时不能为空")
+	}
+
+	if c.Server.Port != "" {
+		if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+			v.add("server.port", "无效的端口 %q", c.Server.Port)
+		}
+	}
+	if c.Server.ConfigWatchInterval < 0 {
+		v.add("server.config_watch_interval", "不能为负数")
+	}
+	if c.Server.EventBufferSize < 0 {
+		v.add("server.event_buffer_size", "不能为负数")
+	}
+	c.Server.Auth.validate(v)
+	c.Notify.validate(v)
+
+	v.oneOf("log.level", c.Log.Level, "trace", "debug", "info", "warn", "warning", "error", "fatal", "panic")
+	if c.Log.MaxSize < 0 {
+		v.add("log.max_size", "不能为负数")
+	}
+	if c.Log.MaxAge < 0 {
+		v.add("log.max_age", "不能为负数")
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validate 校验交易配置和策略规则
+func (t *TradingConfig) validate(v *validator) {
+	v.notional("trading.default_notional", t.DefaultNotional)
+	v.oneOf("trading.position_side", t.PositionSide, "BOTH", "LONG", "SHORT")
+	v.oneOf("trading.direction", strings.ToUpper(t.Direction), "SHORT", "LONG")
+
+	t.Entry.validate(v)
+
+	v.percent("trading.stop_loss.percent", t.StopLoss.Percent)
+	if t.StopLoss.Enabled && t.StopLoss.Percent == 0 {
+		v.add("trading.stop_loss.percent", "启用止损时必须大于0")
+	}
+	v.oneOf("trading.stop_loss.working_type", t.StopLoss.WorkingType, "MARK_PRICE", "CONTRACT_PRICE")
+	if t.StopLoss.Retries != nil && *t.StopLoss.Retries < 0 {
+		v.add("trading.stop_loss.retries", "不能为负数")
+	}
+	v.oneOf("trading.stop_loss.on_failure", t.StopLoss.OnFailure, StopLossOnFailureClose, StopLossOnFailureKeep)
+	t.StopLoss.Ratchet.validate(v)
+
+	v.percent("trading.take_profit.percent", t.TakeProfit.Percent)
+	if t.TakeProfit.Enabled && t.TakeProfit.Percent == 0 && len(t.TakeProfit.Ladder) == 0 {
+		v.add("trading.take_profit.percent", "启用止盈时必须大于0（或设置 ladder）")
+	}
+	v.takeProfitLadder("trading.take_profit.ladder", t.TakeProfit.Ladder)
+	v.oneOf("trading.take_profit.working_type", t.TakeProfit.WorkingType, "MARK_PRICE", "CONTRACT_PRICE")
+
+	if t.MaxHoldingDuration < 0 {
+		v.add("trading.max_holding_duration", "不能为负数")
+	}
+	v.partialExits("trading.partial_exits", t.PartialExits, t.MaxHoldingDuration)
+	t.Funding.validate(v)
+
+	for i, rule := range t.Rules {
+		field := fmt.Sprintf("trading.rules[%d]", i)
+		if rule.Symbol != "" {
+			if _, err := path.Match(rule.Symbol, ""); err != nil {
+				v.add(field+".symbol", "通配符无效: %v", err)
+			}
+		}
+		if rule.SymbolRegex != "" {
+			if _, err := regexp.Compile(rule.SymbolRegex); err != nil {
+				v.add(field+".symbol_regex", "正则表达式无效: %v", err)
+			}
+		}
+		for _, hour := range rule.OnboardHours {
+			if hour < 0 || hour > 23 {
+				v.add(field+".onboard_hours", "小时必须在 0~23 之间，当前值 %d", hour)
+			}
+		}
+		v.notional(field+".notional", rule.Notional)
+		if rule.StopLossPercent != nil {
+			v.percent(field+".stop_loss_percent", *rule.StopLossPercent)
+		}
+		if rule.TakeProfitPercent != nil {
+			v.percent(field+".take_profit_percent", *rule.TakeProfitPercent)
+		}
+		v.oneOf(field+".working_type", rule.WorkingType, "MARK_PRICE", "CONTRACT_PRICE")
+		v.oneOf(field+".direction", strings.ToUpper(rule.Direction), "SHORT", "LONG")
+
+		maxHolding := t.MaxHoldingDuration
+		if rule.MaxHoldingDuration != nil {
+			maxHolding = *rule.MaxHoldingDuration
+			if maxHolding < 0 {
+				v.add(field+".max_holding_duration", "不能为负数")
+			}
+		}
+		v.partialExits(field+".partial_exits", rule.PartialExits, maxHolding)
+	}
+}
+
+// validate 校验移动止损：阶梯的浮盈和锁定利润递增，锁定利润小于浮盈
+func (r *StopRatchetConfig) validate(v *validator) {
+	const field = "trading.stop_loss.ratchet"
+	if r.BreakevenPercent < 0 {
+		v.add(field+".breakeven_percent", "不能为负数")
+	}
+	for i, step := range r.Steps {
+		stepField := fmt.Sprintf("%s.steps[%d]", field, i)
+		if step.ProfitPercent <= 0 {
+			v.add(stepField+".profit_percent", "必须大于0")
+		}
+		if step.LockPercent < 0 || step.LockPercent >= step.ProfitPercent {
+			v.add(stepField+".lock_percent", "必须在 0~profit_percent 之间，当前值 %g", step.LockPercent)
+		}
+		if i > 0 && (step.ProfitPercent <= r.Steps[i-1].ProfitPercent || step.LockPercent <= r.Steps[i-1].LockPercent) {
+			v.add(stepField, "profit_percent 和 lock_percent 必须大于上一级")
+		}
+		if i == 0 && r.BreakevenPercent > 0 && step.ProfitPercent <= r.BreakevenPercent {
+			v.add(stepField+".profit_percent", "必须大于 breakeven_percent")
+		}
+	}
+}
+
+// validate 校验资金费率配置
+func (f *FundingConfig) validate(v *validator) {
+	if f.MaxCostPercent < 0 {
+		v.add("trading.funding.max_cost_percent", "不能为负数")
+	}
+	if f.ExpectedHolding < 0 {
+		v.add("trading.funding.expected_holding", "不能为负数")
+	}
+	if f.CloseBeforeFunding < 0 {
+		v.add("trading.funding.close_before_funding", "不能为负数")
+	}
+	if f.CloseMinRate < 0 {
+		v.add("trading.funding.close_min_rate", "不能为负数")
+	}
+}
+
+// validate 校验开仓方式
+func (e *EntryConfig) validate(v *validator) {
+	v.oneOf("trading.entry.mode", e.Mode, EntryModeMarket, EntryModeLimitIOC, EntryModePostOnlyChase)
+	if e.LimitBps < 0 {
+		v.add("trading.entry.limit_bps", "不能为负数")
+	}
+	if e.ChaseInterval < 0 {
+		v.add("trading.entry.chase_interval", "不能为负数")
+	}
+	if e.ChaseTimeout < 0 {
+		v.add("trading.entry.chase_timeout", "不能为负数")
+	}
+	if e.MaxSlippageBps < 0 {
+		v.add("trading.entry.max_slippage_bps", "不能为负数")
+	}
+	// 限价偏移超过滑点上限时成交后必然触发滑点保护
+	if e.Mode == EntryModeLimitIOC && e.MaxSlippageBps > 0 && e.LimitBps > e.MaxSlippageBps {
+		v.add("trading.entry.limit_bps", "不能大于 max_slippage_bps（%g）", e.MaxSlippageBps)
+	}
+	v.oneOf("trading.entry.on_slippage", e.OnSlippage, SlippageActionClose, SlippageActionWarn)
+
+	slicing := e.Slicing
+	if slicing.MinNotional < 0 {
+		v.add("trading.entry.slicing.min_notional", "不能为负数")
+	}
+	if slicing.Slices < 0 || slicing.Slices == 1 {
+		v.add("trading.entry.slicing.slices", "必须大于1，当前值 %d", slicing.Slices)
+	}
+	if slicing.Duration < 0 {
+		v.add("trading.entry.slicing.duration", "不能为负数")
+	}
+	v.percent("trading.entry.slicing.size_jitter", slicing.SizeJitter)
+	v.percent("trading.entry.slicing.time_jitter", slicing.TimeJitter)
+	if slicing.MaxPriceMoveBps < 0 {
+		v.add("trading.entry.slicing.max_price_move_bps", "不能为负数")
+	}
+
+	liquidity := e.Liquidity
+	if liquidity.DepthBps < 0 {
+		v.add("trading.entry.liquidity.depth_bps", "不能为负数")
+	}
+	if liquidity.MinDepthMultiple < 0 {
+		v.add("trading.entry.liquidity.min_depth_multiple", "不能为负数")
+	}
+	if liquidity.MaxSpreadBps < 0 {
+		v.add("trading.entry.liquidity.max_spread_bps", "不能为负数")
+	}
+	v.oneOf("trading.entry.liquidity.action", liquidity.Action, LiquidityActionSkip, LiquidityActionDownsize)
+}
+
+// takeProfitLadder 校验分档止盈：止盈百分比递增，比例之和为100
+func (v *validator) takeProfitLadder(field string, ladder []TakeProfitLevel) {
+	if len(ladder) == 0 {
+		return
+	}
+	total := 0.0
+	for i, level := range ladder {
+		levelField := fmt.Sprintf("%s[%d]", field, i)
+		if level.Percent <= 0 || level.Percent >= 100 {
+			v.add(levelField+".percent", "必须在 0~100 之间，当前值 %g", level.Percent)
+		} else if i > 0 && level.Percent <= ladder[i-1].Percent {
+			v.add(levelField+".percent", "必须大于上一档")
+		}
+		if level.Size <= 0 {
+			v.add(levelField+".size", "必须大于0")
+		}
+		total += float64(level.Size)
+	}
+	if math.Abs(total-100) > 1e-6 {
+		v.add(field, "各档 size 之和必须为100%%，当前值 %g%%", total)
+	}
+}
+
+// partialExits 校验分批平仓检查点：时间递增、早于最长持仓时间，百分比之和小于100
+func (v *validator) partialExits(field string, exits []PartialExit, maxHolding time.Duration) {
+	total := 0.0
+	for i, exit := range exits {
+		exitField := fmt.Sprintf("%s[%d]", field, i)
+		if exit.After <= 0 {
+			v.add(exitField+".after", "必须大于0")
+		} else if i > 0 && exit.After <= exits[i-1].After {
+			v.add(exitField+".after", "必须晚于上一个检查点")
+		} else if maxHolding > 0 && exit.After >= maxHolding {
+			v.add(exitField+".after", "必须早于 max_holding_duration")
+		}
+		if exit.Percent <= 0 {
+			v.add(exitField+".percent", "必须大于0")
+		}
+		total += exit.Percent
+	}
+	if total >= 100 {
+		v.add(field, "百分比之和必须小于100（全部平仓请使用 max_holding_duration），当前值 %g", total)
+	}
+}
+
+// validate 校验认证配置
+func (a *AuthConfig) validate(v *validator) {
+	if a.Enabled && len(a.Clients) == 0 {
+		v.add("server.auth.clients", "启用认证时至少需要一个调用方")
+	}
+
+	names := make(map[string]bool)
+	for i, client := range a.Clients {
+		field := fmt.Sprintf("server.auth.clients[%d]", i)
+		if client.Name == "" {
+			v.add(field+".name", "不能为空")
+		} else if names[client.Name] {
+			v.add(field+".name", "调用方名称 %q 重复", client.Name)
+		}
+		names[client.Name] = true
+		if client.Role == "" {
+			v.add(field+".role", "不能为空，可选值: %s/%s", RoleRead, RoleTrader)
+		}
+		v.oneOf(field+".role", client.Role, RoleRead, RoleTrader)
+		if client.Token == "" && client.Secret == "" {
+			v.add(field, "token 和 secret 至少设置一个")
+		}
+	}
+
+	for i, entry := range a.IPAllowlist {
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			v.add(fmt.Sprintf("server.auth.ip_allowlist[%d]", i), "无效的IP或网段 %q", entry)
+		}
+	}
+	for i, entry := range a.TrustedProxies {
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			v.add(fmt.Sprintf("server.auth.trusted_proxies[%d]", i), "无效的IP或网段 %q", entry)
+		}
+	}
+}
+
+// validate 校验通知渠道和路由规则
+func (n *NotifyConfig) validate(v *validator) {
+	names := make(map[string]bool)
+	for i, channel := range n.Channels {
+		field := fmt.Sprintf("notify.channels[%d]", i)
+		if channel.Name == "" {
+			v.add(field+".name", "不能为空")
+		} else if names[channel.Name] {
+			v.add(field+".name", "渠道名称 %q 重复", channel.Name)
+		}
+		names[channel.Name] = true
+
+		switch channel.Type {
+		case NotifyTelegram:
+			if channel.BotToken == "" || channel.ChatID == "" {
+				v.add(field, "telegram渠道必须设置 bot_token 和 chat_id")
+			}
+		case NotifySlack, NotifyDingTalk, NotifyFeishu, NotifyWebhook:
+			if channel.URL == "" {
+				v.add(field+".url", "%s渠道不能为空", channel.Type)
+			}
+		case "":
+			v.add(field+".type", "不能为空，可选值: %s", strings.Join(notifyTypes, "/"))
+		default:
+			v.oneOf(field+".type", channel.Type, notifyTypes...)
+		}
+		if channel.RateLimit < 0 {
+			v.add(field+".rate_limit", "不能为负数")
+		}
+		if channel.RequestTimeout < 0 {
+			v.add(field+".request_timeout", "不能为负数")
+		}
+	}
+
+	for i, rule := range n.Rules {
+		field := fmt.Sprintf("notify.rules[%d].channels", i)
+		if len(rule.Channels) == 0 {
+			v.add(field, "不能为空")
+		}
+		for _, name := range rule.Channels {
+			if !names[name] {
+				v.add(field, "渠道 %q 不存在", name)
+			}
+		}
+	}
+}
+
+// notifyTypes 支持的通知渠道类型
+var notifyTypes = []string{NotifyTelegram, NotifySlack, NotifyDingTalk, NotifyFeishu, NotifyWebhook}
@@ -0,0 +1,196 @@
+// Package events 进程内事件总线，监控服务、交易服务和止盈止损监控发布事件，供SSE/WebSocket推送
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/logger"
+)
+
+// 事件类型
+const (
+	TypeListingDiscovered   = "listing_discovered"    // 发现新币对
+	TypeOrderPlaced         = "order_placed"          // 下单成功
+	TypeOrderFilled         = "order_filled"          // 订单成交
+	TypeStopLossTriggered   = "stop_loss_triggered"   // 止损触发
+	TypeTakeProfitTriggered = "take_profit_triggered" // 止盈触发
+	TypeBracketFailed       = "bracket_failed"        // 止损/止盈单创建失败（仓位缺少保护）
+	TypeBracketDrift        = "bracket_drift"         // 持仓缺少止损/止盈单或条件单数量与持仓不一致，data为检查结果
+	TypeStopMoved           = "stop_moved"            // 浮盈后止损单已移动（保本或按阶梯锁定利润）
+	TypePositionClosed      = "position_closed"       // 仓位已平（止盈止损触发或手动平仓），data包含盈亏
+	TypeRiskLimitBreached   = "risk_limit_breached"   // 触发风控限制（资金费成本超限、流动性不足）跳过开仓，data为检查结果
+	TypeError               = "error"                 // 错误
+)
+
+// Types 所有事件类型
+var Types = []string{
+	TypeListingDiscovered,
+	TypeOrderPlaced,
+	TypeOrderFilled,
+	TypeStopLossTriggered,
+	TypeTakeProfitTriggered,
+	TypeBracketFailed,
+	TypeBracketDrift,
+	TypeStopMoved,
+	TypePositionClosed,
+	TypeRiskLimitBreached,
+	TypeError,
+}
+
+// DefaultBufferSize 默认保留的历史事件数量（用于断线续传）
+const DefaultBufferSize = 1000
+
+// subscriberBuffer 每个订阅者的缓冲区大小，缓冲区满时丢弃事件
+const subscriberBuffer = 256
+
+// Event 事件
+type Event struct {
+	ID       uint64      `json:"id"`
+	Type     string      `json:"type"`
+	Time     time.Time   `json:"time"`
+	Symbol   string      `json:"symbol,omitempty"`
+	Exchange string      `json:"exchange,omitempty"`
+	Account  string      `json:"account,omitempty"`
+	Message  string      `json:"message,omitempty"`
+	Data     interface{} `json:"data,omitempty"` // 事件详情（新币对、订单等）
+}
+
+// Filter 事件类型过滤，为空时接收所有类型
+type Filter map[string]bool
+
+// ParseFilter 解析逗号分隔的事件类型
+func ParseFilter(types string) Filter {
+	filter := make(Filter)
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter[t] = true
+		}
+	}
+	return filter
+}
+
+// Match 判断事件是否符合过滤条件
+func (f Filter) Match(e *Event) bool {
+	return len(f) == 0 || f[e.Type]
+}
+
+// Subscription 订阅
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter Filter
+	bus    *Bus
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s)
+}
+
+// Bus 事件总线，保留最近的事件用于断线续传
+type Bus struct {
+	mu     sync.Mutex
+	nextID uint64
+	ring   []Event // 环形缓冲区
+	start  int     // 最早事件在ring中的位置
+	count  int     // ring中的事件数量
+	subs   map[*Subscription]struct{}
+}
+
+// NewBus 创建事件总线，bufferSize为保留的历史事件数量
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Bus{
+		nextID: 1,
+		ring:   make([]Event, bufferSize),
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish 发布事件，自动分配ID和时间；b为nil时忽略（未启用事件总线）
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.ID = b.nextID
+	b.nextID++
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	if b.count < len(b.ring) {
+		b.ring[(b.start+b.count)%len(b.ring)] = e
+		b.count++
+	} else {
+		b.ring[b.start] = e
+		b.start = (b.start + 1) % len(b.ring)
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Match(&e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			logger.Warnf("事件订阅者处理过慢，丢弃事件 #%d（%s）", e.ID, e.Type)
+		}
+	}
+}
+
+// Subscribe 订阅事件，lastID大于0时先返回缓冲区中ID大于lastID的历史事件
+func (b *Bus) Subscribe(filter Filter, lastID uint64) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if lastID > 0 {
+		for i := 0; i < b.count; i++ {
+			e := b.ring[(b.start+i)%len(b.ring)]
+			if e.ID > lastID && filter.Match(&e) {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, bus: b}
+	b.subs[sub] = struct{}{}
+	return sub, replay
+}
+
+// Recent 返回最近的事件（最多limit条，按时间顺序）
+func (b *Bus) Recent(filter Filter, limit int) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make([]Event, 0)
+	for i := b.count - 1; i >= 0 && (limit <= 0 || len(events) < limit); i-- {
+		e := b.ring[(b.start+i)%len(b.ring)]
+		if filter.Match(&e) {
+			events = append(events, e)
+		}
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events
+}
+
+// unsubscribe 取消订阅并关闭channel
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,42 @@
+package events
+
+import "testing"
+
+func TestBusReplayAndFilter(t *testing.T) {
+	bus := NewBus(3)
+	for _, typ := range []string{TypeListingDiscovered, TypeOrderPlaced, TypeError, TypeOrderPlaced} {
+		bus.Publish(Event{Type: typ})
+	}
+
+	// 缓冲区只保留最近3条（ID 2~4）
+	_, replay := bus.Subscribe(nil, 1)
+	if len(replay) != 3 || replay[0].ID != 2 || replay[2].ID != 4 {
+		t.Fatalf("续传事件不正确: %+v", replay)
+	}
+
+	sub, replay := bus.Subscribe(ParseFilter("order_placed"), 2)
+	defer sub.Close()
+	if len(replay) != 1 || replay[0].ID != 4 {
+		t.Fatalf("按类型过滤的续传事件不正确: %+v", replay)
+	}
+
+	bus.Publish(Event{Type: TypeError})
+	bus.Publish(Event{Type: TypeOrderPlaced, Symbol: "ABCUSDT"})
+	e := <-sub.C
+	if e.ID != 6 || e.Symbol != "ABCUSDT" {
+		t.Errorf("订阅收到的事件不正确: %+v", e)
+	}
+	if len(sub.C) != 0 {
+		t.Errorf("不应收到被过滤的事件")
+	}
+
+	recent := bus.Recent(nil, 2)
+	if len(recent) != 2 || recent[0].ID != 5 || recent[1].ID != 6 {
+		t.Errorf("最近事件不正确: %+v", recent)
+	}
+}
+
+func TestNilBusPublish(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Type: TypeError}) // 未启用事件总线时不应panic
+}
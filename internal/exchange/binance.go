@@ -0,0 +1,395 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+
+	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// NewBinance 根据配置创建币安适配器（fapi或papi）
+func NewBinance(cfg config.BinanceConfig) Exchange {
+	apiType := cfg.APIType
+	if apiType == "" {
+		apiType = "fapi" // 默认使用fapi
+	}
+	client := binance.NewClientWithConfig(cfg.APIKey, cfg.SecretKey, apiType, cfg.BaseURL)
+	if apiType == "papi" {
+		return NewBinancePAPI(client)
+	}
+	return NewBinanceFAPI(client)
+}
+
+// binanceCommon fapi和papi共用的行情与查询接口（exchangeInfo和ticker始终走fapi）
+type binanceCommon struct {
+	client *binance.Client
+}
+
+// Name 交易所名称
+func (b *binanceCommon) Name() string {
+	return NameBinance
+}
+
+// Client 返回底层币安客户端
+func (b *binanceCommon) Client() *binance.Client {
+	return b.client
+}
+
+// GetExchangeInfo 获取交易所信息
+func (b *binanceCommon) GetExchangeInfo() (*models.ExchangeInfo, error) {
+	return b.client.GetExchangeInfo()
+}
+
+// GetTickerPrice 获取最新价格
+func (b *binanceCommon) GetTickerPrice(symbol string) (*models.TickerPrice, error) {
+	return b.client.GetTickerPrice(symbol)
+}
+
+// GetBookTicker 获取买一/卖一价格
+func (b *binanceCommon) GetBookTicker(symbol string) (*models.BookTicker, error) {
+	return b.client.GetBookTicker(symbol)
+}
+
+// GetDepth 获取盘口深度
+func (b *binanceCommon) GetDepth(symbol string, limit int) (*models.Depth, error) {
+	return b.client.GetDepth(symbol, limit)
+}
+
+// GetPremiumIndex 获取资金费率和下次资金费结算时间
+func (b *binanceCommon) GetPremiumIndex(symbol string) (*models.PremiumIndex, error) {
+	return b.client.GetPremiumIndex(symbol)
+}
+
+// GetFundingRateHistory 查询历史资金费率
+func (b *binanceCommon) GetFundingRateHistory(params *models.FundingRateParams) ([]models.FundingRate, error) {
+	return b.client.GetFundingRateHistory(params)
+}
+
+// GetFundingInfo 查询调整过结算间隔等资金费率设置的交易对
+func (b *binanceCommon) GetFundingInfo() ([]models.FundingInfo, error) {
+	return b.client.GetFundingInfo()
+}
+
+// CreateLimitOrder 创建限价单，fapi和papi的普通订单接口都支持IOC和GTX
+func (b *binanceCommon) CreateLimitOrder(req *LimitOrderRequest) (*models.OrderResponse, error) {
+	logger.Infof("创建限价单（%s）: %s, 方向: %s, 价格: %s, 数量: %s, 有效方式: %s",
+		b.client.APIType(), req.Symbol, req.Side, req.Price, req.Quantity, req.TimeInForce)
+	return b.client.CreateOrder(&models.OrderRequest{
+		Symbol:       req.Symbol,
+		Side:         req.Side,
+		Type:         "LIMIT",
+		TimeInForce:  req.TimeInForce,
+		Quantity:     req.Quantity,
+		Price:        req.Price,
+		PositionSide: req.PositionSide,
+	})
+}
+
+// GetPositionRisk 查询持仓风险
+func (b *binanceCommon) GetPositionRisk(symbol string) ([]models.PositionRisk, error) {
+	return b.client.GetPositionRisk(symbol)
+}
+
+// QueryOrder 查询订单
+func (b *binanceCommon) QueryOrder(symbol string, orderID int64) (*models.OrderResponse, error) {
+	return b.client.QueryOrder(&models.OrderQueryParams{
+		Symbol:  symbol,
+		OrderID: orderID,
+	})
+}
+
+// 币安成交历史单次查询的时间跨度上限和分页大小
+const (
+	userTradesMaxWindow = 7 * 24 * time.Hour
+	historyPageLimit    = 1000
+)
+
+// GetUserTrades 查询成交历史，按7天拆分时间段并分页
+func (b *binanceCommon) GetUserTrades(symbol string, startTime, endTime int64) ([]models.UserTrade, error) {
+	if endTime <= 0 {
+		endTime = time.Now().UnixMilli()
+	}
+	if startTime <= 0 {
+		startTime = endTime - userTradesMaxWindow.Milliseconds()
+	}
+
+	var trades []models.UserTrade
+	for windowStart := startTime; windowStart <= endTime; {
+		windowEnd := windowStart + userTradesMaxWindow.Milliseconds() - 1
+		if windowEnd > endTime {
+			windowEnd = endTime
+		}
+		page, err := b.client.GetUserTrades(&models.UserTradesParams{
+			Symbol:    symbol,
+			StartTime: windowStart,
+			EndTime:   windowEnd,
+			Limit:     historyPageLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, page...)
+
+		// 未取完时从最后一笔成交之后继续
+		if len(page) == historyPageLimit {
+			windowStart = page[len(page)-1].Time + 1
+			continue
+		}
+		windowStart = windowEnd + 1
+	}
+	return trades, nil
+}
+
+// GetIncomeHistory 查询资金流水并分页
+func (b *binanceCommon) GetIncomeHistory(symbol, incomeType string, startTime, endTime int64) ([]models.Income, error) {
+	var incomes []models.Income
+	for {
+		page, err := b.client.GetIncomeHistory(&models.IncomeParams{
+			Symbol:     symbol,
+			IncomeType: incomeType,
+			StartTime:  startTime,
+			EndTime:    endTime,
+			Limit:      historyPageLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		incomes = append(incomes, page...)
+		if len(page) < historyPageLimit {
+			return incomes, nil
+		}
+		startTime = page[len(page)-1].Time + 1
+	}
+}
+
+// BinanceFAPI 币安U本位合约（fapi）适配器
+type BinanceFAPI struct {
+	binanceCommon
+}
+
+// NewBinanceFAPI 创建fapi适配器
+func NewBinanceFAPI(client *binance.Client) *BinanceFAPI {
+	return &BinanceFAPI{binanceCommon{client: client}}
+}
+
+// CreateMarketOrder 创建市价单，fapi可以直接按USDT金额下单
+func (b *BinanceFAPI) CreateMarketOrder(req *MarketOrderRequest) (*models.OrderResponse, error) {
+	orderReq := &models.OrderRequest{
+		Symbol:       req.Symbol,
+		Side:         req.Side,
+		Type:         "MARKET",
+		PositionSide: req.PositionSide,
+		Quantity:     req.Quantity,
+	}
+	if req.Quantity == "" {
+		orderReq.Notional = req.Notional
+	}
+	if req.ReduceOnly {
+		orderReq.ReduceOnly = "true"
+	}
+
+	logger.Infof("创建市价单（fapi）: %s, 方向: %s, 数量: %s, USDT金额: %s", req.Symbol, req.Side, orderReq.Quantity, orderReq.Notional)
+	return b.client.CreateOrder(orderReq)
+}
+
+// CreateStopOrder 创建止损单（STOP_MARKET）
+func (b *BinanceFAPI) CreateStopOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return b.createTriggerOrder("STOP_MARKET", req)
+}
+
+// CreateTakeProfitOrder 创建止盈单（TAKE_PROFIT_MARKET）
+func (b *BinanceFAPI) CreateTakeProfitOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return b.createTriggerOrder("TAKE_PROFIT_MARKET", req)
+}
+
+// createTriggerOrder fapi条件单使用普通订单接口，优先使用closePosition平掉整个持仓
+func (b *BinanceFAPI) createTriggerOrder(orderType string, req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	orderReq, err := triggerOrderRequest(orderType, req)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("创建条件单（fapi）: %s, 类型: %s, 方向: %s, 触发价格: %s", req.Symbol, orderType, req.Side, req.StopPrice)
+	return b.client.CreateOrder(orderReq)
+}
+
+// triggerOrderRequest 构建fapi条件单请求
+func triggerOrderRequest(orderType string, req *TriggerOrderRequest) (*models.OrderRequest, error) {
+	orderReq := &models.OrderRequest{
+		Symbol:       req.Symbol,
+		Side:         req.Side,
+		Type:         orderType,
+		StopPrice:    req.StopPrice,
+		PositionSide: req.PositionSide,
+		WorkingType:  req.WorkingType,
+		PriceProtect: "true",
+	}
+	if req.ClosePosition {
+		orderReq.ClosePosition = "true" // 平仓，自动平掉整个持仓（不需要quantity）
+	} else {
+		if req.Quantity == "" {
+			return nil, fmt.Errorf("未设置closePosition时需要quantity参数")
+		}
+		orderReq.Quantity = req.Quantity
+		if reduceOnlyAllowed(req.PositionSide) {
+			orderReq.ReduceOnly = "true"
+		}
+	}
+	return orderReq, nil
+}
+
+// reduceOnlyAllowed 单向持仓模式才能携带reduceOnly，双向持仓模式下按positionSide平仓
+func reduceOnlyAllowed(positionSide string) bool {
+	return positionSide == "" || positionSide == "BOTH"
+}
+
+// GetOpenOrders 查询当前挂单（fapi条件单也在普通挂单中）
+func (b *BinanceFAPI) GetOpenOrders(symbol string) ([]models.OrderResponse, error) {
+	return b.client.GetOpenOrders(symbol)
+}
+
+// CancelOrder 撤销订单
+func (b *BinanceFAPI) CancelOrder(symbol string, order *models.OrderResponse) error {
+	_, err := b.client.CancelOrder(symbol, order.OrderID)
+	return err
+}
+
+// BinancePAPI 币安统一账户（papi）适配器
+type BinancePAPI struct {
+	binanceCommon
+}
+
+// NewBinancePAPI 创建papi适配器
+func NewBinancePAPI(client *binance.Client) *BinancePAPI {
+	return &BinancePAPI{binanceCommon{client: client}}
+}
+
+// CreateMarketOrder 创建市价单，统一账户接口不支持notional参数，需要换算为quantity
+func (b *BinancePAPI) CreateMarketOrder(req *MarketOrderRequest) (*models.OrderResponse, error) {
+	orderReq := &models.OrderRequest{
+		Symbol:       req.Symbol,
+		Side:         req.Side,
+		Type:         "MARKET",
+		PositionSide: req.PositionSide,
+		Quantity:     req.Quantity,
+	}
+	if req.ReduceOnly {
+		orderReq.ReduceOnly = "true"
+	}
+
+	if orderReq.Quantity == "" {
+		quantity, price, err := quantityFromNotional(b, req.Symbol, req.Notional)
+		if err != nil {
+			return nil, err
+		}
+		orderReq.Quantity = quantity
+		logger.Infof("创建市价单（统一账户）: %s, 方向: %s, USDT金额: %s, 当前价格: %s, 计算数量: %s",
+			req.Symbol, req.Side, req.Notional, price, quantity)
+	} else {
+		logger.Infof("创建市价单（统一账户）: %s, 方向: %s, 数量: %s", req.Symbol, req.Side, orderReq.Quantity)
+	}
+
+	return b.client.CreateOrder(orderReq)
+}
+
+// CreateStopOrder 创建止损条件单（STOP_MARKET）
+func (b *BinancePAPI) CreateStopOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return b.createConditionalOrder("STOP_MARKET", req)
+}
+
+// CreateTakeProfitOrder 创建止盈条件单（TAKE_PROFIT_MARKET）
+func (b *BinancePAPI) CreateTakeProfitOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return b.createConditionalOrder("TAKE_PROFIT_MARKET", req)
+}
+
+// createConditionalOrder 统一账户使用条件单接口，必须提供quantity
+func (b *BinancePAPI) createConditionalOrder(strategyType string, req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	condReq, err := conditionalOrderRequest(strategyType, req)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("创建条件单（统一账户）: %s, 策略类型: %s, 方向: %s, 触发价格: %s, 数量: %s",
+		req.Symbol, strategyType, req.Side, req.StopPrice, req.Quantity)
+
+	condResp, err := b.client.CreateConditionalOrder(condReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换为OrderResponse以保持兼容性
+	return ConvertConditionalOrder(condResp), nil
+}
+
+// conditionalOrderRequest 构建统一账户条件单请求
+func conditionalOrderRequest(strategyType string, req *TriggerOrderRequest) (*models.ConditionalOrderRequest, error) {
+	if req.Quantity == "" {
+		return nil, fmt.Errorf("统一账户条件单需要quantity参数，请提供平仓数量")
+	}
+
+	condReq := &models.ConditionalOrderRequest{
+		Symbol:       req.Symbol,
+		Side:         req.Side,
+		StrategyType: strategyType,
+		StopPrice:    req.StopPrice,
+		Quantity:     req.Quantity,
+		PositionSide: req.PositionSide,
+		WorkingType:  req.WorkingType,
+		PriceProtect: "TRUE",
+	}
+	if reduceOnlyAllowed(req.PositionSide) {
+		condReq.ReduceOnly = "true" // 只减仓，确保平仓
+	}
+	return condReq, nil
+}
+
+// GetOpenOrders 查询当前挂单，合并普通挂单和条件单
+func (b *BinancePAPI) GetOpenOrders(symbol string) ([]models.OrderResponse, error) {
+	orders, err := b.client.GetOpenOrders(symbol)
+	if err != nil {
+		return nil, err
+	}
+	conditional, err := b.client.GetConditionalOpenOrders(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("查询条件单失败: %w", err)
+	}
+	for i := range conditional {
+		orders = append(orders, *ConvertConditionalOrder(&conditional[i]))
+	}
+	return orders, nil
+}
+
+// CancelOrder 撤销订单，条件单走条件单撤单接口
+func (b *BinancePAPI) CancelOrder(symbol string, order *models.OrderResponse) error {
+	if IsStopOrderType(order.Type) {
+		_, err := b.client.CancelConditionalOrder(symbol, order.OrderID)
+		return err
+	}
+	_, err := b.client.CancelOrder(symbol, order.OrderID)
+	return err
+}
+
+// ConvertConditionalOrder 将条件单响应转换为订单响应（用于兼容性）
+func ConvertConditionalOrder(condResp *models.ConditionalOrderResponse) *models.OrderResponse {
+	return &models.OrderResponse{
+		OrderID:       condResp.StrategyID,
+		Symbol:        condResp.Symbol,
+		Status:        condResp.StrategyStatus,
+		ClientOrderID: condResp.NewClientStrategyId,
+		Price:         condResp.Price,
+		OrigQty:       condResp.OrigQty,
+		TimeInForce:   condResp.TimeInForce,
+		Type:          condResp.StrategyType,
+		ReduceOnly:    condResp.ReduceOnly,
+		Side:          condResp.Side,
+		PositionSide:  condResp.PositionSide,
+		StopPrice:     condResp.StopPrice,
+		WorkingType:   condResp.WorkingType,
+		PriceProtect:  condResp.PriceProtect,
+		UpdateTime:    condResp.UpdateTime,
+		Time:          condResp.BookTime,
+	}
+}
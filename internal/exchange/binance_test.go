@@ -0,0 +1,41 @@
+package exchange
+
+import "testing"
+
+func TestBinanceTriggerOrderReduceOnly(t *testing.T) {
+	tests := []struct {
+		positionSide string
+		reduceOnly   string
+	}{
+		{"", "true"},
+		{"BOTH", "true"},
+		// 双向持仓模式下携带reduceOnly会被拒绝
+		{"SHORT", ""},
+		{"LONG", ""},
+	}
+	for _, tt := range tests {
+		req := &TriggerOrderRequest{Symbol: "ABCUSDT", Side: "BUY", StopPrice: "10.6", Quantity: "4.0", PositionSide: tt.positionSide}
+
+		orderReq, err := triggerOrderRequest("TAKE_PROFIT_MARKET", req)
+		if err != nil {
+			t.Fatalf("构建fapi条件单失败: %v", err)
+		}
+		if orderReq.ReduceOnly != tt.reduceOnly || orderReq.PositionSide != tt.positionSide {
+			t.Errorf("fapi positionSide=%q 时reduceOnly应为%q: %+v", tt.positionSide, tt.reduceOnly, orderReq)
+		}
+
+		condReq, err := conditionalOrderRequest("TAKE_PROFIT_MARKET", req)
+		if err != nil {
+			t.Fatalf("构建统一账户条件单失败: %v", err)
+		}
+		if condReq.ReduceOnly != tt.reduceOnly {
+			t.Errorf("papi positionSide=%q 时reduceOnly应为%q: %+v", tt.positionSide, tt.reduceOnly, condReq)
+		}
+	}
+
+	// closePosition 不携带数量和reduceOnly
+	orderReq, _ := triggerOrderRequest("STOP_MARKET", &TriggerOrderRequest{Symbol: "ABCUSDT", Side: "BUY", StopPrice: "10.2", ClosePosition: true})
+	if orderReq.ClosePosition != "true" || orderReq.ReduceOnly != "" || orderReq.Quantity != "" {
+		t.Errorf("closePosition条件单不应携带reduceOnly: %+v", orderReq)
+	}
+}
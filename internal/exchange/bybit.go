@@ -0,0 +1,277 @@
+package exchange
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"new_listing_trade/internal/api/bybit"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// Bybit Bybit USDT永续合约（V5 linear）适配器
+type Bybit struct {
+	client *bybit.Client
+}
+
+// NewBybit 根据配置创建Bybit适配器
+func NewBybit(cfg config.BybitConfig) *Bybit {
+	return NewBybitWithClient(bybit.NewClient(cfg.APIKey, cfg.SecretKey, cfg.BaseURL))
+}
+
+// NewBybitWithClient 使用已有客户端创建Bybit适配器
+func NewBybitWithClient(client *bybit.Client) *Bybit {
+	return &Bybit{client: client}
+}
+
+// Name 交易所名称
+func (b *Bybit) Name() string {
+	return NameBybit
+}
+
+// GetExchangeInfo 获取USDT永续合约列表，转换为币安格式
+func (b *Bybit) GetExchangeInfo() (*models.ExchangeInfo, error) {
+	instruments, err := b.client.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeInfo := &models.ExchangeInfo{}
+	for _, inst := range instruments {
+		if inst.QuoteCoin != "USDT" || inst.ContractType != "LinearPerpetual" {
+			continue
+		}
+		launchTime, _ := strconv.ParseInt(inst.LaunchTime, 10, 64)
+		exchangeInfo.Symbols = append(exchangeInfo.Symbols, models.Symbol{
+			Symbol:      inst.Symbol,
+			OnboardDate: launchTime,
+			Status:      bybitStatus(inst.Status),
+			Filters: []models.Filter{
+				{
+					FilterType: models.FilterTypeLotSize,
+					MinQty:     inst.LotSizeFilter.MinOrderQty,
+					MaxQty:     inst.LotSizeFilter.MaxOrderQty,
+					StepSize:   inst.LotSizeFilter.QtyStep,
+				},
+				{
+					FilterType: models.FilterTypePrice,
+					MinPrice:   inst.PriceFilter.MinPrice,
+					MaxPrice:   inst.PriceFilter.MaxPrice,
+					TickSize:   inst.PriceFilter.TickSize,
+				},
+			},
+		})
+	}
+	return exchangeInfo, nil
+}
+
+// GetTickerPrice 获取最新价格
+func (b *Bybit) GetTickerPrice(symbol string) (*models.TickerPrice, error) {
+	ticker, err := b.client.GetTicker(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TickerPrice{Symbol: ticker.Symbol, Price: ticker.LastPrice}, nil
+}
+
+// CreateMarketOrder 创建市价单，Bybit按数量下单，USDT金额按当前价格换算
+func (b *Bybit) CreateMarketOrder(req *MarketOrderRequest) (*models.OrderResponse, error) {
+	quantity := req.Quantity
+	if quantity == "" {
+		var price string
+		var err error
+		quantity, price, err = quantityFromNotional(b, req.Symbol, req.Notional)
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof("创建市价单（Bybit）: %s, 方向: %s, USDT金额: %s, 当前价格: %s, 计算数量: %s",
+			req.Symbol, req.Side, req.Notional, price, quantity)
+	}
+
+	orderReq := &bybit.OrderRequest{
+		Symbol:      req.Symbol,
+		Side:        bybitSide(req.Side),
+		OrderType:   "Market",
+		Qty:         quantity,
+		PositionIdx: bybitPositionIdx(req.PositionSide),
+		ReduceOnly:  req.ReduceOnly,
+		OrderLinkID: newClientOrderID(),
+	}
+
+	result, err := b.client.CreateOrder(orderReq)
+	if err != nil {
+		return nil, err
+	}
+	return b.orderResponse(req.Symbol, req.Side, "MARKET", req.PositionSide, quantity, "", result), nil
+}
+
+// CreateStopOrder 创建止损条件单
+func (b *Bybit) CreateStopOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return b.createTriggerOrder("STOP_MARKET", req)
+}
+
+// CreateTakeProfitOrder 创建止盈条件单
+func (b *Bybit) CreateTakeProfitOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return b.createTriggerOrder("TAKE_PROFIT_MARKET", req)
+}
+
+// createTriggerOrder Bybit条件单是带triggerPrice的市价单，需要指定触发方向
+func (b *Bybit) createTriggerOrder(orderType string, req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	if req.Quantity == "" {
+		return nil, fmt.Errorf("Bybit条件单需要quantity参数，请提供平仓数量")
+	}
+
+	// 买入止损/卖出止盈在价格上涨时触发，买入止盈/卖出止损在价格下跌时触发
+	rising := (orderType == "STOP_MARKET") == (req.Side == "BUY")
+	direction := 2
+	if rising {
+		direction = 1
+	}
+
+	triggerBy := "LastPrice"
+	if req.WorkingType == "MARK_PRICE" {
+		triggerBy = "MarkPrice"
+	}
+
+	orderReq := &bybit.OrderRequest{
+		Symbol:           req.Symbol,
+		Side:             bybitSide(req.Side),
+		OrderType:        "Market",
+		Qty:              req.Quantity,
+		TriggerPrice:     req.StopPrice,
+		TriggerDirection: direction,
+		TriggerBy:        triggerBy,
+		PositionIdx:      bybitPositionIdx(req.PositionSide),
+		ReduceOnly:       true,
+		CloseOnTrigger:   req.ClosePosition,
+		OrderLinkID:      newClientOrderID(),
+	}
+
+	logger.Infof("创建条件单（Bybit）: %s, 类型: %s, 方向: %s, 触发价格: %s, 数量: %s",
+		req.Symbol, orderType, req.Side, req.StopPrice, req.Quantity)
+
+	result, err := b.client.CreateOrder(orderReq)
+	if err != nil {
+		return nil, err
+	}
+	return b.orderResponse(req.Symbol, req.Side, orderType, req.PositionSide, req.Quantity, req.StopPrice, result), nil
+}
+
+// GetPositionRisk 查询持仓，转换为币安格式（空仓数量和名义价值为负数）
+func (b *Bybit) GetPositionRisk(symbol string) ([]models.PositionRisk, error) {
+	positions, err := b.client.GetPositions(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.PositionRisk, 0, len(positions))
+	for _, p := range positions {
+		positionAmt, notional := p.Size, p.PositionValue
+		if p.Side == "Sell" {
+			positionAmt, notional = negate(positionAmt), negate(notional)
+		}
+		marginType := "cross"
+		if p.TradeMode == 1 {
+			marginType = "isolated"
+		}
+		updateTime, _ := strconv.ParseInt(p.UpdatedTime, 10, 64)
+
+		result = append(result, models.PositionRisk{
+			Symbol:           p.Symbol,
+			PositionAmt:      positionAmt,
+			EntryPrice:       p.AvgPrice,
+			MarkPrice:        p.MarkPrice,
+			UnRealizedProfit: p.UnrealisedPnl,
+			LiquidationPrice: p.LiqPrice,
+			Leverage:         p.Leverage,
+			MarginType:       marginType,
+			PositionSide:     bybitPositionSide(p.PositionIdx),
+			Notional:         notional,
+			UpdateTime:       updateTime,
+		})
+	}
+	return result, nil
+}
+
+// CancelOrder 撤销订单（Bybit的条件单与普通订单使用同一撤单接口）
+func (b *Bybit) CancelOrder(symbol string, order *models.OrderResponse) error {
+	_, err := b.client.CancelOrder(symbol, "", order.ClientOrderID)
+	return err
+}
+
+// orderResponse 构造币安格式的订单响应，Bybit订单ID为字符串，使用ClientOrderID（orderLinkId）标识订单
+func (b *Bybit) orderResponse(symbol, side, orderType, positionSide, quantity, stopPrice string, result *bybit.OrderResult) *models.OrderResponse {
+	now := time.Now().UnixMilli()
+	return &models.OrderResponse{
+		Symbol:        symbol,
+		Status:        "NEW",
+		ClientOrderID: result.OrderLinkID,
+		OrigQty:       quantity,
+		Type:          orderType,
+		Side:          side,
+		PositionSide:  positionSide,
+		StopPrice:     stopPrice,
+		Time:          now,
+		UpdateTime:    now,
+	}
+}
+
+// bybitSide BUY/SELL -> Buy/Sell
+func bybitSide(side string) string {
+	if strings.ToUpper(side) == "BUY" {
+		return "Buy"
+	}
+	return "Sell"
+}
+
+// bybitPositionIdx 持仓方向 -> positionIdx
+func bybitPositionIdx(positionSide string) int {
+	switch positionSide {
+	case "LONG":
+		return 1
+	case "SHORT":
+		return 2
+	}
+	return 0
+}
+
+// bybitPositionSide positionIdx -> 持仓方向
+func bybitPositionSide(positionIdx int) string {
+	switch positionIdx {
+	case 1:
+		return "LONG"
+	case 2:
+		return "SHORT"
+	}
+	return "BOTH"
+}
+
+// bybitStatus 合约状态转换为币安格式
+func bybitStatus(status string) string {
+	switch status {
+	case "Trading":
+		return "TRADING"
+	case "PreLaunch":
+		return "PENDING_TRADING"
+	}
+	return strings.ToUpper(status)
+}
+
+// negate 对数字字符串取反
+func negate(value string) string {
+	if value == "" || value == "0" {
+		return value
+	}
+	if strings.HasPrefix(value, "-") {
+		return value[1:]
+	}
+	return "-" + value
+}
+
+// newClientOrderID 生成自定义订单号
+func newClientOrderID() string {
+	return fmt.Sprintf("nlt%d", time.Now().UnixNano())
+}
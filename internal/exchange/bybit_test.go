@@ -0,0 +1,157 @@
+package exchange
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"new_listing_trade/internal/api/bybit"
+)
+
+// fixtureServer 按请求路径返回testdata中的录制响应，并记录最近一次请求体
+type fixtureServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies map[string][]byte
+}
+
+func newFixtureServer(t *testing.T, fixtures map[string]string) *fixtureServer {
+	t.Helper()
+	fs := &fixtureServer{bodies: make(map[string][]byte)}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fs.mu.Lock()
+		fs.bodies[r.URL.Path] = body
+		fs.mu.Unlock()
+
+		data, err := os.ReadFile("testdata/" + file)
+		if err != nil {
+			t.Errorf("读取fixture失败: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+// lastBody 解析指定路径最近一次的JSON请求体
+func (fs *fixtureServer) lastBody(t *testing.T, path string, out interface{}) {
+	t.Helper()
+	fs.mu.Lock()
+	body := fs.bodies[path]
+	fs.mu.Unlock()
+	if err := json.Unmarshal(body, out); err != nil {
+		t.Fatalf("解析请求体失败: %v (%s)", err, body)
+	}
+}
+
+func newTestBybit(t *testing.T) (*Bybit, *fixtureServer) {
+	srv := newFixtureServer(t, map[string]string{
+		bybit.InstrumentsInfoEndpoint: "bybit_instruments.json",
+		bybit.TickersEndpoint:         "bybit_ticker.json",
+		bybit.OrderCreateEndpoint:     "bybit_order.json",
+		bybit.PositionListEndpoint:    "bybit_positions.json",
+	})
+	return NewBybitWithClient(bybit.NewClient("k", "s", srv.URL)), srv
+}
+
+func TestBybitExchangeInfo(t *testing.T) {
+	ex, _ := newTestBybit(t)
+
+	info, err := ex.GetExchangeInfo()
+	if err != nil {
+		t.Fatalf("获取合约列表失败: %v", err)
+	}
+	if len(info.Symbols) != 2 {
+		t.Fatalf("应只保留USDT永续合约，实际: %+v", info.Symbols)
+	}
+	if s := info.Symbols[1]; s.Symbol != "NEWUSDT" || s.Status != "PENDING_TRADING" || s.OnboardDate != 1893456000000 {
+		t.Errorf("预上线合约转换不正确: %+v", s)
+	}
+	if f := info.Symbols[0].Filters[0]; f.FilterType != "LOT_SIZE" || f.StepSize != "0.001" {
+		t.Errorf("LOT_SIZE转换不正确: %+v", f)
+	}
+}
+
+func TestBybitMarketOrderFromNotional(t *testing.T) {
+	ex, srv := newTestBybit(t)
+
+	order, err := ex.CreateMarketOrder(&MarketOrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "SELL",
+		PositionSide: "BOTH",
+		Notional:     "120",
+	})
+	if err != nil {
+		t.Fatalf("创建市价单失败: %v", err)
+	}
+
+	var req bybit.OrderRequest
+	srv.lastBody(t, bybit.OrderCreateEndpoint, &req)
+	if req.Side != "Sell" || req.OrderType != "Market" || req.Qty != "0.002" || req.PositionIdx != 0 {
+		t.Errorf("下单参数不正确: %+v", req)
+	}
+	if order.ClientOrderID != "nlt1760000000000000000" || order.OrigQty != "0.002" || order.Type != "MARKET" {
+		t.Errorf("订单响应不正确: %+v", order)
+	}
+}
+
+func TestBybitStopOrder(t *testing.T) {
+	ex, srv := newTestBybit(t)
+
+	// 空单止损：买入方向，价格上涨触发
+	order, err := ex.CreateStopOrder(&TriggerOrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          "BUY",
+		PositionSide:  "BOTH",
+		StopPrice:     "66000",
+		Quantity:      "0.002",
+		ClosePosition: true,
+		WorkingType:   "MARK_PRICE",
+	})
+	if err != nil {
+		t.Fatalf("创建止损单失败: %v", err)
+	}
+
+	var req bybit.OrderRequest
+	srv.lastBody(t, bybit.OrderCreateEndpoint, &req)
+	if req.TriggerDirection != 1 || req.TriggerBy != "MarkPrice" || !req.ReduceOnly || !req.CloseOnTrigger {
+		t.Errorf("条件单参数不正确: %+v", req)
+	}
+	if !IsStopOrderType(order.Type) || order.StopPrice != "66000" {
+		t.Errorf("订单响应不正确: %+v", order)
+	}
+
+	if _, err := ex.CreateStopOrder(&TriggerOrderRequest{Symbol: "BTCUSDT", Side: "BUY", StopPrice: "66000", ClosePosition: true}); err == nil {
+		t.Error("缺少数量时应返回错误")
+	}
+}
+
+func TestBybitPositionRisk(t *testing.T) {
+	ex, _ := newTestBybit(t)
+
+	positions, err := ex.GetPositionRisk("BTCUSDT")
+	if err != nil {
+		t.Fatalf("查询持仓失败: %v", err)
+	}
+	if len(positions) != 1 {
+		t.Fatalf("持仓数量不正确: %+v", positions)
+	}
+	p := positions[0]
+	if p.PositionAmt != "-0.002" || p.Notional != "-120" || p.PositionSide != "BOTH" || p.MarginType != "cross" {
+		t.Errorf("空仓转换不正确: %+v", p)
+	}
+}
@@ -0,0 +1,156 @@
+package exchange
+
+import (
+	"fmt"
+	"strconv"
+
+	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/models"
+)
+
+// 交易所名称
+const (
+	NameBinance = "binance"
+	NameBybit   = "bybit"
+	NameOKX     = "okx"
+)
+
+// Exchange 交易所适配器
+// 统一使用币安的数据模型（ExchangeInfo/OrderResponse/PositionRisk），各交易所在适配器内部完成转换
+type Exchange interface {
+	// Name 交易所名称（binance/bybit/okx）
+	Name() string
+	// GetExchangeInfo 获取合约列表及精度规则
+	GetExchangeInfo() (*models.ExchangeInfo, error)
+	// GetTickerPrice 获取最新价格
+	GetTickerPrice(symbol string) (*models.TickerPrice, error)
+	// CreateMarketOrder 创建市价单
+	CreateMarketOrder(req *MarketOrderRequest) (*models.OrderResponse, error)
+	// CreateStopOrder 创建止损条件单（STOP_MARKET）
+	CreateStopOrder(req *TriggerOrderRequest) (*models.OrderResponse, error)
+	// CreateTakeProfitOrder 创建止盈条件单（TAKE_PROFIT_MARKET）
+	CreateTakeProfitOrder(req *TriggerOrderRequest) (*models.OrderResponse, error)
+	// GetPositionRisk 查询持仓，symbol留空查询全部
+	GetPositionRisk(symbol string) ([]models.PositionRisk, error)
+	// CancelOrder 撤销订单（普通订单或条件单，由适配器根据order.Type判断）
+	CancelOrder(symbol string, order *models.OrderResponse) error
+}
+
+// OrderQuerier 支持按订单ID查询订单的交易所
+type OrderQuerier interface {
+	QueryOrder(symbol string, orderID int64) (*models.OrderResponse, error)
+}
+
+// OpenOrderLister 支持查询当前挂单（包括止盈止损条件单）的交易所
+type OpenOrderLister interface {
+	GetOpenOrders(symbol string) ([]models.OrderResponse, error)
+}
+
+// TradeHistoryProvider 支持查询成交历史和资金流水的交易所（用于交易日志计算已实现盈亏）
+type TradeHistoryProvider interface {
+	// GetUserTrades 查询[startTime, endTime]内的成交，endTime为0表示到当前时间
+	GetUserTrades(symbol string, startTime, endTime int64) ([]models.UserTrade, error)
+	// GetIncomeHistory 查询[startTime, endTime]内的资金流水，symbol/incomeType留空表示不限
+	GetIncomeHistory(symbol, incomeType string, startTime, endTime int64) ([]models.Income, error)
+}
+
+// BookTickerProvider 支持查询买一/卖一价格的交易所
+type BookTickerProvider interface {
+	GetBookTicker(symbol string) (*models.BookTicker, error)
+}
+
+// DepthProvider 支持查询盘口深度的交易所
+type DepthProvider interface {
+	GetDepth(symbol string, limit int) (*models.Depth, error)
+}
+
+// FundingRateProvider 支持查询资金费率的交易所
+type FundingRateProvider interface {
+	GetPremiumIndex(symbol string) (*models.PremiumIndex, error)
+	GetFundingRateHistory(params *models.FundingRateParams) ([]models.FundingRate, error)
+	GetFundingInfo() ([]models.FundingInfo, error)
+}
+
+// LimitOrderCreator 支持限价开仓单（IOC/GTX）的交易所
+type LimitOrderCreator interface {
+	CreateLimitOrder(req *LimitOrderRequest) (*models.OrderResponse, error)
+}
+
+// MarketOrderRequest 市价单请求
+type MarketOrderRequest struct {
+	Symbol       string // 交易对（统一使用币安格式，例如 BTCUSDT）
+	Side         string // 买卖方向 BUY/SELL
+	PositionSide string // 持仓方向 BOTH/LONG/SHORT
+	Quantity     string // 数量，与Notional二选一
+	Notional     string // USDT金额，不支持按金额下单的交易所会换算为数量
+	ReduceOnly   bool   // 只减仓
+}
+
+// LimitOrderRequest 限价单请求
+type LimitOrderRequest struct {
+	Symbol       string // 交易对
+	Side         string // 买卖方向 BUY/SELL
+	PositionSide string // 持仓方向 BOTH/LONG/SHORT
+	Quantity     string // 数量
+	Price        string // 限价
+	TimeInForce  string // 有效方式 GTC/IOC/FOK/GTX（GTX为只做maker）
+}
+
+// TriggerOrderRequest 止盈止损条件单请求
+type TriggerOrderRequest struct {
+	Symbol        string // 交易对
+	Side          string // 买卖方向 BUY/SELL
+	PositionSide  string // 持仓方向 BOTH/LONG/SHORT
+	StopPrice     string // 触发价格
+	Quantity      string // 平仓数量，不支持ClosePosition的交易所必需
+	ClosePosition bool   // 触发后平掉整个持仓（支持时优先使用）
+	WorkingType   string // 触发类型 MARK_PRICE/CONTRACT_PRICE
+}
+
+// quantityFromNotional 按当前价格将USDT金额换算为符合精度规则的数量
+func quantityFromNotional(ex Exchange, symbol, notional string) (string, string, error) {
+	exchangeInfo, err := ex.GetExchangeInfo()
+	if err != nil {
+		return "", "", fmt.Errorf("获取交易所信息失败: %w", err)
+	}
+
+	symbolInfo, err := binance.GetSymbolInfo(exchangeInfo, symbol)
+	if err != nil {
+		return "", "", fmt.Errorf("获取交易对信息失败: %w", err)
+	}
+
+	tickerPrice, err := ex.GetTickerPrice(symbol)
+	if err != nil {
+		return "", "", fmt.Errorf("获取当前价格失败，无法计算quantity: %w", err)
+	}
+
+	notionalFloat, err := strconv.ParseFloat(notional, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("无效的USDT金额: %s", notional)
+	}
+
+	priceFloat, err := strconv.ParseFloat(tickerPrice.Price, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("无效的价格: %s", tickerPrice.Price)
+	}
+
+	if priceFloat <= 0 {
+		return "", "", fmt.Errorf("价格无效: %f", priceFloat)
+	}
+
+	// quantity = notional / price，市价单按MARKET_LOT_SIZE调整
+	quantityStr, err := binance.ValidateAndAdjustOrderQuantity(notionalFloat/priceFloat, "MARKET", symbolInfo)
+	if err != nil {
+		return "", "", fmt.Errorf("调整quantity精度失败: %w", err)
+	}
+	quantity, _ := strconv.ParseFloat(quantityStr, 64)
+	if err := binance.CheckMinNotional(quantity, priceFloat, symbolInfo); err != nil {
+		return "", "", err
+	}
+	return quantityStr, tickerPrice.Price, nil
+}
+
+// IsStopOrderType 是否为止损/止盈条件单类型
+func IsStopOrderType(orderType string) bool {
+	return binance.ConditionalOrderTypes[orderType]
+}
@@ -0,0 +1,379 @@
+package exchange
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/api/okx"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// OKX OKX USDT永续合约（SWAP）适配器
+// OKX按张下单，适配器对外统一使用币的数量，内部按合约面值ctVal换算
+type OKX struct {
+	client *okx.Client
+
+	mu          sync.RWMutex
+	instruments map[string]okx.Instrument // 合约信息缓存，key为币安格式symbol
+}
+
+// NewOKX 根据配置创建OKX适配器
+func NewOKX(cfg config.OKXConfig) *OKX {
+	return NewOKXWithClient(okx.NewClient(cfg.APIKey, cfg.SecretKey, cfg.Passphrase, cfg.BaseURL))
+}
+
+// NewOKXWithClient 使用已有客户端创建OKX适配器
+func NewOKXWithClient(client *okx.Client) *OKX {
+	return &OKX{
+		client:      client,
+		instruments: make(map[string]okx.Instrument),
+	}
+}
+
+// Name 交易所名称
+func (o *OKX) Name() string {
+	return NameOKX
+}
+
+// GetExchangeInfo 获取USDT永续合约列表，数量精度换算为币的数量
+func (o *OKX) GetExchangeInfo() (*models.ExchangeInfo, error) {
+	instruments, err := o.client.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeInfo := &models.ExchangeInfo{}
+	cache := make(map[string]okx.Instrument)
+	for _, inst := range instruments {
+		if inst.SettleCcy != "USDT" || inst.CtType != "linear" {
+			continue
+		}
+		symbol := okxSymbol(inst.InstID)
+		cache[symbol] = inst
+
+		ctVal, _ := strconv.ParseFloat(inst.CtVal, 64)
+		lotSz, _ := strconv.ParseFloat(inst.LotSz, 64)
+		minSz, _ := strconv.ParseFloat(inst.MinSz, 64)
+		listTime, _ := strconv.ParseInt(inst.ListTime, 10, 64)
+
+		exchangeInfo.Symbols = append(exchangeInfo.Symbols, models.Symbol{
+			Symbol:      symbol,
+			OnboardDate: listTime,
+			Status:      okxStatus(inst.State),
+			Filters: []models.Filter{
+				{
+					FilterType: models.FilterTypeLotSize,
+					MinQty:     formatFloat(minSz * ctVal),
+					StepSize:   formatFloat(lotSz * ctVal),
+				},
+				{
+					FilterType: models.FilterTypePrice,
+					TickSize:   inst.TickSz,
+				},
+			},
+		})
+	}
+
+	o.mu.Lock()
+	o.instruments = cache
+	o.mu.Unlock()
+
+	return exchangeInfo, nil
+}
+
+// GetTickerPrice 获取最新价格
+func (o *OKX) GetTickerPrice(symbol string) (*models.TickerPrice, error) {
+	ticker, err := o.client.GetTicker(okxInstID(symbol))
+	if err != nil {
+		return nil, err
+	}
+	return &models.TickerPrice{Symbol: symbol, Price: ticker.Last}, nil
+}
+
+// CreateMarketOrder 创建市价单
+func (o *OKX) CreateMarketOrder(req *MarketOrderRequest) (*models.OrderResponse, error) {
+	quantity := req.Quantity
+	if quantity == "" {
+		var price string
+		var err error
+		quantity, price, err = quantityFromNotional(o, req.Symbol, req.Notional)
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof("创建市价单（OKX）: %s, 方向: %s, USDT金额: %s, 当前价格: %s, 计算数量: %s",
+			req.Symbol, req.Side, req.Notional, price, quantity)
+	}
+
+	contracts, err := o.toContracts(req.Symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	orderReq := &okx.OrderRequest{
+		InstID:     okxInstID(req.Symbol),
+		TdMode:     "cross",
+		Side:       strings.ToLower(req.Side),
+		PosSide:    okxPosSide(req.PositionSide),
+		OrdType:    "market",
+		Sz:         contracts,
+		ReduceOnly: req.ReduceOnly,
+		ClOrdID:    newClientOrderID(),
+	}
+
+	result, err := o.client.PlaceOrder(orderReq)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, _ := strconv.ParseInt(result.OrdID, 10, 64)
+	now := time.Now().UnixMilli()
+	return &models.OrderResponse{
+		OrderID:       orderID,
+		Symbol:        req.Symbol,
+		Status:        "NEW",
+		ClientOrderID: result.ClOrdID,
+		OrigQty:       quantity,
+		Type:          "MARKET",
+		Side:          req.Side,
+		PositionSide:  req.PositionSide,
+		Time:          now,
+		UpdateTime:    now,
+	}, nil
+}
+
+// CreateStopOrder 创建止损策略委托
+func (o *OKX) CreateStopOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return o.createAlgoOrder("STOP_MARKET", req)
+}
+
+// CreateTakeProfitOrder 创建止盈策略委托
+func (o *OKX) CreateTakeProfitOrder(req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	return o.createAlgoOrder("TAKE_PROFIT_MARKET", req)
+}
+
+// createAlgoOrder 使用conditional策略委托实现止盈止损，触发后市价平仓
+func (o *OKX) createAlgoOrder(orderType string, req *TriggerOrderRequest) (*models.OrderResponse, error) {
+	triggerPxType := "last"
+	if req.WorkingType == "MARK_PRICE" {
+		triggerPxType = "mark"
+	}
+
+	algoReq := &okx.AlgoOrderRequest{
+		InstID:      okxInstID(req.Symbol),
+		TdMode:      "cross",
+		Side:        strings.ToLower(req.Side),
+		PosSide:     okxPosSide(req.PositionSide),
+		OrdType:     "conditional",
+		ReduceOnly:  true,
+		AlgoClOrdID: newClientOrderID(),
+	}
+
+	if req.Quantity != "" {
+		contracts, err := o.toContracts(req.Symbol, req.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		algoReq.Sz = contracts
+	} else if req.ClosePosition {
+		algoReq.CloseFraction = "1"
+	} else {
+		return nil, fmt.Errorf("OKX条件单需要quantity参数或closePosition")
+	}
+
+	if orderType == "STOP_MARKET" {
+		algoReq.SlTriggerPx = req.StopPrice
+		algoReq.SlOrdPx = "-1" // 市价
+		algoReq.SlTriggerPxType = triggerPxType
+	} else {
+		algoReq.TpTriggerPx = req.StopPrice
+		algoReq.TpOrdPx = "-1" // 市价
+		algoReq.TpTriggerPxType = triggerPxType
+	}
+
+	logger.Infof("创建条件单（OKX）: %s, 类型: %s, 方向: %s, 触发价格: %s, 数量: %s",
+		req.Symbol, orderType, req.Side, req.StopPrice, req.Quantity)
+
+	result, err := o.client.PlaceAlgoOrder(algoReq)
+	if err != nil {
+		return nil, err
+	}
+
+	algoID, _ := strconv.ParseInt(result.AlgoID, 10, 64)
+	now := time.Now().UnixMilli()
+	return &models.OrderResponse{
+		OrderID:       algoID,
+		Symbol:        req.Symbol,
+		Status:        "NEW",
+		ClientOrderID: result.AlgoClOrdID,
+		OrigQty:       req.Quantity,
+		Type:          orderType,
+		Side:          req.Side,
+		PositionSide:  req.PositionSide,
+		StopPrice:     req.StopPrice,
+		WorkingType:   req.WorkingType,
+		ReduceOnly:    true,
+		Time:          now,
+		UpdateTime:    now,
+	}, nil
+}
+
+// GetPositionRisk 查询持仓，数量由张换算为币的数量
+func (o *OKX) GetPositionRisk(symbol string) ([]models.PositionRisk, error) {
+	instID := ""
+	if symbol != "" {
+		instID = okxInstID(symbol)
+	}
+	positions, err := o.client.GetPositions(instID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.PositionRisk, 0, len(positions))
+	for _, p := range positions {
+		positionSymbol := okxSymbol(p.InstID)
+		ctVal, err := o.contractValue(positionSymbol)
+		if err != nil {
+			return nil, err
+		}
+
+		contracts, _ := strconv.ParseFloat(p.Pos, 64)
+		if p.PosSide == "short" && contracts > 0 {
+			contracts = -contracts
+		}
+		notional, _ := strconv.ParseFloat(p.NotionalUsd, 64)
+		if contracts < 0 {
+			notional = -math.Abs(notional)
+		}
+		updateTime, _ := strconv.ParseInt(p.UTime, 10, 64)
+
+		result = append(result, models.PositionRisk{
+			Symbol:           positionSymbol,
+			PositionAmt:      formatFloat(contracts * ctVal),
+			EntryPrice:       p.AvgPx,
+			MarkPrice:        p.MarkPx,
+			UnRealizedProfit: p.Upl,
+			LiquidationPrice: p.LiqPx,
+			Leverage:         p.Lever,
+			MarginType:       p.MgnMode,
+			PositionSide:     okxPositionSide(p.PosSide),
+			Notional:         formatFloat(notional),
+			UpdateTime:       updateTime,
+		})
+	}
+	return result, nil
+}
+
+// CancelOrder 撤销订单，条件单走策略委托撤单接口
+func (o *OKX) CancelOrder(symbol string, order *models.OrderResponse) error {
+	orderID := strconv.FormatInt(order.OrderID, 10)
+	if IsStopOrderType(order.Type) {
+		return o.client.CancelAlgoOrder(okxInstID(symbol), orderID)
+	}
+	_, err := o.client.CancelOrder(okxInstID(symbol), orderID, "")
+	return err
+}
+
+// contractValue 获取合约面值，缓存未命中时刷新合约列表
+func (o *OKX) contractValue(symbol string) (float64, error) {
+	o.mu.RLock()
+	inst, ok := o.instruments[symbol]
+	o.mu.RUnlock()
+
+	if !ok {
+		if _, err := o.GetExchangeInfo(); err != nil {
+			return 0, fmt.Errorf("获取合约信息失败: %w", err)
+		}
+		o.mu.RLock()
+		inst, ok = o.instruments[symbol]
+		o.mu.RUnlock()
+		if !ok {
+			return 0, fmt.Errorf("交易对 %s 不存在", symbol)
+		}
+	}
+
+	ctVal, err := strconv.ParseFloat(inst.CtVal, 64)
+	if err != nil || ctVal <= 0 {
+		return 0, fmt.Errorf("无效的合约面值: %s", inst.CtVal)
+	}
+	return ctVal, nil
+}
+
+// toContracts 币的数量换算为张数（向下取整到lotSz）
+func (o *OKX) toContracts(symbol, quantity string) (string, error) {
+	ctVal, err := o.contractValue(symbol)
+	if err != nil {
+		return "", err
+	}
+	qty, err := strconv.ParseFloat(quantity, 64)
+	if err != nil {
+		return "", fmt.Errorf("无效的数量: %s", quantity)
+	}
+
+	o.mu.RLock()
+	lotSz, _ := strconv.ParseFloat(o.instruments[symbol].LotSz, 64)
+	o.mu.RUnlock()
+
+	contracts := qty / ctVal
+	if lotSz > 0 {
+		// 加上极小值避免浮点误差导致少一个步进
+		contracts = math.Floor(contracts/lotSz+1e-9) * lotSz
+	}
+	if contracts <= 0 {
+		return "", fmt.Errorf("数量 %s 不足一张合约（面值 %s）", quantity, formatFloat(ctVal))
+	}
+	return formatFloat(contracts), nil
+}
+
+// okxInstID BTCUSDT -> BTC-USDT-SWAP
+func okxInstID(symbol string) string {
+	return strings.TrimSuffix(symbol, "USDT") + "-USDT-SWAP"
+}
+
+// okxSymbol BTC-USDT-SWAP -> BTCUSDT
+func okxSymbol(instID string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(instID, "-SWAP"), "-", "")
+}
+
+// okxPosSide 持仓方向 -> posSide
+func okxPosSide(positionSide string) string {
+	switch positionSide {
+	case "LONG":
+		return "long"
+	case "SHORT":
+		return "short"
+	}
+	return "net"
+}
+
+// okxPositionSide posSide -> 持仓方向
+func okxPositionSide(posSide string) string {
+	switch posSide {
+	case "long":
+		return "LONG"
+	case "short":
+		return "SHORT"
+	}
+	return "BOTH"
+}
+
+// okxStatus 合约状态转换为币安格式
+func okxStatus(state string) string {
+	switch state {
+	case "live":
+		return "TRADING"
+	case "preopen":
+		return "PENDING_TRADING"
+	}
+	return strings.ToUpper(state)
+}
+
+// formatFloat 格式化浮点数，去掉多余的0（先舍入到12位小数消除乘法带来的浮点误差）
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e12)/1e12, 'f', -1, 64)
+}
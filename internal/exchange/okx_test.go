@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"testing"
+
+	"new_listing_trade/internal/api/okx"
+)
+
+func newTestOKX(t *testing.T) (*OKX, *fixtureServer) {
+	srv := newFixtureServer(t, map[string]string{
+		okx.InstrumentsEndpoint: "okx_instruments.json",
+		okx.TickerEndpoint:      "okx_ticker.json",
+		okx.OrderEndpoint:       "okx_order.json",
+		okx.AlgoOrderEndpoint:   "okx_algo_order.json",
+		okx.PositionsEndpoint:   "okx_positions.json",
+	})
+	return NewOKXWithClient(okx.NewClient("k", "s", "p", srv.URL)), srv
+}
+
+func TestOKXExchangeInfo(t *testing.T) {
+	ex, _ := newTestOKX(t)
+
+	info, err := ex.GetExchangeInfo()
+	if err != nil {
+		t.Fatalf("获取合约列表失败: %v", err)
+	}
+	if len(info.Symbols) != 2 {
+		t.Fatalf("应只保留USDT本位永续合约，实际: %+v", info.Symbols)
+	}
+
+	btc := info.Symbols[0]
+	if btc.Symbol != "BTCUSDT" || btc.Status != "TRADING" {
+		t.Errorf("合约转换不正确: %+v", btc)
+	}
+	// lotSz 0.01张 * ctVal 0.01 = 0.0001 BTC
+	if f := btc.Filters[0]; f.StepSize != "0.0001" || f.MinQty != "0.0001" {
+		t.Errorf("数量精度应按合约面值换算: %+v", f)
+	}
+
+	if s := info.Symbols[1]; s.Symbol != "NEWUSDT" || s.Status != "PENDING_TRADING" || s.Filters[0].StepSize != "10" {
+		t.Errorf("预上线合约转换不正确: %+v", s)
+	}
+}
+
+func TestOKXMarketOrderFromNotional(t *testing.T) {
+	ex, srv := newTestOKX(t)
+
+	order, err := ex.CreateMarketOrder(&MarketOrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "SELL",
+		PositionSide: "BOTH",
+		Notional:     "120",
+	})
+	if err != nil {
+		t.Fatalf("创建市价单失败: %v", err)
+	}
+
+	var req okx.OrderRequest
+	srv.lastBody(t, okx.OrderEndpoint, &req)
+	// 120 / 60000 = 0.002 BTC = 0.2张
+	if req.InstID != "BTC-USDT-SWAP" || req.Side != "sell" || req.OrdType != "market" || req.Sz != "0.2" || req.PosSide != "net" {
+		t.Errorf("下单参数不正确: %+v", req)
+	}
+	if order.OrderID != 312269865356374016 || order.OrigQty != "0.0020" {
+		t.Errorf("订单响应不正确: %+v", order)
+	}
+}
+
+func TestOKXStopOrder(t *testing.T) {
+	ex, srv := newTestOKX(t)
+
+	order, err := ex.CreateStopOrder(&TriggerOrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          "BUY",
+		PositionSide:  "BOTH",
+		StopPrice:     "66000",
+		ClosePosition: true,
+		WorkingType:   "CONTRACT_PRICE",
+	})
+	if err != nil {
+		t.Fatalf("创建止损单失败: %v", err)
+	}
+
+	var req okx.AlgoOrderRequest
+	srv.lastBody(t, okx.AlgoOrderEndpoint, &req)
+	if req.OrdType != "conditional" || req.CloseFraction != "1" || req.SlTriggerPx != "66000" || req.SlOrdPx != "-1" || req.SlTriggerPxType != "last" {
+		t.Errorf("策略委托参数不正确: %+v", req)
+	}
+	if req.TpTriggerPx != "" {
+		t.Errorf("止损单不应设置止盈触发价: %+v", req)
+	}
+	if order.OrderID != 681096944655273984 || !IsStopOrderType(order.Type) {
+		t.Errorf("订单响应不正确: %+v", order)
+	}
+}
+
+func TestOKXPositionRisk(t *testing.T) {
+	ex, _ := newTestOKX(t)
+
+	positions, err := ex.GetPositionRisk("")
+	if err != nil {
+		t.Fatalf("查询持仓失败: %v", err)
+	}
+	if len(positions) != 1 {
+		t.Fatalf("持仓数量不正确: %+v", positions)
+	}
+	// -20张 * 0.01 = -0.2 BTC
+	p := positions[0]
+	if p.Symbol != "BTCUSDT" || p.PositionAmt != "-0.2" || p.Notional != "-11800" || p.PositionSide != "BOTH" {
+		t.Errorf("空仓转换不正确: %+v", p)
+	}
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"new_listing_trade/internal/models"
+)
+
+// 数据集
+const (
+	DatasetListings  = "listings"  // 新币对
+	DatasetOrders    = "orders"    // 开仓记录（交易日志）
+	DatasetFills     = "fills"     // 开仓和平仓成交
+	DatasetTrades    = "trades"    // 每次开仓的已实现盈亏
+	DatasetPositions = "positions" // 当前持仓
+)
+
+// Datasets 支持导出的数据集
+var Datasets = []string{DatasetListings, DatasetOrders, DatasetFills, DatasetTrades, DatasetPositions}
+
+// 各数据集的列（只追加新列，不修改已有列的名称和顺序）
+var (
+	ListingColumns = []string{
+		"symbol", "source", "status", "confidence", "onboard_time", "found_time", "is_ordered", "order_time",
+	}
+	OrderColumns = []string{
+		"id", "exchange", "account", "symbol", "direction", "rule", "entry_order_id", "entry_time", "onboard_time",
+	}
+	FillColumns = []string{
+		"trade_id", "exchange", "account", "symbol", "fill_type", "fill_id", "order_id", "side", "position_side",
+		"price", "qty", "quote_qty", "realized_pnl", "commission", "commission_asset", "maker", "time",
+	}
+	TradeColumns = []string{
+		"id", "exchange", "account", "symbol", "direction", "rule", "onboard_time",
+		"entry_order_id", "entry_time", "entry_price", "entry_qty",
+		"exit_time", "exit_price", "exit_qty", "closed",
+		"realized_pnl", "commission", "funding", "net_pnl", "error",
+	}
+	PositionColumns = []string{
+		"account", "symbol", "position_side", "position_amt", "entry_price", "mark_price", "liquidation_price",
+		"distance_to_liquidation_percent", "leverage", "margin_type", "notional", "unrealized_profit", "profit_percent",
+		"update_time",
+	}
+)
+
+// ValidDataset 检查数据集名称
+func ValidDataset(dataset string) error {
+	for _, d := range Datasets {
+		if d == dataset {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的数据集: %s（支持 %v）", dataset, Datasets)
+}
+
+// ListingsTable 新币对，按上线时间排序
+func ListingsTable(listings []*models.NewListingSymbol) *Table {
+	sorted := append([]*models.NewListingSymbol(nil), listings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].OnboardDate != sorted[j].OnboardDate {
+			return sorted[i].OnboardDate < sorted[j].OnboardDate
+		}
+		return sorted[i].Symbol < sorted[j].Symbol
+	})
+
+	table := &Table{Name: DatasetListings, Columns: ListingColumns}
+	for _, l := range sorted {
+		orderTime := ""
+		if l.OrderTime != nil {
+			orderTime = formatTime(*l.OrderTime)
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			l.Symbol, l.Source, l.Status, l.Confidence, formatMillis(l.OnboardDate), formatTime(l.FoundTime), l.IsOrdered, orderTime,
+		})
+	}
+	return table
+}
+
+// OrdersTable 开仓记录
+func OrdersTable(entries []models.JournalEntry) *Table {
+	table := &Table{Name: DatasetOrders, Columns: OrderColumns}
+	for _, e := range entries {
+		table.Rows = append(table.Rows, []interface{}{
+			e.ID, e.Exchange, e.Account, e.Symbol, e.Direction, e.Rule, e.EntryOrderID, formatMillis(e.EntryTime), formatMillis(e.OnboardDate),
+		})
+	}
+	return table
+}
+
+// FillsTable 交易记录关联的开仓和平仓成交
+func FillsTable(records []models.TradeRecord) *Table {
+	table := &Table{Name: DatasetFills, Columns: FillColumns}
+	for _, r := range records {
+		entryOrders := map[int64]bool{r.EntryOrderID: true}
+		for _, id := range r.EntryOrderIDs {
+			entryOrders[id] = true
+		}
+		for _, f := range r.Fills {
+			fillType := "exit"
+			if entryOrders[f.OrderID] {
+				fillType = "entry"
+			}
+			table.Rows = append(table.Rows, []interface{}{
+				r.ID, r.Exchange, r.Account, r.Symbol, fillType, f.ID, f.OrderID, f.Side, f.PositionSide,
+				parseFloat(f.Price), parseFloat(f.Qty), parseFloat(f.QuoteQty), parseFloat(f.RealizedPnl),
+				parseFloat(f.Commission), f.CommissionAsset, f.Maker, formatMillis(f.Time),
+			})
+		}
+	}
+	return table
+}
+
+// TradesTable 每次开仓的已实现盈亏
+func TradesTable(records []models.TradeRecord) *Table {
+	table := &Table{Name: DatasetTrades, Columns: TradeColumns}
+	for _, r := range records {
+		table.Rows = append(table.Rows, []interface{}{
+			r.ID, r.Exchange, r.Account, r.Symbol, r.Direction, r.Rule, formatMillis(r.OnboardDate),
+			r.EntryOrderID, formatMillis(r.EntryTime), r.EntryPrice, r.EntryQty,
+			formatMillis(r.ExitTime), r.ExitPrice, r.ExitQty, r.Closed,
+			r.RealizedPnL, r.Commission, r.Funding, r.NetPnL, r.Error,
+		})
+	}
+	return table
+}
+
+// PositionsTable 当前持仓，没有强平价格时距强平百分比为空
+func PositionsTable(positions []models.Position) *Table {
+	table := &Table{Name: DatasetPositions, Columns: PositionColumns}
+	for _, p := range positions {
+		var distance interface{}
+		if p.DistanceToLiquidationPercent != nil {
+			distance = *p.DistanceToLiquidationPercent
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			p.Account, p.Symbol, p.PositionSide, p.PositionAmt, p.EntryPrice, p.MarkPrice, p.LiquidationPrice,
+			distance, p.Leverage, p.MarginType, p.Notional, p.UnRealizedProfit, p.ProfitPercent,
+			formatMillis(p.UpdateTime),
+		})
+	}
+	return table
+}
+
+// parseFloat 解析币安返回的数字字符串，无效时返回0
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+// FilterListings 按币对和上线时间[from, to)筛选新币对，零值表示不限
+func FilterListings(listings map[string]*models.NewListingSymbol, symbol string, from, to int64) []*models.NewListingSymbol {
+	filtered := make([]*models.NewListingSymbol, 0, len(listings))
+	for _, l := range listings {
+		if symbol != "" && l.Symbol != symbol {
+			continue
+		}
+		if (from > 0 && l.OnboardDate < from) || (to > 0 && l.OnboardDate >= to) {
+			continue
+		}
+		filtered = append(filtered, l)
+	}
+	return filtered
+}
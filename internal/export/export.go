@@ -0,0 +1,142 @@
+// Package export 把新币对、开仓记录、成交、盈亏和持仓导出为CSV/JSON Lines/XLSX
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// 导出格式
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatXLSX  = "xlsx"
+)
+
+// timeLayout 导出的时间格式（UTC，毫秒精度）
+const timeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Table 导出的数据表，单元格取值为 string/int64/float64/bool
+type Table struct {
+	Name    string // 数据集名称（XLSX工作表名）
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ValidFormat 检查导出格式
+func ValidFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatJSONL, FormatXLSX:
+		return nil
+	default:
+		return fmt.Errorf("不支持的导出格式: %s（支持 %s/%s/%s）", format, FormatCSV, FormatJSONL, FormatXLSX)
+	}
+}
+
+// ContentType 导出格式对应的HTTP Content-Type
+func ContentType(format string) string {
+	switch format {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Write 按格式写出数据表
+func Write(w io.Writer, format string, table *Table) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, table)
+	case FormatJSONL:
+		return writeJSONL(w, table)
+	case FormatXLSX:
+		return writeXLSX(w, table)
+	default:
+		return ValidFormat(format)
+	}
+}
+
+// writeCSV 第一行为列名
+func writeCSV(w io.Writer, table *Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(table.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, v := range row {
+			record[i] = formatCell(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSONL 每行一个JSON对象，字段顺序与列顺序一致
+func writeJSONL(w io.Writer, table *Table) error {
+	for _, row := range table.Rows {
+		line := []byte{'{'}
+		for i, v := range row {
+			if i > 0 {
+				line = append(line, ',')
+			}
+			key, _ := json.Marshal(table.Columns[i])
+			value, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			line = append(line, key...)
+			line = append(line, ':')
+			line = append(line, value...)
+		}
+		line = append(line, '}', '\n')
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatCell 单元格的文本形式
+func formatCell(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// formatMillis 毫秒时间戳格式化为UTC时间，0返回空字符串
+func formatMillis(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format(timeLayout)
+}
+
+// formatTime 时间格式化为UTC时间，零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(timeLayout)
+}
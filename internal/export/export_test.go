@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/models"
+)
+
+var testTable = &Table{
+	Name:    "trades",
+	Columns: []string{"symbol", "qty", "closed", "note"},
+	Rows: [][]interface{}{
+		{"ABCUSDT", 1.5, true, `a,"b"`},
+		{"XYZUSDT", int64(2), false, nil},
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, testTable); err != nil {
+		t.Fatalf("导出CSV失败: %v", err)
+	}
+	want := "symbol,qty,closed,note\nABCUSDT,1.5,true,\"a,\"\"b\"\"\"\nXYZUSDT,2,false,\n"
+	if buf.String() != want {
+		t.Errorf("CSV内容不正确:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSONL, testTable); err != nil {
+		t.Fatalf("导出JSONL失败: %v", err)
+	}
+	want := `{"symbol":"ABCUSDT","qty":1.5,"closed":true,"note":"a,\"b\""}` + "\n" +
+		`{"symbol":"XYZUSDT","qty":2,"closed":false,"note":null}` + "\n"
+	if buf.String() != want {
+		t.Errorf("JSONL内容不正确:\n%s", buf.String())
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatXLSX, testTable); err != nil {
+		t.Fatalf("导出XLSX失败: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("XLSX不是有效的zip文件: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("XLSX缺少 %s", name)
+		}
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, cell := range []string{
+		`<c r="A1" t="inlineStr"><is><t>symbol</t></is></c>`,
+		`<c r="B2"><v>1.5</v></c>`,
+		`<c r="C2" t="b"><v>1</v></c>`,
+		`<c r="D2" t="inlineStr"><is><t>a,&#34;b&#34;</t></is></c>`,
+		`<c r="B3"><v>2</v></c>`,
+	} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("工作表缺少单元格 %s", cell)
+		}
+	}
+	if strings.Contains(sheet, `r="D3"`) {
+		t.Error("空单元格应省略")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(index); got != want {
+			t.Errorf("columnName(%d) = %s，期望 %s", index, got, want)
+		}
+	}
+}
+
+func TestTablesMatchColumns(t *testing.T) {
+	orderTime := time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)
+	distance := 12.5
+	tables := []*Table{
+		ListingsTable([]*models.NewListingSymbol{{Symbol: "ABCUSDT", OnboardDate: 1704067200000, FoundTime: orderTime, OrderTime: &orderTime}}),
+		OrdersTable([]models.JournalEntry{{ID: "binance/main/ABCUSDT/1", EntryOrderID: 1, EntryTime: 1704067200000}}),
+		FillsTable([]models.TradeRecord{{JournalEntry: models.JournalEntry{EntryOrderID: 1}, Fills: []models.UserTrade{{OrderID: 1}, {OrderID: 2}}}}),
+		TradesTable([]models.TradeRecord{{JournalEntry: models.JournalEntry{Symbol: "ABCUSDT"}}}),
+		PositionsTable([]models.Position{{Symbol: "ABCUSDT", DistanceToLiquidationPercent: &distance}, {Symbol: "XYZUSDT"}}),
+	}
+	for _, table := range tables {
+		if len(table.Rows) == 0 {
+			t.Errorf("%s: 没有数据行", table.Name)
+		}
+		for i, row := range table.Rows {
+			if len(row) != len(table.Columns) {
+				t.Errorf("%s 第 %d 行有 %d 列，期望 %d 列", table.Name, i, len(row), len(table.Columns))
+			}
+		}
+	}
+
+	if got := tables[0].Rows[0][4]; got != "2024-01-01T00:00:00.000Z" {
+		t.Errorf("上线时间应格式化为UTC时间，实际: %v", got)
+	}
+	if fills := tables[2].Rows; fills[0][4] != "entry" || fills[1][4] != "exit" {
+		t.Errorf("成交类型不正确: %v / %v", fills[0][4], fills[1][4])
+	}
+}
+
+func TestFilterListings(t *testing.T) {
+	listings := map[string]*models.NewListingSymbol{
+		"AAAUSDT": {Symbol: "AAAUSDT", OnboardDate: 1000},
+		"BBBUSDT": {Symbol: "BBBUSDT", OnboardDate: 2000},
+		"CCCUSDT": {Symbol: "CCCUSDT", OnboardDate: 3000},
+	}
+	if got := FilterListings(listings, "", 2000, 3000); len(got) != 1 || got[0].Symbol != "BBBUSDT" {
+		t.Errorf("按上线时间筛选不正确: %v", got)
+	}
+	if got := FilterListings(listings, "CCCUSDT", 0, 0); len(got) != 1 || got[0].Symbol != "CCCUSDT" {
+		t.Errorf("按币对筛选不正确: %v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+// 服务导出的指标
+var (
+	// BinanceRequestDuration 币安REST请求耗时（endpoint为请求路径，status为HTTP状态码，网络错误为error）
+	BinanceRequestDuration = NewHistogramVec(
+		"nlt_binance_request_duration_seconds",
+		"Binance REST request latency in seconds.",
+		DefaultBuckets, "endpoint", "status",
+	)
+
+	// BinanceRetries RetryWithBackoff 的重试次数（reason: network/api）
+	BinanceRetries = NewCounterVec(
+		"nlt_binance_retries_total",
+		"Number of retries performed by RetryWithBackoff.",
+		"reason",
+	)
+
+	// Orders 下单次数（type: entry/stop_loss/take_profit，result: placed/failed）
+	Orders = NewCounterVec(
+		"nlt_orders_total",
+		"Orders placed or failed by order type.",
+		"exchange", "type", "result",
+	)
+
+	// NewListings 发现的新币对数量
+	NewListings = NewCounterVec(
+		"nlt_new_listings_total",
+		"New listings detected by source.",
+		"source",
+	)
+
+	// OnboardToFill 从上线时间到开仓成交的耗时
+	OnboardToFill = NewHistogramVec(
+		"nlt_onboard_to_fill_seconds",
+		"Time from listing onboard date to entry order fill in seconds.",
+		[]float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	)
+
+	// ExchangeInfoPollDuration exchangeInfo轮询耗时（result: ok/not_modified/error）
+	ExchangeInfoPollDuration = NewHistogramVec(
+		"nlt_exchange_info_poll_duration_seconds",
+		"Duration of exchangeInfo polls in seconds.",
+		DefaultBuckets, "result",
+	)
+
+	// PositionNotional 持仓名义价值（USDT，空仓为负数），来自GetPositionRisk
+	PositionNotional = NewGaugeVec(
+		"nlt_position_notional_usdt",
+		"Open position notional in USDT from GetPositionRisk.",
+		"account", "symbol", "position_side",
+	)
+
+	// PositionUnrealizedPnL 持仓未实现盈亏（USDT），来自GetPositionRisk
+	PositionUnrealizedPnL = NewGaugeVec(
+		"nlt_position_unrealized_pnl_usdt",
+		"Open position unrealized PnL in USDT from GetPositionRisk.",
+		"account", "symbol", "position_side",
+	)
+)
+
+// 下单类型
+const (
+	OrderTypeEntry      = "entry"
+	OrderTypeStopLoss   = "stop_loss"
+	OrderTypeTakeProfit = "take_profit"
+	OrderTypeUnwind     = "unwind" // 止损单创建失败后的市价平仓
+)
+
+// OrderResult 根据下单错误返回result标签
+func OrderResult(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "placed"
+}
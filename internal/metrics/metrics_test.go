@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	counter := NewCounterVec("test_requests_total", "Test counter.", "code")
+	counter.Inc("200")
+	counter.Add(2, "200")
+	counter.Inc("500")
+
+	gauge := NewGaugeVec("test_position", "Test gauge.", "account", "symbol")
+	gauge.Set(-12.5, "main", "BTCUSDT")
+	gauge.Set(3, "sub", "ETHUSDT")
+	gauge.DeleteMatching("sub")
+
+	histogram := NewHistogramVec("test_latency_seconds", "Test histogram.", []float64{0.1, 1}, "endpoint")
+	histogram.Observe(0.05, "/fapi/v1/order")
+	histogram.Observe(0.5, "/fapi/v1/order")
+	histogram.Observe(5, "/fapi/v1/order")
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf); err != nil {
+		t.Fatalf("输出指标失败: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{code="200"} 3` + "\n",
+		`test_requests_total{code="500"} 1` + "\n",
+		"# TYPE test_position gauge\n",
+		`test_position{account="main",symbol="BTCUSDT"} -12.5` + "\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{endpoint="/fapi/v1/order",le="0.1"} 1` + "\n",
+		`test_latency_seconds_bucket{endpoint="/fapi/v1/order",le="1"} 2` + "\n",
+		`test_latency_seconds_bucket{endpoint="/fapi/v1/order",le="+Inf"} 3` + "\n",
+		`test_latency_seconds_sum{endpoint="/fapi/v1/order"} 5.55` + "\n",
+		`test_latency_seconds_count{endpoint="/fapi/v1/order"} 3` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `account="sub"`) {
+		t.Errorf("已删除的指标仍被输出:\n%s", out)
+	}
+}
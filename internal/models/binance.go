@@ -13,13 +13,39 @@ type Symbol struct {
 	Filters     []Filter `json:"filters,omitempty"` // 交易对过滤器
 }
 
-// Filter 交易对过滤器
+// 交易对过滤器类型
+const (
+	FilterTypePrice            = "PRICE_FILTER"        // 价格范围和步进值
+	FilterTypeLotSize          = "LOT_SIZE"            // 限价单的数量范围和步进值
+	FilterTypeMarketLotSize    = "MARKET_LOT_SIZE"     // 市价单（包括市价止盈止损单）的数量范围和步进值
+	FilterTypeMaxNumOrders     = "MAX_NUM_ORDERS"      // 最大挂单数
+	FilterTypeMaxNumAlgoOrders = "MAX_NUM_ALGO_ORDERS" // 最大条件单数
+	FilterTypeMinNotional      = "MIN_NOTIONAL"        // 最小名义价值
+	FilterTypePercentPrice     = "PERCENT_PRICE"       // 价格相对标记价格的上下限
+)
+
+// Filter 交易对过滤器，不同类型只使用其中一部分字段
 type Filter struct {
-	FilterType string `json:"filterType"`         // LOT_SIZE, PRICE_FILTER等
-	MinQty     string `json:"minQty,omitempty"`   // 最小交易量
-	MaxQty     string `json:"maxQty,omitempty"`   // 最大交易量
-	StepSize   string `json:"stepSize,omitempty"` // 数量步进值
-	MinPrice   string `json:"minPrice,omitempty"` // 最小价格
-	MaxPrice   string `json:"maxPrice,omitempty"` // 最大价格
-	TickSize   string `json:"tickSize,omitempty"` // 价格步进值
+	FilterType string `json:"filterType"` // 过滤器类型，见 FilterType* 常量
+
+	// PRICE_FILTER
+	MinPrice string `json:"minPrice,omitempty"` // 最小价格
+	MaxPrice string `json:"maxPrice,omitempty"` // 最大价格
+	TickSize string `json:"tickSize,omitempty"` // 价格步进值
+
+	// LOT_SIZE / MARKET_LOT_SIZE
+	MinQty   string `json:"minQty,omitempty"`   // 最小交易量
+	MaxQty   string `json:"maxQty,omitempty"`   // 最大交易量
+	StepSize string `json:"stepSize,omitempty"` // 数量步进值
+
+	// MAX_NUM_ORDERS / MAX_NUM_ALGO_ORDERS
+	Limit int `json:"limit,omitempty"` // 最大挂单数或最大条件单数
+
+	// MIN_NOTIONAL
+	Notional string `json:"notional,omitempty"` // 最小名义价值（数量×价格）
+
+	// PERCENT_PRICE
+	MultiplierUp      string `json:"multiplierUp,omitempty"`      // 价格上限为标记价格的倍数
+	MultiplierDown    string `json:"multiplierDown,omitempty"`    // 价格下限为标记价格的倍数
+	MultiplierDecimal string `json:"multiplierDecimal,omitempty"` // 倍数的精度
 }
@@ -10,4 +10,6 @@ type NewListingSymbol struct {
 	FoundTime   time.Time  // 发现时间
 	IsOrdered   bool       // 是否已下单
 	OrderTime   *time.Time // 下单时间（如果已下单）
+	Source      string     // 发现来源：exchange_info/announcement/webhook/manual
+	Confidence  float64    // 置信度（0~1），exchangeInfo确认的币对为1
 }
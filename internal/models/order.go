@@ -47,6 +47,8 @@ type OrderResponse struct {
 	GoodTillDate            int64  `json:"goodTillDate"`
 	Time                    int64  `json:"time"`
 	UpdateTime              int64  `json:"updateTime"`
+
+	MergedOrderIDs []int64 `json:"mergedOrderIds,omitempty"` // 多个子单合并为一个开仓单时各子单的订单ID（本地字段，交易所不返回）
 }
 
 // OrderQueryParams 查询订单参数
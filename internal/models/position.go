@@ -19,6 +19,7 @@ type PositionRisk struct {
 
 // Position 持仓信息（内部使用）
 type Position struct {
+	Account          string  `json:"account,omitempty"` // 账户名称
 	Symbol           string  `json:"symbol"`            // 交易对
 	PositionAmt      float64 `json:"position_amt"`      // 持仓数量（正数为多，负数为空）
 	EntryPrice       float64 `json:"entry_price"`       // 开仓价格
@@ -31,6 +32,30 @@ type Position struct {
 	Notional         float64 `json:"notional"`          // 持仓名义价值（USDT）
 	UpdateTime       int64   `json:"update_time"`       // 更新时间
 	ProfitPercent    float64 `json:"profit_percent"`    // 收益率百分比
+
+	// DistanceToLiquidationPercent 标记价格距强平价格的百分比（|标记价-强平价|/标记价*100），
+	// 没有强平价格（例如全仓保证金充足）时为空
+	DistanceToLiquidationPercent *float64 `json:"distance_to_liquidation_percent,omitempty"`
+
+	// FundingRatePercent 当前资金费率（百分比，正数为多头支付空头），NextFundingTime 下次结算时间（毫秒），
+	// 交易所不支持或查询失败时为空
+	FundingRatePercent *float64 `json:"funding_rate_percent,omitempty"`
+	NextFundingTime    int64    `json:"next_funding_time,omitempty"`
+}
+
+// PositionSummary 持仓汇总
+type PositionSummary struct {
+	TotalNotional         float64 `json:"total_notional"`          // 持仓名义价值绝对值之和（USDT）
+	TotalUnrealizedProfit float64 `json:"total_unrealized_profit"` // 未实现盈亏之和（USDT）
+	ProfitableCount       int     `json:"profitable_count"`        // 盈利仓位数
+	LosingCount           int     `json:"losing_count"`            // 亏损仓位数
+}
+
+// PositionsResponse 持仓列表响应
+type PositionsResponse struct {
+	TotalCount int             `json:"total_count"` // 筛选后的仓位数
+	Summary    PositionSummary `json:"summary"`     // 筛选后仓位的汇总
+	Positions  []Position      `json:"positions"`   // 仓位列表（按查询参数排序）
 }
 
 // NegativePositionResponse 负收益仓位响应
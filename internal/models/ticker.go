@@ -5,3 +5,54 @@ type TickerPrice struct {
 	Symbol string `json:"symbol"`
 	Price  string `json:"price"`
 }
+
+// BookTicker 最优挂单（买一/卖一）
+type BookTicker struct {
+	Symbol   string `json:"symbol"`
+	BidPrice string `json:"bidPrice"`
+	BidQty   string `json:"bidQty"`
+	AskPrice string `json:"askPrice"`
+	AskQty   string `json:"askQty"`
+}
+
+// Depth 盘口深度，每档为 [价格, 数量]，买盘按价格从高到低，卖盘按价格从低到高
+type Depth struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Bids         [][]string `json:"bids"`
+	Asks         [][]string `json:"asks"`
+}
+
+// PremiumIndex 标记价格和资金费率
+type PremiumIndex struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	IndexPrice      string `json:"indexPrice"`
+	LastFundingRate string `json:"lastFundingRate"` // 最近更新的资金费率（下次结算的预测值）
+	NextFundingTime int64  `json:"nextFundingTime"` // 下次资金费结算时间（毫秒）
+	InterestRate    string `json:"interestRate"`
+	Time            int64  `json:"time"`
+}
+
+// FundingRate 历史资金费率
+type FundingRate struct {
+	Symbol      string `json:"symbol"`
+	FundingRate string `json:"fundingRate"`
+	FundingTime int64  `json:"fundingTime"` // 结算时间（毫秒）
+	MarkPrice   string `json:"markPrice"`
+}
+
+// FundingInfo 资金费率设置（只返回调整过资金费率上下限或结算间隔的交易对）
+type FundingInfo struct {
+	Symbol                   string `json:"symbol"`
+	AdjustedFundingRateCap   string `json:"adjustedFundingRateCap"`   // 资金费率上限
+	AdjustedFundingRateFloor string `json:"adjustedFundingRateFloor"` // 资金费率下限
+	FundingIntervalHours     int    `json:"fundingIntervalHours"`     // 结算间隔（小时）
+}
+
+// FundingRateParams 历史资金费率查询参数
+type FundingRateParams struct {
+	Symbol    string // 交易对，留空查询全部
+	StartTime int64  // 开始时间（毫秒），0表示不限
+	EndTime   int64  // 结束时间（毫秒），0表示不限
+	Limit     int    // 返回数量，默认100，最大1000
+}
@@ -0,0 +1,106 @@
+package models
+
+// UserTrade 账户成交记录（币安API返回格式）
+type UserTrade struct {
+	ID              int64  `json:"id"`              // 成交ID
+	OrderID         int64  `json:"orderId"`         // 订单ID
+	Symbol          string `json:"symbol"`          // 交易对
+	Side            string `json:"side"`            // 买卖方向 BUY/SELL
+	PositionSide    string `json:"positionSide"`    // 持仓方向 BOTH/LONG/SHORT
+	Price           string `json:"price"`           // 成交价格
+	Qty             string `json:"qty"`             // 成交数量
+	QuoteQty        string `json:"quoteQty"`        // 成交金额
+	RealizedPnl     string `json:"realizedPnl"`     // 已实现盈亏
+	Commission      string `json:"commission"`      // 手续费
+	CommissionAsset string `json:"commissionAsset"` // 手续费资产
+	Buyer           bool   `json:"buyer"`           // 是否为买方
+	Maker           bool   `json:"maker"`           // 是否为挂单方
+	Time            int64  `json:"time"`            // 成交时间
+}
+
+// UserTradesParams 查询成交记录参数
+type UserTradesParams struct {
+	Symbol    string // 交易对（必填）
+	StartTime int64  // 开始时间（毫秒），0表示不限
+	EndTime   int64  // 结束时间（毫秒），0表示不限；与StartTime间隔不能超过7天
+	FromID    int64  // 从该成交ID开始返回，0表示不限
+	Limit     int    // 返回数量，默认500，最大1000
+}
+
+// 收益类型
+const (
+	IncomeTypeRealizedPnL = "REALIZED_PNL" // 已实现盈亏
+	IncomeTypeCommission  = "COMMISSION"   // 手续费
+	IncomeTypeFundingFee  = "FUNDING_FEE"  // 资金费
+)
+
+// Income 资金流水（币安API返回格式）
+type Income struct {
+	Symbol     string `json:"symbol"`     // 交易对，可能为空
+	IncomeType string `json:"incomeType"` // 收益类型
+	Income     string `json:"income"`     // 金额，正数为收入，负数为支出
+	Asset      string `json:"asset"`      // 资产
+	Info       string `json:"info"`       // 备注
+	Time       int64  `json:"time"`       // 时间
+	TranID     int64  `json:"tranId"`     // 划转ID
+	TradeID    string `json:"tradeId"`    // 成交ID（仅成交相关流水）
+}
+
+// IncomeParams 查询资金流水参数
+type IncomeParams struct {
+	Symbol     string // 交易对，留空查询全部
+	IncomeType string // 收益类型，留空查询全部
+	StartTime  int64  // 开始时间（毫秒），0表示不限
+	EndTime    int64  // 结束时间（毫秒），0表示不限
+	Limit      int    // 返回数量，默认100，最大1000
+}
+
+// JournalEntry 交易日志中的一次开仓（持久化到日志文件）
+type JournalEntry struct {
+	ID            string  `json:"id"`                        // 交易所/账户/币对/开仓单ID
+	Exchange      string  `json:"exchange"`                  // 交易所
+	Account       string  `json:"account,omitempty"`         // 币安账户
+	Symbol        string  `json:"symbol"`                    // 交易对
+	Direction     string  `json:"direction"`                 // 开仓方向 SHORT/LONG
+	Rule          string  `json:"rule,omitempty"`            // 生效的策略规则
+	EntryOrderID  int64   `json:"entry_order_id"`            // 开仓单ID
+	EntryOrderIDs []int64 `json:"entry_order_ids,omitempty"` // 拆单或追价开仓时全部有成交的子单ID（包含EntryOrderID）
+	EntryTime     int64   `json:"entry_time"`                // 开仓时间（毫秒）
+	Quantity      string  `json:"quantity,omitempty"`        // 开仓成交数量
+	OnboardDate   int64   `json:"onboard_date,omitempty"`    // 币对上线时间（毫秒）
+}
+
+// TradeRecord 一次开仓及其平仓成交，盈亏均为USDT
+type TradeRecord struct {
+	JournalEntry
+	EntryPrice   float64 `json:"entry_price"`         // 开仓均价
+	EntryQty     float64 `json:"entry_qty"`           // 开仓数量
+	ExitPrice    float64 `json:"exit_price"`          // 平仓均价
+	ExitQty      float64 `json:"exit_qty"`            // 已平仓数量
+	ExitTime     int64   `json:"exit_time,omitempty"` // 最后一笔平仓成交时间（毫秒）
+	ExitOrderIDs []int64 `json:"exit_order_ids"`      // 平仓订单ID
+	Closed       bool    `json:"closed"`              // 是否已完全平仓
+	RealizedPnL  float64 `json:"realized_pnl"`        // 平仓成交的已实现盈亏
+	Commission   float64 `json:"commission"`          // 开仓和平仓手续费
+	Funding      float64 `json:"funding"`             // 持仓期间资金费，正数为收入
+	NetPnL       float64 `json:"net_pnl"`             // 净盈亏 = 已实现盈亏 - 手续费 + 资金费
+	Error        string  `json:"error,omitempty"`     // 查询成交失败的原因
+
+	Fills []UserTrade `json:"fills,omitempty"` // 开仓和平仓成交（按时间排序）
+}
+
+// PnLSummary 已实现盈亏汇总
+type PnLSummary struct {
+	From        int64              `json:"from,omitempty"` // 开仓时间范围（毫秒）
+	To          int64              `json:"to,omitempty"`
+	TradeCount  int                `json:"trade_count"`  // 开仓次数
+	ClosedCount int                `json:"closed_count"` // 已完全平仓次数
+	WinCount    int                `json:"win_count"`    // 净盈利次数
+	LossCount   int                `json:"loss_count"`   // 净亏损次数
+	RealizedPnL float64            `json:"realized_pnl"`
+	Commission  float64            `json:"commission"`
+	Funding     float64            `json:"funding"`
+	NetPnL      float64            `json:"net_pnl"`
+	BySymbol    map[string]float64 `json:"by_symbol"`  // 各币对净盈亏
+	ByAccount   map[string]float64 `json:"by_account"` // 各账户净盈亏
+}
@@ -0,0 +1,349 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/logger"
+)
+
+// DefaultRateLimit 每个渠道每分钟默认最多发送的消息数
+const DefaultRateLimit = 20
+
+// rateWindow 限流的时间窗口
+const rateWindow = time.Minute
+
+// queueSize 每个渠道待发送消息的队列长度，队列满时丢弃
+const queueSize = 100
+
+// criticalQueueSize 每个渠道为需要人工介入的告警预留的队列长度，普通消息占满队列时也能入队
+const criticalQueueSize = 20
+
+// defaultTemplate 默认消息模板
+const defaultTemplate = `【{{label .Type}}】{{if .Account}}[{{.Account}}] {{end}}{{.Message}}
+{{formatTime .Time}}`
+
+// typeLabels 事件类型的中文说明（消息标题）
+var typeLabels = map[string]string{
+	events.TypeListingDiscovered:   "发现新币",
+	events.TypeOrderPlaced:         "下单",
+	events.TypeOrderFilled:         "成交",
+	events.TypeStopLossTriggered:   "止损触发",
+	events.TypeTakeProfitTriggered: "止盈触发",
+	events.TypeBracketFailed:       "止盈止损单创建失败",
+	events.TypeBracketDrift:        "仓位保护异常",
+	events.TypeStopMoved:           "移动止损",
+	events.TypePositionClosed:      "平仓",
+	events.TypeRiskLimitBreached:   "风控告警",
+	events.TypeError:               "错误",
+}
+
+// criticalTypes 需要人工介入的告警，超出限流也不丢弃
+var criticalTypes = map[string]bool{
+	events.TypeBracketFailed: true,
+	events.TypeBracketDrift:  true,
+	events.TypeError:         true,
+}
+
+// label 事件类型的中文说明，未知类型原样返回
+func label(eventType string) string {
+	if l, ok := typeLabels[eventType]; ok {
+		return l
+	}
+	return eventType
+}
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	"label": label,
+	"formatTime": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+}
+
+// route 路由规则
+type route struct {
+	events   map[string]bool
+	accounts map[string]bool
+	symbols  map[string]bool
+	channels []*channel
+}
+
+// match 事件是否符合规则，条件为空表示不限
+func (r *route) match(e *events.Event) bool {
+	return (len(r.events) == 0 || r.events[e.Type]) &&
+		(len(r.accounts) == 0 || r.accounts[e.Account]) &&
+		(len(r.symbols) == 0 || r.symbols[e.Symbol])
+}
+
+// channel 通知渠道及其发送队列和限流
+type channel struct {
+	notifier Notifier
+	limiter  *rateLimiter
+	queue    chan Message
+	critical chan Message // criticalTypes 的消息，优先发送
+}
+
+// Dispatcher 订阅事件总线，按规则渲染消息并推送到通知渠道
+type Dispatcher struct {
+	channels  []*channel
+	routes    []*route
+	templates map[string]*template.Template
+}
+
+// NewDispatcher 根据配置创建通知分发器
+func NewDispatcher(cfg config.NotifyConfig) (*Dispatcher, error) {
+	notifiers := make([]Notifier, 0, len(cfg.Channels))
+	rateLimits := make([]int, 0, len(cfg.Channels))
+	for _, channelCfg := range cfg.Channels {
+		notifier, err := New(channelCfg)
+		if err != nil {
+			return nil, fmt.Errorf("通知渠道 %s: %w", channelCfg.Name, err)
+		}
+		notifiers = append(notifiers, notifier)
+		rateLimits = append(rateLimits, channelCfg.RateLimit)
+	}
+	return NewDispatcherWithNotifiers(notifiers, rateLimits, cfg.Rules, cfg.Templates)
+}
+
+// NewDispatcherWithNotifiers 使用已创建的通知渠道创建分发器，rateLimits与notifiers一一对应（0表示默认值）
+func NewDispatcherWithNotifiers(notifiers []Notifier, rateLimits []int, rules []config.NotifyRule, templates map[string]string) (*Dispatcher, error) {
+	d := &Dispatcher{templates: make(map[string]*template.Template)}
+	knownTypes := toSet(events.Types)
+
+	byName := make(map[string]*channel, len(notifiers))
+	for i, notifier := range notifiers {
+		limit := DefaultRateLimit
+		if i < len(rateLimits) && rateLimits[i] > 0 {
+			limit = rateLimits[i]
+		}
+		ch := &channel{
+			notifier: notifier,
+			limiter:  newRateLimiter(limit, rateWindow),
+			queue:    make(chan Message, queueSize),
+			critical: make(chan Message, criticalQueueSize),
+		}
+		d.channels = append(d.channels, ch)
+		byName[notifier.Name()] = ch
+	}
+
+	// 未配置规则时所有事件发送到所有渠道
+	if len(rules) == 0 && len(d.channels) > 0 {
+		d.routes = append(d.routes, &route{channels: d.channels})
+	}
+	for i, rule := range rules {
+		r := &route{
+			events:   toSet(rule.Events),
+			accounts: toSet(rule.Accounts),
+			symbols:  toSet(rule.Symbols),
+		}
+		for _, eventType := range rule.Events {
+			if !knownTypes[eventType] {
+				return nil, fmt.Errorf("路由规则 %d: 未知的事件类型 %s", i, eventType)
+			}
+		}
+		for _, name := range rule.Channels {
+			ch, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("路由规则 %d: 通知渠道 %s 不存在", i, name)
+			}
+			r.channels = append(r.channels, ch)
+		}
+		d.routes = append(d.routes, r)
+	}
+
+	d.templates["default"] = template.Must(template.New("default").Funcs(templateFuncs).Parse(defaultTemplate))
+	for name, text := range templates {
+		if name != "default" && !knownTypes[name] {
+			return nil, fmt.Errorf("消息模板 %s: 未知的事件类型", name)
+		}
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("消息模板 %s 解析失败: %w", name, err)
+		}
+		d.templates[name] = tmpl
+	}
+	return d, nil
+}
+
+// toSet 列表转为集合
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// Run 订阅事件总线并启动各渠道的发送协程，直到ctx取消
+func (d *Dispatcher) Run(ctx context.Context, bus *events.Bus) {
+	if len(d.channels) == 0 || bus == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, ch := range d.channels {
+		wg.Add(1)
+		go func(ch *channel) {
+			defer wg.Done()
+			ch.run(ctx)
+		}(ch)
+	}
+
+	sub, _ := bus.Subscribe(nil, 0)
+	defer sub.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case e := <-sub.C:
+			d.Dispatch(e)
+		}
+	}
+}
+
+// Dispatch 把事件发送到匹配的渠道（每个渠道最多一次）
+func (d *Dispatcher) Dispatch(e events.Event) {
+	var targets []*channel
+	seen := make(map[*channel]bool)
+	for _, r := range d.routes {
+		if !r.match(&e) {
+			continue
+		}
+		for _, ch := range r.channels {
+			if !seen[ch] {
+				seen[ch] = true
+				targets = append(targets, ch)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	msg := Message{Title: label(e.Type), Text: d.render(e), Event: e}
+	critical := criticalTypes[e.Type]
+	for _, ch := range targets {
+		if critical {
+			ch.enqueueCritical(msg)
+			continue
+		}
+		select {
+		case ch.queue <- msg:
+		default:
+			logger.Warnf("通知渠道 %s 发送队列已满，丢弃 %s 事件", ch.notifier.Name(), e.Type)
+		}
+	}
+}
+
+// enqueueCritical 告警先进入预留队列，预留队列满时再尝试普通队列，都满时记录错误日志
+func (ch *channel) enqueueCritical(msg Message) {
+	select {
+	case ch.critical <- msg:
+		return
+	default:
+	}
+	select {
+	case ch.queue <- msg:
+	default:
+		logger.Errorf("通知渠道 %s 发送队列已满，丢弃告警 %s: %s", ch.notifier.Name(), msg.Event.Type, msg.Event.Message)
+	}
+}
+
+// render 按事件类型选择模板渲染消息，模板出错时使用事件消息
+func (d *Dispatcher) render(e events.Event) string {
+	tmpl, ok := d.templates[e.Type]
+	if !ok {
+		tmpl = d.templates["default"]
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		logger.Warnf("渲染 %s 消息模板失败: %v", e.Type, err)
+		return fmt.Sprintf("【%s】%s", label(e.Type), e.Message)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// run 发送队列中的消息，超出限流的消息丢弃，并在下一条消息末尾提示丢弃数量
+func (ch *channel) run(ctx context.Context) {
+	for {
+		msg, ok := ch.next(ctx)
+		if !ok {
+			return
+		}
+		allowed, dropped := ch.limiter.allow(criticalTypes[msg.Event.Type])
+		if !allowed {
+			logger.Debugf("通知渠道 %s 超出限流，丢弃 %s 事件", ch.notifier.Name(), msg.Event.Type)
+			continue
+		}
+		if dropped > 0 {
+			msg.Text += fmt.Sprintf("\n（限流期间省略了 %d 条通知）", dropped)
+		}
+		if err := ch.notifier.Send(ctx, msg); err != nil {
+			logger.Warnf("通知渠道 %s 发送 %s 事件失败: %v", ch.notifier.Name(), msg.Event.Type, err)
+		}
+	}
+}
+
+// next 取出下一条待发送的消息，预留队列中的告警优先，ctx取消时返回false
+func (ch *channel) next(ctx context.Context) (Message, bool) {
+	select {
+	case msg := <-ch.critical:
+		return msg, true
+	default:
+	}
+	select {
+	case <-ctx.Done():
+		return Message{}, false
+	case msg := <-ch.critical:
+		return msg, true
+	case msg := <-ch.queue:
+		return msg, true
+	}
+}
+
+// rateLimiter 滑动窗口限流
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	sent    []time.Time
+	dropped int
+	now     func() time.Time
+}
+
+// newRateLimiter 创建限流器：window内最多limit条
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, now: time.Now}
+}
+
+// allow 是否允许发送，允许时返回此前被丢弃的消息数并清零
+// critical 的消息不受限流限制，但仍计入发送数量
+func (r *rateLimiter) allow(critical bool) (bool, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	expired := 0
+	for expired < len(r.sent) && now.Sub(r.sent[expired]) >= r.window {
+		expired++
+	}
+	r.sent = r.sent[expired:]
+
+	if len(r.sent) >= r.limit && !critical {
+		r.dropped++
+		return false, 0
+	}
+	r.sent = append(r.sent, now)
+	dropped := r.dropped
+	r.dropped = 0
+	return true, dropped
+}
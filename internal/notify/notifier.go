@@ -0,0 +1,276 @@
+// Package notify 把事件总线上的事件按规则推送到Telegram、Slack、钉钉、飞书和通用Webhook
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+)
+
+// DefaultRequestTimeout 默认的单次推送请求超时
+const DefaultRequestTimeout = 10 * time.Second
+
+// defaultTelegramURL Telegram Bot API地址
+const defaultTelegramURL = "https://api.telegram.org"
+
+// telegramMaxLength Telegram单条消息最大长度
+const telegramMaxLength = 4096
+
+// Message 通知消息
+type Message struct {
+	Title string       // 标题（事件类型说明）
+	Text  string       // 按模板渲染后的正文
+	Event events.Event // 原始事件
+}
+
+// Notifier 通知渠道
+type Notifier interface {
+	// Name 渠道名称
+	Name() string
+	// Send 发送一条消息
+	Send(ctx context.Context, msg Message) error
+}
+
+// New 根据渠道配置创建通知渠道
+func New(cfg config.NotifyChannelConfig) (Notifier, error) {
+	timeout := cfg.RequestTimeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	base := baseNotifier{name: cfg.Name, client: &http.Client{Timeout: timeout}}
+
+	switch cfg.Type {
+	case config.NotifyTelegram:
+		apiURL := cfg.URL
+		if apiURL == "" {
+			apiURL = defaultTelegramURL
+		}
+		return &Telegram{baseNotifier: base, apiURL: strings.TrimRight(apiURL, "/"), botToken: cfg.BotToken, chatID: cfg.ChatID}, nil
+	case config.NotifySlack:
+		return &Slack{baseNotifier: base, url: cfg.URL}, nil
+	case config.NotifyDingTalk:
+		return &DingTalk{baseNotifier: base, url: cfg.URL, secret: cfg.Secret}, nil
+	case config.NotifyFeishu:
+		return &Feishu{baseNotifier: base, url: cfg.URL, secret: cfg.Secret}, nil
+	case config.NotifyWebhook:
+		return &Webhook{baseNotifier: base, url: cfg.URL, headers: cfg.Headers}, nil
+	default:
+		return nil, fmt.Errorf("不支持的通知渠道类型: %s", cfg.Type)
+	}
+}
+
+// baseNotifier 各渠道共用的名称和HTTP客户端
+type baseNotifier struct {
+	name   string
+	client *http.Client
+}
+
+// Name 渠道名称
+func (b *baseNotifier) Name() string {
+	return b.name
+}
+
+// postJSON 发送JSON请求，非2xx状态码返回错误，成功时返回响应体
+func (b *baseNotifier) postJSON(ctx context.Context, requestURL string, headers map[string]string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化消息失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// url.Error会带上完整URL，其中可能有Telegram bot token、webhook access_token等密钥
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// Telegram 通过Bot API发送消息
+type Telegram struct {
+	baseNotifier
+	apiURL   string
+	botToken string
+	chatID   string
+}
+
+// Send 发送消息（纯文本，超长时截断）
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	text := msg.Text
+	if runes := []rune(text); len(runes) > telegramMaxLength {
+		text = string(runes[:telegramMaxLength-3]) + "..."
+	}
+	body, err := t.postJSON(ctx, fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.botToken), nil, map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram返回错误: %s", result.Description)
+	}
+	return nil
+}
+
+// Slack 通过Incoming Webhook发送消息
+type Slack struct {
+	baseNotifier
+	url string
+}
+
+// Send 发送消息
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	_, err := s.postJSON(ctx, s.url, nil, map[string]string{"text": msg.Text})
+	return err
+}
+
+// DingTalk 钉钉自定义机器人，配置secret时使用加签
+type DingTalk struct {
+	baseNotifier
+	url    string
+	secret string
+}
+
+// Send 发送文本消息
+func (d *DingTalk) Send(ctx context.Context, msg Message) error {
+	requestURL := d.url
+	if d.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		separator := "?"
+		if strings.Contains(requestURL, "?") {
+			separator = "&"
+		}
+		requestURL += separator + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(DingTalkSign(timestamp, d.secret))
+	}
+
+	body, err := d.postJSON(ctx, requestURL, nil, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": msg.Text},
+	})
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("钉钉返回错误 %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// DingTalkSign 钉钉加签：HmacSHA256(secret, timestamp+"\n"+secret)的Base64
+func DingTalkSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Feishu 飞书自定义机器人，配置secret时使用签名校验
+type Feishu struct {
+	baseNotifier
+	url    string
+	secret string
+}
+
+// Send 发送文本消息
+func (f *Feishu) Send(ctx context.Context, msg Message) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": msg.Text},
+	}
+	if f.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		payload["timestamp"] = timestamp
+		payload["sign"] = FeishuSign(timestamp, f.secret)
+	}
+
+	body, err := f.postJSON(ctx, f.url, nil, payload)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("飞书返回错误 %d: %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// FeishuSign 飞书签名：以timestamp+"\n"+secret为密钥对空字符串做HmacSHA256，再Base64
+func FeishuSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Webhook 通用Webhook，POST JSON: {"title": ..., "text": ..., "event": {...}}
+type Webhook struct {
+	baseNotifier
+	url     string
+	headers map[string]string
+}
+
+// Send 发送消息
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	_, err := w.postJSON(ctx, w.url, w.headers, map[string]interface{}{
+		"title": msg.Title,
+		"text":  msg.Text,
+		"event": msg.Event,
+	})
+	return err
+}
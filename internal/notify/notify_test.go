@@ -0,0 +1,363 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+)
+
+// receivedRequest 本地webhook收到的请求
+type receivedRequest struct {
+	Path   string
+	Query  map[string][]string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+// newReceiver 启动本地webhook接收端，返回固定的响应体
+func newReceiver(t *testing.T, response string) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var received []receivedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("请求体不是JSON: %s", data)
+		}
+		mu.Lock()
+		received = append(received, receivedRequest{Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: body})
+		mu.Unlock()
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedRequest(nil), received...)
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	ctx := context.Background()
+	msg := Message{Title: "成交", Text: "ABCUSDT 已成交", Event: events.Event{Type: events.TypeOrderFilled, Symbol: "ABCUSDT"}}
+
+	t.Run("telegram", func(t *testing.T) {
+		server, received := newReceiver(t, `{"ok":true}`)
+		n, err := New(config.NotifyChannelConfig{Name: "tg", Type: config.NotifyTelegram, URL: server.URL, BotToken: "123:abc", ChatID: "-100"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Send(ctx, msg); err != nil {
+			t.Fatalf("发送失败: %v", err)
+		}
+		req := received()[0]
+		if req.Path != "/bot123:abc/sendMessage" || req.Body["chat_id"] != "-100" || req.Body["text"] != msg.Text {
+			t.Errorf("Telegram请求不正确: %+v", req)
+		}
+	})
+
+	t.Run("telegram错误", func(t *testing.T) {
+		server, _ := newReceiver(t, `{"ok":false,"description":"chat not found"}`)
+		n, _ := New(config.NotifyChannelConfig{Name: "tg", Type: config.NotifyTelegram, URL: server.URL, BotToken: "t", ChatID: "1"})
+		if err := n.Send(ctx, msg); err == nil || !strings.Contains(err.Error(), "chat not found") {
+			t.Errorf("应返回Telegram错误: %v", err)
+		}
+	})
+
+	t.Run("telegram请求失败不泄露token", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+		n, _ := New(config.NotifyChannelConfig{Name: "tg", Type: config.NotifyTelegram, URL: server.URL, BotToken: "123:secret", ChatID: "1"})
+		err := n.Send(ctx, msg)
+		if err == nil {
+			t.Fatal("接收方不可用时应返回错误")
+		}
+		if strings.Contains(err.Error(), "123:secret") {
+			t.Errorf("错误信息不应包含bot token: %v", err)
+		}
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		server, received := newReceiver(t, `ok`)
+		n, _ := New(config.NotifyChannelConfig{Name: "slack", Type: config.NotifySlack, URL: server.URL + "/services/x"})
+		if err := n.Send(ctx, msg); err != nil {
+			t.Fatalf("发送失败: %v", err)
+		}
+		if req := received()[0]; req.Path != "/services/x" || req.Body["text"] != msg.Text {
+			t.Errorf("Slack请求不正确: %+v", req)
+		}
+	})
+
+	t.Run("dingtalk加签", func(t *testing.T) {
+		server, received := newReceiver(t, `{"errcode":0,"errmsg":"ok"}`)
+		n, _ := New(config.NotifyChannelConfig{Name: "ding", Type: config.NotifyDingTalk, URL: server.URL + "/robot/send?access_token=tk", Secret: "SEC123"})
+		if err := n.Send(ctx, msg); err != nil {
+			t.Fatalf("发送失败: %v", err)
+		}
+		req := received()[0]
+		timestamp := req.Query["timestamp"]
+		if len(timestamp) != 1 || req.Query["access_token"][0] != "tk" {
+			t.Fatalf("钉钉请求参数不正确: %+v", req.Query)
+		}
+		if sign := req.Query["sign"]; len(sign) != 1 || sign[0] != DingTalkSign(timestamp[0], "SEC123") {
+			t.Errorf("钉钉签名不正确: %v", req.Query["sign"])
+		}
+		if text, _ := req.Body["text"].(map[string]interface{}); req.Body["msgtype"] != "text" || text["content"] != msg.Text {
+			t.Errorf("钉钉消息不正确: %+v", req.Body)
+		}
+	})
+
+	t.Run("dingtalk错误", func(t *testing.T) {
+		server, _ := newReceiver(t, `{"errcode":310000,"errmsg":"sign not match"}`)
+		n, _ := New(config.NotifyChannelConfig{Name: "ding", Type: config.NotifyDingTalk, URL: server.URL})
+		if err := n.Send(ctx, msg); err == nil || !strings.Contains(err.Error(), "310000") {
+			t.Errorf("应返回钉钉错误: %v", err)
+		}
+	})
+
+	t.Run("feishu签名", func(t *testing.T) {
+		server, received := newReceiver(t, `{"code":0,"msg":"success"}`)
+		n, _ := New(config.NotifyChannelConfig{Name: "feishu", Type: config.NotifyFeishu, URL: server.URL, Secret: "fs"})
+		if err := n.Send(ctx, msg); err != nil {
+			t.Fatalf("发送失败: %v", err)
+		}
+		req := received()[0]
+		timestamp, _ := req.Body["timestamp"].(string)
+		if timestamp == "" || req.Body["sign"] != FeishuSign(timestamp, "fs") {
+			t.Errorf("飞书签名不正确: %+v", req.Body)
+		}
+		if content, _ := req.Body["content"].(map[string]interface{}); req.Body["msg_type"] != "text" || content["text"] != msg.Text {
+			t.Errorf("飞书消息不正确: %+v", req.Body)
+		}
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		server, received := newReceiver(t, ``)
+		n, _ := New(config.NotifyChannelConfig{Name: "hook", Type: config.NotifyWebhook, URL: server.URL, Headers: map[string]string{"X-Token": "abc"}})
+		if err := n.Send(ctx, msg); err != nil {
+			t.Fatalf("发送失败: %v", err)
+		}
+		req := received()[0]
+		event, _ := req.Body["event"].(map[string]interface{})
+		if req.Header.Get("X-Token") != "abc" || req.Body["title"] != msg.Title || event["symbol"] != "ABCUSDT" {
+			t.Errorf("Webhook请求不正确: %+v", req)
+		}
+	})
+
+	t.Run("HTTP错误", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad", http.StatusBadRequest)
+		}))
+		defer server.Close()
+		n, _ := New(config.NotifyChannelConfig{Name: "hook", Type: config.NotifyWebhook, URL: server.URL})
+		if err := n.Send(ctx, msg); err == nil || !strings.Contains(err.Error(), "400") {
+			t.Errorf("应返回HTTP错误: %v", err)
+		}
+	})
+}
+
+func TestSigns(t *testing.T) {
+	// 与钉钉/飞书文档中的算法一致（固定输入，防止实现被误改）
+	if got := DingTalkSign("1600000000000", "secret"); got != "XHSnLTbboLLBCrXfAQRHx6W9LkLB43RYwgcsOS2j3vs=" {
+		t.Errorf("钉钉签名不正确: %s", got)
+	}
+	if got := FeishuSign("1600000000", "secret"); got != "vvU1S4ucHy95pQ90meMW66yQJ+Szge4s9g7hQUu9yP8=" {
+		t.Errorf("飞书签名不正确: %s", got)
+	}
+}
+
+// fakeNotifier 只提供渠道名称，消息从渠道队列中检查
+type fakeNotifier struct {
+	name string
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+
+func (f *fakeNotifier) Send(ctx context.Context, msg Message) error { return nil }
+
+// queued 取出渠道队列中的消息
+func queued(d *Dispatcher, name string) []Message {
+	var messages []Message
+	for _, ch := range d.channels {
+		if ch.notifier.Name() != name {
+			continue
+		}
+		for len(ch.critical) > 0 {
+			messages = append(messages, <-ch.critical)
+		}
+		for len(ch.queue) > 0 {
+			messages = append(messages, <-ch.queue)
+		}
+	}
+	return messages
+}
+
+func TestDispatcherRouting(t *testing.T) {
+	ops, alerts := &fakeNotifier{name: "ops"}, &fakeNotifier{name: "alerts"}
+	rules := []config.NotifyRule{
+		{Events: []string{events.TypeBracketFailed, events.TypeRiskLimitBreached}, Channels: []string{"alerts", "ops"}},
+		{Accounts: []string{"main"}, Symbols: []string{"ABCUSDT"}, Channels: []string{"ops"}},
+	}
+	d, err := NewDispatcherWithNotifiers([]Notifier{ops, alerts}, nil, rules, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Dispatch(events.Event{Type: events.TypeBracketFailed, Account: "main", Symbol: "ABCUSDT"})
+	d.Dispatch(events.Event{Type: events.TypeOrderFilled, Account: "main", Symbol: "ABCUSDT"})
+	d.Dispatch(events.Event{Type: events.TypeOrderFilled, Account: "sub", Symbol: "ABCUSDT"})
+	d.Dispatch(events.Event{Type: events.TypeOrderFilled, Account: "main", Symbol: "XYZUSDT"})
+
+	if got := queued(d, "alerts"); len(got) != 1 || got[0].Event.Type != events.TypeBracketFailed {
+		t.Errorf("alerts渠道应只收到bracket_failed: %+v", got)
+	}
+	// 同一事件匹配多条规则时每个渠道只发送一次
+	if got := queued(d, "ops"); len(got) != 2 || got[0].Event.Type != events.TypeBracketFailed || got[1].Event.Type != events.TypeOrderFilled {
+		t.Errorf("ops渠道收到的事件不正确: %+v", got)
+	}
+
+	if _, err := NewDispatcherWithNotifiers([]Notifier{ops}, nil, []config.NotifyRule{{Channels: []string{"missing"}}}, nil); err == nil {
+		t.Errorf("引用不存在的渠道应返回错误")
+	}
+	if _, err := NewDispatcherWithNotifiers([]Notifier{ops}, nil, []config.NotifyRule{{Events: []string{"unknown"}, Channels: []string{"ops"}}}, nil); err == nil {
+		t.Errorf("未知事件类型应返回错误")
+	}
+
+	// 未配置规则时所有事件发送到所有渠道
+	d, _ = NewDispatcherWithNotifiers([]Notifier{ops, alerts}, nil, nil, nil)
+	d.Dispatch(events.Event{Type: events.TypeListingDiscovered})
+	if len(queued(d, "ops")) != 1 || len(queued(d, "alerts")) != 1 {
+		t.Errorf("未配置规则时应发送到所有渠道")
+	}
+}
+
+func TestDispatcherQueueFull(t *testing.T) {
+	d, _ := NewDispatcherWithNotifiers([]Notifier{&fakeNotifier{name: "ops"}}, nil, nil, nil)
+	for i := 0; i < queueSize+10; i++ {
+		d.Dispatch(events.Event{Type: events.TypeOrderFilled})
+	}
+	d.Dispatch(events.Event{Type: events.TypeBracketFailed})
+
+	got := queued(d, "ops")
+	if len(got) != queueSize+1 || got[0].Event.Type != events.TypeBracketFailed {
+		t.Errorf("普通队列已满时告警仍应入队并优先发送，实际: %d 条", len(got))
+	}
+}
+
+func TestDispatcherTemplates(t *testing.T) {
+	templates := map[string]string{
+		events.TypePositionClosed: `{{.Symbol}} 平仓 盈亏 {{printf "%.2f" .Data.PnL}}`,
+		events.TypeOrderFilled:    `{{.Data.Missing}}`,
+	}
+	d, err := NewDispatcherWithNotifiers([]Notifier{&fakeNotifier{name: "ops"}}, nil, nil, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closed := struct{ PnL float64 }{PnL: 12.345}
+	d.Dispatch(events.Event{Type: events.TypePositionClosed, Symbol: "ABCUSDT", Data: closed})
+	d.Dispatch(events.Event{Type: events.TypeListingDiscovered, Account: "main", Message: "发现 ABCUSDT", Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)})
+	d.Dispatch(events.Event{Type: events.TypeOrderFilled, Message: "ABCUSDT 已成交", Data: closed})
+
+	got := queued(d, "ops")
+	if len(got) != 3 {
+		t.Fatalf("消息数量不正确: %d", len(got))
+	}
+	if got[0].Text != "ABCUSDT 平仓 盈亏 12.35" {
+		t.Errorf("自定义模板渲染不正确: %q", got[0].Text)
+	}
+	if got[1].Title != "发现新币" || got[1].Text != "【发现新币】[main] 发现 ABCUSDT\n2024-01-02 03:04:05" {
+		t.Errorf("默认模板渲染不正确: %+v", got[1])
+	}
+	// 模板执行出错时退回事件消息
+	if got[2].Text != "【成交】ABCUSDT 已成交" {
+		t.Errorf("模板出错时应使用事件消息: %q", got[2].Text)
+	}
+
+	if _, err := NewDispatcherWithNotifiers(nil, nil, nil, map[string]string{events.TypeError: "{{.Symbol"}); err == nil {
+		t.Errorf("模板语法错误应返回错误")
+	}
+	if _, err := NewDispatcherWithNotifiers(nil, nil, nil, map[string]string{"unknown": "x"}); err == nil {
+		t.Errorf("未知事件类型的模板应返回错误")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := limiter.allow(false); !allowed {
+			t.Fatalf("第%d条应允许发送", i+1)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limiter.allow(false); allowed {
+			t.Fatalf("超出限流应丢弃")
+		}
+	}
+
+	now = now.Add(time.Minute)
+	allowed, dropped := limiter.allow(false)
+	if !allowed || dropped != 3 {
+		t.Errorf("窗口过后应允许发送并返回丢弃数量: %v %d", allowed, dropped)
+	}
+	if _, dropped := limiter.allow(false); dropped != 0 {
+		t.Errorf("丢弃数量应清零: %d", dropped)
+	}
+
+	// 需要人工介入的告警不受限流限制
+	if allowed, _ := limiter.allow(false); allowed {
+		t.Fatalf("超出限流应丢弃")
+	}
+	allowed, dropped = limiter.allow(true)
+	if !allowed || dropped != 1 {
+		t.Errorf("关键告警超出限流也应发送并返回丢弃数量: %v %d", allowed, dropped)
+	}
+}
+
+func TestDispatcherRun(t *testing.T) {
+	server, received := newReceiver(t, ``)
+	d, err := NewDispatcher(config.NotifyConfig{
+		Channels: []config.NotifyChannelConfig{{Name: "hook", Type: config.NotifyWebhook, URL: server.URL, RateLimit: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx, bus)
+		close(done)
+	}()
+
+	// 等待订阅完成
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		bus.Publish(events.Event{Type: events.TypeOrderFilled, Symbol: "ABCUSDT"})
+		if len(received()) > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// 每分钟限1条，其余被丢弃
+	if got := received(); len(got) != 1 || got[0].Body["title"] != "成交" {
+		t.Errorf("webhook收到的消息不正确: %+v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+)
+
+// Account 币安账户（主账户或子账户）
+type Account struct {
+	Name               string
+	Exchange           exchange.Exchange
+	NotionalMultiplier float64 // 下单金额倍数
+}
+
+// newAccount 根据账户配置创建账户
+func newAccount(cfg config.AccountConfig) *Account {
+	multiplier := cfg.NotionalMultiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	return &Account{
+		Name:               cfg.Name,
+		Exchange:           exchange.NewBinance(cfg.BinanceConfig()),
+		NotionalMultiplier: multiplier,
+	}
+}
+
+// AccountOrderSet 单个账户的下单结果
+type AccountOrderSet struct {
+	Account  string
+	OrderSet *OrderSet
+	Err      error
+}
+
+// AccountNames 返回所有账户名称（按配置顺序）
+func (ts *TradingService) AccountNames() []string {
+	names := make([]string, 0, len(ts.accounts))
+	for _, acc := range ts.accounts {
+		names = append(names, acc.Name)
+	}
+	return names
+}
+
+// HasAccount 判断账户是否存在，name留空视为所有账户
+func (ts *TradingService) HasAccount(name string) bool {
+	_, err := ts.selectAccounts(name)
+	return err == nil
+}
+
+// selectAccounts 按名称选择账户，name留空返回所有账户
+func (ts *TradingService) selectAccounts(name string) ([]*Account, error) {
+	if name == "" {
+		return ts.accounts, nil
+	}
+	for _, acc := range ts.accounts {
+		if acc.Name == name {
+			return []*Account{acc}, nil
+		}
+	}
+	return nil, fmt.Errorf("账户 %s 不存在或未启用", name)
+}
+
+// CreateOrdersForAccounts 在多个币安账户上按策略并行执行开仓+止损+止盈流程
+// notionalUSDT: 留空使用策略中的金额；每个账户的下单金额为 notionalUSDT * 账户倍数
+// names: 账户名称列表，留空表示所有已启用账户
+func (ts *TradingService) CreateOrdersForAccounts(strategy *Strategy, notionalUSDT string, names []string) ([]AccountOrderSet, error) {
+	accounts := ts.accounts
+	if len(names) > 0 {
+		accounts = make([]*Account, 0, len(names))
+		for _, name := range names {
+			selected, err := ts.selectAccounts(name)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, selected...)
+		}
+	}
+
+	if notionalUSDT == "" {
+		notionalUSDT = strategy.Notional
+	}
+
+	// 各账户在同一盘口开仓，按合计金额检查一次流动性
+	prechecks := ts.checkAccountsLiquidity(accounts, strategy, notionalUSDT)
+
+	results := make([]AccountOrderSet, len(accounts))
+	var wg sync.WaitGroup
+	for i, acc := range accounts {
+		wg.Add(1)
+		go func(i int, acc *Account) {
+			defer wg.Done()
+			results[i] = ts.createAccountOrderSet(acc, strategy, notionalUSDT, prechecks[i])
+		}(i, acc)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// createAccountOrderSet 在单个账户上下单，金额按账户倍数调整
+func (ts *TradingService) createAccountOrderSet(acc *Account, strategy *Strategy, notionalUSDT string, precheck *entryPrecheck) AccountOrderSet {
+	result := AccountOrderSet{Account: acc.Name}
+
+	notional, err := scaleNotional(notionalUSDT, acc.NotionalMultiplier)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	logger.Infof("账户 %s 开始下单: %s, USDT金额: %s (倍数: %g)", acc.Name, strategy.Symbol, notional, acc.NotionalMultiplier)
+	orderSet, err := ts.createOrderSet(acc.Exchange, acc.Name, strategy, notional, precheck)
+	if err != nil {
+		logger.Errorf("账户 %s 下单失败: %v", acc.Name, err)
+		result.Err = err
+		return result
+	}
+	result.OrderSet = orderSet
+	return result
+}
+
+// scaleNotional 按倍数调整USDT金额
+func scaleNotional(notionalUSDT string, multiplier float64) (string, error) {
+	if multiplier == 1 {
+		return notionalUSDT, nil
+	}
+	notional, err := strconv.ParseFloat(notionalUSDT, 64)
+	if err != nil {
+		return "", fmt.Errorf("无效的USDT金额: %s", notionalUSDT)
+	}
+	return strconv.FormatFloat(notional*multiplier, 'f', -1, 64), nil
+}
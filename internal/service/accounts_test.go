@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"new_listing_trade/internal/config"
+)
+
+func TestBinanceAccounts(t *testing.T) {
+	cfg := &config.Config{
+		Binance: config.BinanceConfig{APIKey: "k", SecretKey: "s", APIType: "papi"},
+		Accounts: []config.AccountConfig{
+			{Name: "sub1", Enabled: true, APIKey: "k1", SecretKey: "s1", NotionalMultiplier: 0.5},
+			{Name: "sub2", Enabled: false, APIKey: "k2", SecretKey: "s2"},
+			{Name: "sub3", Enabled: true},
+		},
+	}
+
+	ts, err := NewTradingService(cfg)
+	if err != nil {
+		t.Fatalf("创建交易服务失败: %v", err)
+	}
+	names := ts.AccountNames()
+	if len(names) != 2 || names[0] != config.DefaultAccountName || names[1] != "sub1" {
+		t.Fatalf("账户列表不正确: %v", names)
+	}
+	if ts.accounts[1].NotionalMultiplier != 0.5 || ts.accounts[0].NotionalMultiplier != 1 {
+		t.Errorf("账户倍数不正确: %v %v", ts.accounts[0].NotionalMultiplier, ts.accounts[1].NotionalMultiplier)
+	}
+	if !ts.HasAccount("") || !ts.HasAccount("sub1") || ts.HasAccount("sub2") {
+		t.Error("账户筛选不正确")
+	}
+	if _, err := ts.CreateOrdersForAccounts(ts.ResolveStrategy("BTCUSDT", 0), "10", []string{"sub2"}); err == nil {
+		t.Error("指定未启用的账户应返回错误")
+	}
+}
+
+func TestScaleNotional(t *testing.T) {
+	cases := []struct {
+		notional   string
+		multiplier float64
+		want       string
+	}{
+		{"10", 1, "10"},
+		{"10", 0.5, "5"},
+		{"100", 2.5, "250"},
+	}
+	for _, c := range cases {
+		got, err := scaleNotional(c.notional, c.multiplier)
+		if err != nil || got != c.want {
+			t.Errorf("scaleNotional(%s, %g) = %s, %v, 期望 %s", c.notional, c.multiplier, got, err, c.want)
+		}
+	}
+	if _, err := scaleNotional("abc", 2); err == nil {
+		t.Error("无效金额应返回错误")
+	}
+}
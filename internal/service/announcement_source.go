@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+var (
+	// 公告标题中的USDT永续合约币对，例如 "Binance Futures Will Launch USDⓈ-Margined ZORAUSDT Perpetual Contract"
+	announcementSymbolPattern = regexp.MustCompile(`\b([A-Z0-9]{1,20}USDT)\b`)
+	// 公告中的上线时间，例如 "at 2025-07-15 11:30 (UTC)"
+	announcementTimePattern = regexp.MustCompile(`(\d{4}-\d{2}-\d{2} \d{2}:\d{2}) \(UTC\)`)
+)
+
+// AnnouncementSource 币安公告来源（JSON接口或RSS）
+type AnnouncementSource struct {
+	url        string
+	format     string
+	interval   time.Duration
+	httpClient *http.Client
+	seen       map[string]bool // 已上报的币对，避免重复推送
+}
+
+// NewAnnouncementSource 根据配置创建公告来源
+func NewAnnouncementSource(cfg config.AnnouncementSourceConfig) *AnnouncementSource {
+	format := strings.ToLower(cfg.Format)
+	if format == "" {
+		format = "json"
+	}
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &AnnouncementSource{
+		url:      cfg.URL,
+		format:   format,
+		interval: interval,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		seen: make(map[string]bool),
+	}
+}
+
+// Name 来源名称
+func (as *AnnouncementSource) Name() string {
+	return SourceAnnouncement
+}
+
+// Run 定时拉取公告，发现新的合约币对时交给sink
+func (as *AnnouncementSource) Run(ctx context.Context, sink ListingSink) error {
+	ticker := time.NewTicker(as.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := as.poll(sink); err != nil {
+			logger.Errorf("拉取公告失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll 拉取并解析一次公告
+func (as *AnnouncementSource) poll(sink ListingSink) error {
+	resp, err := as.httpClient.Get(as.url)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("公告接口返回错误状态码 %d", resp.StatusCode)
+	}
+
+	var listings []*models.NewListingSymbol
+	if as.format == "rss" {
+		listings, err = ParseAnnouncementRSS(body)
+	} else {
+		listings, err = ParseAnnouncementJSON(body)
+	}
+	if err != nil {
+		return err
+	}
+
+	var fresh []*models.NewListingSymbol
+	for _, listing := range listings {
+		if as.seen[listing.Symbol] {
+			continue
+		}
+		as.seen[listing.Symbol] = true
+		fresh = append(fresh, listing)
+	}
+	if len(fresh) > 0 {
+		sink(fresh)
+	}
+	return nil
+}
+
+// announcementArticle 公告条目
+type announcementArticle struct {
+	Title       string `json:"title"`
+	ReleaseDate int64  `json:"releaseDate"`
+}
+
+// announcementListResponse 币安公告列表接口响应（兼容catalogs嵌套和articles平铺两种格式）
+type announcementListResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Catalogs []struct {
+			Articles []announcementArticle `json:"articles"`
+		} `json:"catalogs"`
+		Articles []announcementArticle `json:"articles"`
+	} `json:"data"`
+}
+
+// ParseAnnouncementJSON 解析币安公告列表JSON接口
+func ParseAnnouncementJSON(body []byte) ([]*models.NewListingSymbol, error) {
+	var resp announcementListResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析公告JSON失败: %w", err)
+	}
+
+	articles := resp.Data.Articles
+	for _, catalog := range resp.Data.Catalogs {
+		articles = append(articles, catalog.Articles...)
+	}
+
+	var result []*models.NewListingSymbol
+	for _, article := range articles {
+		result = append(result, parseAnnouncement(article.Title, "", time.UnixMilli(article.ReleaseDate))...)
+	}
+	return result, nil
+}
+
+// announcementRSS RSS格式公告
+type announcementRSS struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// ParseAnnouncementRSS 解析RSS格式的公告
+func ParseAnnouncementRSS(body []byte) ([]*models.NewListingSymbol, error) {
+	var feed announcementRSS
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("解析公告RSS失败: %w", err)
+	}
+
+	var result []*models.NewListingSymbol
+	for _, item := range feed.Channel.Items {
+		published, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil {
+			published, _ = time.Parse(time.RFC1123, item.PubDate)
+		}
+		result = append(result, parseAnnouncement(item.Title, item.Description, published)...)
+	}
+	return result, nil
+}
+
+// parseAnnouncement 从公告标题和正文中提取合约币对
+// 只处理合约相关公告：标题同时包含"Will Launch"和"Perpetual"的置信度为0.9，仅提及合约的为0.6
+func parseAnnouncement(title, description string, published time.Time) []*models.NewListingSymbol {
+	lowerTitle := strings.ToLower(title)
+	if !strings.Contains(lowerTitle, "perpetual") && !strings.Contains(lowerTitle, "futures") {
+		return nil
+	}
+
+	confidence := 0.6
+	if strings.Contains(lowerTitle, "will launch") && strings.Contains(lowerTitle, "perpetual") {
+		confidence = 0.9
+	}
+
+	// 正文或标题中带有UTC上线时间时，作为OnboardDate
+	var onboardDate int64
+	if match := announcementTimePattern.FindStringSubmatch(title + " " + description); match != nil {
+		if t, err := time.Parse("2006-01-02 15:04", match[1]); err == nil {
+			onboardDate = t.UnixMilli()
+		}
+	}
+
+	foundTime := time.Now()
+	if !published.IsZero() && published.Unix() > 0 {
+		foundTime = published
+	}
+
+	var result []*models.NewListingSymbol
+	seen := make(map[string]bool)
+	for _, match := range announcementSymbolPattern.FindAllStringSubmatch(title, -1) {
+		symbol := match[1]
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		result = append(result, &models.NewListingSymbol{
+			Symbol:      symbol,
+			OnboardDate: onboardDate,
+			Status:      "ANNOUNCED",
+			FoundTime:   foundTime,
+			Source:      SourceAnnouncement,
+			Confidence:  confidence,
+		})
+	}
+	return result
+}
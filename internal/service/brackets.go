@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// DefaultBracketPollInterval 默认的止盈止损单状态轮询间隔
+const DefaultBracketPollInterval = 10 * time.Second
+
+// maxBracketQueryFailures 连续查询失败多少次后停止跟踪（例如交易所不支持查询条件单）
+const maxBracketQueryFailures = 5
+
+// 订单终态
+var terminalOrderStatuses = map[string]bool{
+	"FILLED":           true,
+	"CANCELED":         true,
+	"CANCELLED":        true,
+	"EXPIRED":          true,
+	"REJECTED":         true,
+	"FINISHED":         true, // papi条件单
+	"EXPIRED_IN_MATCH": true,
+}
+
+// trackedBracket 跟踪中的开仓单及其止盈止损单
+type trackedBracket struct {
+	ex       exchange.Exchange
+	querier  exchange.OrderQuerier
+	orderSet *OrderSet
+	failures int
+	filled   map[int64]bool // 已成交的分档止盈单
+}
+
+// BracketMonitor 轮询止盈止损单状态，触发时发布 stop_loss_triggered / take_profit_triggered 事件
+type BracketMonitor struct {
+	mu       sync.Mutex
+	brackets map[string]*trackedBracket // key为 交易所/账户/币对/开仓单ID
+	bus      *events.Bus
+}
+
+// NewBracketMonitor 创建止盈止损监控
+func NewBracketMonitor(bus *events.Bus) *BracketMonitor {
+	return &BracketMonitor{
+		brackets: make(map[string]*trackedBracket),
+		bus:      bus,
+	}
+}
+
+// bracketKey 跟踪key
+func bracketKey(orderSet *OrderSet) string {
+	return fmt.Sprintf("%s/%s/%s/%d", orderSet.Exchange, orderSet.Account, orderSet.Symbol, orderSet.SellOrder.OrderID)
+}
+
+// Track 开始跟踪开仓单的止盈止损单，交易所不支持查询订单时忽略
+func (bm *BracketMonitor) Track(ex exchange.Exchange, orderSet *OrderSet) {
+	if orderSet.SellOrder == nil || (orderSet.StopLossOrder == nil && orderSet.TakeProfitOrder == nil && len(orderSet.TakeProfitOrders) == 0) {
+		return
+	}
+	querier, ok := ex.(exchange.OrderQuerier)
+	if !ok {
+		logger.Debugf("交易所 %s 不支持查询订单，不跟踪 %s 的止盈止损单", ex.Name(), orderSet.Symbol)
+		return
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.brackets[bracketKey(orderSet)] = &trackedBracket{ex: ex, querier: querier, orderSet: orderSet, filled: make(map[int64]bool)}
+}
+
+// Untrack 停止跟踪某个账户某个币对的止盈止损单（未记录账户的开仓单也会移除）
+func (bm *BracketMonitor) Untrack(account, symbol string) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	for key, b := range bm.brackets {
+		if b.orderSet.Symbol == symbol && (b.orderSet.Account == account || b.orderSet.Account == "") {
+			delete(bm.brackets, key)
+		}
+	}
+}
+
+// ReplaceStopLoss 止损单被撤销重建后，改为跟踪新的止损单
+func (bm *BracketMonitor) ReplaceStopLoss(account, symbol string, oldOrderID int64, newOrder *models.OrderResponse) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	for key, b := range bm.brackets {
+		orderSet := b.orderSet
+		if orderSet.Symbol != symbol || (orderSet.Account != account && orderSet.Account != "") ||
+			orderSet.StopLossOrder == nil || orderSet.StopLossOrder.OrderID != oldOrderID {
+			continue
+		}
+		// 复制后整体替换，检查中的快照不受影响
+		updated := *orderSet
+		updated.StopLossOrder = newOrder
+		bm.brackets[key] = &trackedBracket{ex: b.ex, querier: b.querier, orderSet: &updated, failures: b.failures, filled: b.filled}
+	}
+}
+
+// Tracked 返回跟踪中的开仓单
+func (bm *BracketMonitor) Tracked() []*OrderSet {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	orderSets := make([]*OrderSet, 0, len(bm.brackets))
+	for _, b := range bm.brackets {
+		orderSets = append(orderSets, b.orderSet)
+	}
+	return orderSets
+}
+
+// Run 定时检查止盈止损单状态，直到ctx取消
+func (bm *BracketMonitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultBracketPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			bm.check()
+		}
+	}
+}
+
+// check 检查所有跟踪中的止盈止损单
+func (bm *BracketMonitor) check() {
+	bm.mu.Lock()
+	snapshot := make(map[string]*trackedBracket, len(bm.brackets))
+	for key, b := range bm.brackets {
+		snapshot[key] = b
+	}
+	bm.mu.Unlock()
+
+	for key, b := range snapshot {
+		if done := bm.checkBracket(b); done {
+			bm.mu.Lock()
+			delete(bm.brackets, key)
+			bm.mu.Unlock()
+		}
+	}
+}
+
+// bracketLeg 开仓单的一条止盈或止损腿
+type bracketLeg struct {
+	order     *models.OrderResponse
+	eventType string
+	label     string
+	reason    string
+	partial   bool // 分档止盈单，成交后仓位只平掉一部分
+}
+
+// checkBracket 检查单个开仓单的止盈止损单，返回是否可以停止跟踪
+func (bm *BracketMonitor) checkBracket(b *trackedBracket) bool {
+	orderSet := b.orderSet
+	legs := []bracketLeg{
+		{orderSet.StopLossOrder, events.TypeStopLossTriggered, "止损", CloseReasonStopLoss, false},
+		{orderSet.TakeProfitOrder, events.TypeTakeProfitTriggered, "止盈", CloseReasonTakeProfit, false},
+	}
+	for i, order := range orderSet.TakeProfitOrders {
+		legs = append(legs, bracketLeg{order, events.TypeTakeProfitTriggered, fmt.Sprintf("第%d档止盈", i+1), CloseReasonTakeProfit, true})
+	}
+
+	open, ladderFilled := 0, 0
+	for _, leg := range legs {
+		if leg.order == nil {
+			continue
+		}
+		if b.filled[leg.order.OrderID] {
+			ladderFilled++
+			continue
+		}
+		order, err := b.querier.QueryOrder(orderSet.Symbol, leg.order.OrderID)
+		if err != nil {
+			b.failures++
+			logger.Debugf("查询%s单失败: %s #%d: %v", leg.label, orderSet.Symbol, leg.order.OrderID, err)
+			if b.failures >= maxBracketQueryFailures {
+				logger.Warnf("连续 %d 次查询 %s 的止盈止损单失败，停止跟踪", b.failures, orderSet.Symbol)
+				return true
+			}
+			open++
+			continue
+		}
+		b.failures = 0
+
+		if order.Status == "FILLED" {
+			logger.Infof("%s单已触发: %s, 账户: %s, 成交均价: %s", leg.label, orderSet.Symbol, orderSet.Account, order.AvgPrice)
+			bm.bus.Publish(events.Event{
+				Type:     leg.eventType,
+				Symbol:   orderSet.Symbol,
+				Exchange: orderSet.Exchange,
+				Account:  orderSet.Account,
+				Message:  fmt.Sprintf("%s %s单已触发，成交均价 %s", orderSet.Symbol, leg.label, order.AvgPrice),
+				Data:     order,
+			})
+			publishPositionClosed(bm.bus, orderSet.Exchange, orderSet.Account, orderSet.Symbol, bracketClosedPosition(orderSet, order, leg.reason))
+			if leg.partial {
+				b.filled[leg.order.OrderID] = true
+				ladderFilled++
+				continue
+			}
+			// 仓位已平掉，另一条腿不再需要跟踪
+			return true
+		}
+		if !terminalOrderStatuses[order.Status] {
+			open++
+		}
+	}
+	// 分档止盈全部成交时仓位已平掉
+	if len(orderSet.TakeProfitOrders) > 0 && ladderFilled == len(orderSet.TakeProfitOrders) {
+		return true
+	}
+	return open == 0
+}
+
+// bracketClosedPosition 按开仓单和触发的止盈止损单成交均价估算盈亏
+func bracketClosedPosition(orderSet *OrderSet, exitOrder *models.OrderResponse, reason string) *ClosedPosition {
+	entryPrice, _ := strconv.ParseFloat(orderSet.SellOrder.AvgPrice, 64)
+	exitPrice, _ := strconv.ParseFloat(exitOrder.AvgPrice, 64)
+	quantity, _ := strconv.ParseFloat(exitOrder.ExecutedQty, 64)
+	if quantity == 0 {
+		quantity, _ = strconv.ParseFloat(orderSet.SellOrder.ExecutedQty, 64)
+	}
+
+	closed := &ClosedPosition{Reason: reason, Quantity: quantity, EntryPrice: entryPrice, ExitPrice: exitPrice}
+	if entryPrice > 0 && exitPrice > 0 {
+		closed.PnL = (exitPrice - entryPrice) * quantity
+		if orderSet.Direction != DirectionLong {
+			closed.PnL = -closed.PnL
+		}
+	}
+	return closed
+}
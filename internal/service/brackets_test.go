@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/models"
+)
+
+// fakeExchange 返回预设订单状态的交易所
+type fakeExchange struct {
+	exchange.Exchange
+	statuses map[int64]string
+}
+
+func (f *fakeExchange) Name() string { return exchange.NameBinance }
+
+func (f *fakeExchange) QueryOrder(symbol string, orderID int64) (*models.OrderResponse, error) {
+	return &models.OrderResponse{OrderID: orderID, Symbol: symbol, Status: f.statuses[orderID], AvgPrice: "1.1"}, nil
+}
+
+func TestBracketMonitorTriggered(t *testing.T) {
+	bus := events.NewBus(10)
+	sub, _ := bus.Subscribe(nil, 0)
+	defer sub.Close()
+
+	ex := &fakeExchange{statuses: map[int64]string{2: "NEW", 3: "NEW"}}
+	bm := NewBracketMonitor(bus)
+	bm.Track(ex, &OrderSet{
+		Symbol:          "ABCUSDT",
+		Exchange:        exchange.NameBinance,
+		Account:         "main",
+		SellOrder:       &models.OrderResponse{OrderID: 1},
+		StopLossOrder:   &models.OrderResponse{OrderID: 2},
+		TakeProfitOrder: &models.OrderResponse{OrderID: 3},
+	})
+
+	bm.check()
+	if len(bm.Tracked()) != 1 || len(sub.C) != 0 {
+		t.Fatalf("止盈止损单未触发时应继续跟踪且不发布事件")
+	}
+
+	ex.statuses[3] = "FILLED"
+	bm.check()
+	if len(bm.Tracked()) != 0 {
+		t.Errorf("止盈触发后应停止跟踪")
+	}
+	e := <-sub.C
+	if e.Type != events.TypeTakeProfitTriggered || e.Symbol != "ABCUSDT" || e.Account != "main" {
+		t.Errorf("止盈触发事件不正确: %+v", e)
+	}
+}
+
+func TestBracketMonitorCanceled(t *testing.T) {
+	ex := &fakeExchange{statuses: map[int64]string{2: "CANCELED"}}
+	bm := NewBracketMonitor(nil)
+	bm.Track(ex, &OrderSet{
+		Symbol:        "ABCUSDT",
+		SellOrder:     &models.OrderResponse{OrderID: 1},
+		StopLossOrder: &models.OrderResponse{OrderID: 2},
+	})
+	bm.check()
+	if len(bm.Tracked()) != 0 {
+		t.Errorf("止损单已撤销时应停止跟踪")
+	}
+}
+
+func TestBracketMonitorLadder(t *testing.T) {
+	bus := events.NewBus(10)
+	ex := &fakeExchange{statuses: map[int64]string{2: "NEW", 3: "FILLED", 4: "NEW"}}
+	bm := NewBracketMonitor(bus)
+	bm.Track(ex, &OrderSet{
+		Symbol:           "ABCUSDT",
+		SellOrder:        &models.OrderResponse{OrderID: 1},
+		StopLossOrder:    &models.OrderResponse{OrderID: 2},
+		TakeProfitOrders: []*models.OrderResponse{{OrderID: 3}, {OrderID: 4}},
+	})
+
+	// 第一档成交只平掉部分仓位，继续跟踪且不重复发布事件
+	bm.check()
+	bm.check()
+	if len(bm.Tracked()) != 1 {
+		t.Fatalf("部分止盈档成交后应继续跟踪")
+	}
+	if triggered := bus.Recent(events.ParseFilter(events.TypeTakeProfitTriggered), 10); len(triggered) != 1 {
+		t.Fatalf("每档成交只应发布一次止盈事件，实际: %d", len(triggered))
+	}
+
+	ex.statuses[4] = "FILLED"
+	bm.check()
+	if len(bm.Tracked()) != 0 {
+		t.Errorf("全部止盈档成交后应停止跟踪")
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/logger"
+)
+
+// ReloadResult 配置热更新结果
+type ReloadResult struct {
+	Applied         []config.Change `json:"applied"`          // 已生效的变更
+	RestartRequired []config.Change `json:"restart_required"` // 需要重启才能生效的变更（未应用）
+}
+
+// ConfigReloader 重新读取配置文件并热更新交易服务
+type ConfigReloader struct {
+	path    string
+	trading *TradingService
+
+	mu      sync.Mutex // 串行化文件监听和手动触发的重载
+	modTime time.Time  // 上次加载时配置文件的修改时间
+}
+
+// NewConfigReloader 创建配置重载器
+func NewConfigReloader(path string, trading *TradingService) *ConfigReloader {
+	reloader := &ConfigReloader{
+		path:    path,
+		trading: trading,
+	}
+	if info, err := os.Stat(path); err == nil {
+		reloader.modTime = info.ModTime()
+	}
+	return reloader
+}
+
+// Reload 重新读取并校验配置文件，校验失败时保留当前配置
+func (r *ConfigReloader) Reload() (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reload()
+}
+
+func (r *ConfigReloader) reload() (*ReloadResult, error) {
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime = info.ModTime()
+	}
+
+	newCfg, err := config.LoadConfig(r.path)
+	if err != nil {
+		logger.Errorf("配置热更新失败，保留当前配置: %v", err)
+		return nil, err
+	}
+
+	changes, err := r.trading.ApplyConfig(newCfg)
+	if err != nil {
+		logger.Errorf("配置热更新失败，保留当前配置: %v", err)
+		return nil, err
+	}
+
+	result := &ReloadResult{
+		Applied:         make([]config.Change, 0),
+		RestartRequired: make([]config.Change, 0),
+	}
+	for _, change := range changes {
+		if change.RestartRequired {
+			result.RestartRequired = append(result.RestartRequired, change)
+			logger.Warnf("配置变更需要重启才能生效: %s: %s -> %s", change.Field, change.Old, change.New)
+		} else {
+			result.Applied = append(result.Applied, change)
+			logger.Infof("配置热更新: %s: %s -> %s", change.Field, change.Old, change.New)
+		}
+	}
+	if len(changes) == 0 {
+		logger.Info("配置热更新: 无变化")
+	}
+	return result, nil
+}
+
+// Watch 定时检查配置文件的修改时间，变化时自动重载，直到ctx取消
+func (r *ConfigReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(r.path)
+		if err != nil {
+			continue
+		}
+
+		r.mu.Lock()
+		if info.ModTime().After(r.modTime) {
+			logger.Infof("检测到配置文件变更: %s", r.path)
+			r.reload()
+		}
+		r.mu.Unlock()
+	}
+}
+
+// String 描述重载结果，用于接口响应消息
+func (result *ReloadResult) String() string {
+	return fmt.Sprintf("已生效 %d 项，需要重启 %d 项", len(result.Applied), len(result.RestartRequired))
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"new_listing_trade/internal/config"
+)
+
+const reloadTestConfig = `
+binance:
+  api_key: "%s"
+  secret_key: "secret"
+trading:
+  default_notional: "%s"
+  position_side: "BOTH"
+  stop_loss:
+    enabled: true
+    percent: %s
+    working_type: "MARK_PRICE"
+  take_profit:
+    enabled: true
+    percent: 5
+    working_type: "MARK_PRICE"
+`
+
+func writeReloadConfig(t *testing.T, path, apiKey, notional, stopLoss string) {
+	t.Helper()
+	content := []byte(fmt.Sprintf(reloadTestConfig, apiKey, notional, stopLoss))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeReloadConfig(t, path, "key", "10", "2")
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	ts, err := NewTradingService(cfg)
+	if err != nil {
+		t.Fatalf("创建交易服务失败: %v", err)
+	}
+	reloader := NewConfigReloader(path, ts)
+
+	writeReloadConfig(t, path, "new-key", "20", "3")
+	result, err := reloader.Reload()
+	if err != nil {
+		t.Fatalf("热更新失败: %v", err)
+	}
+
+	applied := make(map[string]bool)
+	for _, change := range result.Applied {
+		applied[change.Field] = true
+	}
+	if !applied["trading.default_notional"] || !applied["trading.stop_loss.percent"] || len(result.Applied) != 2 {
+		t.Errorf("已生效的变更不正确: %+v", result.Applied)
+	}
+	if len(result.RestartRequired) != 1 || result.RestartRequired[0].Field != "binance.api_key" {
+		t.Fatalf("需要重启的变更不正确: %+v", result.RestartRequired)
+	}
+	if result.RestartRequired[0].New != "******" {
+		t.Errorf("密钥不应出现在变更中: %+v", result.RestartRequired[0])
+	}
+
+	strategy := ts.ResolveStrategy("BTCUSDT", 0)
+	if strategy.Notional != "20" || strategy.StopLossPercent != 3 {
+		t.Errorf("交易配置未生效: %+v", strategy)
+	}
+	if ts.getConfig().Binance.APIKey != "key" {
+		t.Error("API密钥不应被热更新")
+	}
+
+	// 校验失败时保留当前配置
+	writeReloadConfig(t, path, "key", "abc", "3")
+	if _, err := reloader.Reload(); err == nil {
+		t.Error("非法配置应返回错误")
+	}
+	if ts.ResolveStrategy("BTCUSDT", 0).Notional != "20" {
+		t.Error("校验失败时不应修改当前配置")
+	}
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/metrics"
+	"new_listing_trade/internal/models"
+)
+
+// post_only_chase 的默认重挂间隔和最长等待时间
+const (
+	DefaultChaseInterval = time.Second
+	DefaultChaseTimeout  = 30 * time.Second
+)
+
+// createEntryOrder 按 trading.entry 创建开仓单，返回开仓单和下单前的参考价格（未知时为0）
+// 金额达到拆单阈值时拆成多个子单，合并为一个开仓单返回
+func (ts *TradingService) createEntryOrder(ex exchange.Exchange, strategy *Strategy, notionalUSDT string) (*models.OrderResponse, float64, error) {
+	if notionalUSDT == "" {
+		notionalUSDT = strategy.Notional
+	}
+	entry := ts.getConfig().Trading.Entry
+
+	if notional, err := strconv.ParseFloat(notionalUSDT, 64); err == nil && sliceEntry(entry.Slicing, notional) {
+		return ts.slicedEntry(ex, strategy, notional, entry)
+	}
+	return ts.placeEntry(ex, strategy, notionalUSDT, entry)
+}
+
+// placeEntry 按 trading.entry.mode 创建一个开仓单，交易所不支持限价开仓时退回为市价单
+func (ts *TradingService) placeEntry(ex exchange.Exchange, strategy *Strategy, notionalUSDT string, entry config.EntryConfig) (*models.OrderResponse, float64, error) {
+	if entry.Mode == config.EntryModeLimitIOC || entry.Mode == config.EntryModePostOnlyChase {
+		limiter, limitOK := ex.(exchange.LimitOrderCreator)
+		_, bookOK := ex.(exchange.BookTickerProvider)
+		if limitOK && bookOK {
+			if entry.Mode == config.EntryModeLimitIOC {
+				return ts.createLimitIOCEntry(ex, limiter, strategy, notionalUSDT, entry)
+			}
+			return ts.chaseEntry(ex, limiter, strategy, notionalUSDT, entry)
+		}
+		logger.Warnf("交易所 %s 不支持限价开仓，%s 改用市价单", ex.Name(), strategy.Symbol)
+	}
+
+	// 市价单只在需要检查滑点时获取参考价格，避免增加下单延迟
+	reference := 0.0
+	if entry.MaxSlippageBps > 0 {
+		if price, _, err := entryReference(ex, strategy); err != nil {
+			logger.Warnf("获取开仓参考价格失败，不检查滑点: %v", err)
+		} else {
+			reference = price
+		}
+	}
+	order, err := ts.createMarketEntry(ex, strategy, notionalUSDT)
+	return order, reference, err
+}
+
+// entryReference 下单前的参考价格：做空取买一价，做多取卖一价，不支持查询盘口时取最新价格
+func entryReference(ex exchange.Exchange, strategy *Strategy) (float64, *models.BookTicker, error) {
+	if provider, ok := ex.(exchange.BookTickerProvider); ok {
+		book, err := provider.GetBookTicker(strategy.Symbol)
+		if err != nil {
+			return 0, nil, fmt.Errorf("获取买一/卖一价格失败: %w", err)
+		}
+		price := touchPrice(book, strategy.EntrySide())
+		if price <= 0 {
+			return 0, nil, fmt.Errorf("%s 盘口为空", strategy.Symbol)
+		}
+		return price, book, nil
+	}
+
+	ticker, err := ex.GetTickerPrice(strategy.Symbol)
+	if err != nil {
+		return 0, nil, fmt.Errorf("获取当前价格失败: %w", err)
+	}
+	price, err := strconv.ParseFloat(ticker.Price, 64)
+	if err != nil || price <= 0 {
+		return 0, nil, fmt.Errorf("无效的价格: %s", ticker.Price)
+	}
+	return price, nil, nil
+}
+
+// touchPrice 吃单方向的对手价：卖出取买一价，买入取卖一价
+func touchPrice(book *models.BookTicker, side string) float64 {
+	price := book.BidPrice
+	if side == "BUY" {
+		price = book.AskPrice
+	}
+	parsed, _ := strconv.ParseFloat(price, 64)
+	return parsed
+}
+
+// makerPrice 只做maker时挂单的价格：卖出挂卖一价，买入挂买一价
+func makerPrice(book *models.BookTicker, side string) float64 {
+	price := book.AskPrice
+	if side == "BUY" {
+		price = book.BidPrice
+	}
+	parsed, _ := strconv.ParseFloat(price, 64)
+	return parsed
+}
+
+// createLimitIOCEntry 以对手价向成交方向偏移 limit_bps 个基点的价格下IOC限价单，未成交部分自动撤销
+func (ts *TradingService) createLimitIOCEntry(ex exchange.Exchange, limiter exchange.LimitOrderCreator, strategy *Strategy, notionalUSDT string, entry config.EntryConfig) (*models.OrderResponse, float64, error) {
+	reference, _, err := entryReference(ex, strategy)
+	if err != nil {
+		return nil, 0, err
+	}
+	limit := reference * (1 - entry.LimitBps/10000)
+	if strategy.EntrySide() == "BUY" {
+		limit = reference * (1 + entry.LimitBps/10000)
+	}
+	price, err := ts.adjustPrice(ex, strategy.Symbol, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("调整限价精度失败: %w", err)
+	}
+	quantity, err := ts.entryQuantity(ex, strategy.Symbol, notionalUSDT, reference)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	logger.Infof("创建IOC限价开仓单（%s，%s）: %s, 参考价格: %.8f, 限价: %s, 数量: %s",
+		strategy.directionLabel(), ex.Name(), strategy.Symbol, reference, price, quantity)
+	order, err := limiter.CreateLimitOrder(&exchange.LimitOrderRequest{
+		Symbol:       strategy.Symbol,
+		Side:         strategy.EntrySide(),
+		PositionSide: strategy.PositionSide,
+		Quantity:     quantity,
+		Price:        price,
+		TimeInForce:  "IOC",
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	order = finalOrder(ex, strategy.Symbol, order)
+	if qty, _ := orderFill(order); qty <= 0 {
+		return nil, 0, fmt.Errorf("IOC限价单未成交（限价 %s，状态 %s）", price, order.Status)
+	}
+	return order, reference, nil
+}
+
+// chaseEntry 在买一/卖一价挂只做maker的GTX限价单，价格变化或被拒绝时按新价格重挂剩余数量，
+// 超过 chase_timeout 后撤单，按已成交的数量继续后续流程
+func (ts *TradingService) chaseEntry(ex exchange.Exchange, limiter exchange.LimitOrderCreator, strategy *Strategy, notionalUSDT string, entry config.EntryConfig) (*models.OrderResponse, float64, error) {
+	querier, ok := ex.(exchange.OrderQuerier)
+	if !ok {
+		return nil, 0, fmt.Errorf("交易所 %s 不支持查询订单", ex.Name())
+	}
+	interval, timeout := entry.ChaseInterval, entry.ChaseTimeout
+	if interval <= 0 {
+		interval = DefaultChaseInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultChaseTimeout
+	}
+
+	reference, book, err := entryReference(ex, strategy)
+	if err != nil {
+		return nil, 0, err
+	}
+	quantity, err := ts.entryQuantity(ex, strategy.Symbol, notionalUSDT, reference)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, _ := strconv.ParseFloat(quantity, 64)
+	symbolInfo, err := ts.getSymbolInfo(ex, strategy.Symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	side := strategy.EntrySide()
+	deadline := time.Now().Add(timeout)
+	var fills []*models.OrderResponse
+	var lastErr error
+	filled := 0.0
+	for time.Now().Before(deadline) {
+		remaining, err := binance.ValidateAndAdjustQuantity(total-filled, symbolInfo)
+		if err != nil {
+			break // 剩余数量不足最小交易量
+		}
+		price, err := binance.ValidateAndAdjustPrice(makerPrice(book, side), symbolInfo)
+		if err != nil {
+			return nil, 0, fmt.Errorf("调整限价精度失败: %w", err)
+		}
+
+		logger.Infof("挂只做maker开仓单（%s，%s）: %s, 价格: %s, 数量: %s", strategy.directionLabel(), ex.Name(), strategy.Symbol, price, remaining)
+		order, err := limiter.CreateLimitOrder(&exchange.LimitOrderRequest{
+			Symbol:       strategy.Symbol,
+			Side:         side,
+			PositionSide: strategy.PositionSide,
+			Quantity:     remaining,
+			Price:        price,
+			TimeInForce:  "GTX",
+		})
+		if err != nil {
+			// 价格已变化时GTX单会被拒绝，按最新盘口重挂
+			logger.Warnf("挂只做maker开仓单失败，%s后按最新盘口重挂: %v", interval, err)
+			lastErr = err
+			time.Sleep(interval)
+			if latest, bookErr := ex.(exchange.BookTickerProvider).GetBookTicker(strategy.Symbol); bookErr == nil {
+				book = latest
+			}
+			continue
+		}
+
+		order, book = ts.waitChase(ex, querier, strategy, order, price, interval, deadline)
+		fills = append(fills, order)
+		qty, _ := orderFill(order)
+		filled += qty
+		if order.Status == "FILLED" || book == nil {
+			break
+		}
+	}
+
+	merged := mergeFills(fills, total)
+	if merged == nil {
+		if lastErr != nil {
+			return nil, 0, fmt.Errorf("只做maker开仓单在 %s 内未成交: %w", timeout, lastErr)
+		}
+		return nil, 0, fmt.Errorf("只做maker开仓单在 %s 内未成交", timeout)
+	}
+	return merged, reference, nil
+}
+
+// waitChase 等待挂单成交；价格变化、挂单被拒绝（会立即成交的GTX单）或超时时撤单，
+// 返回挂单的最终状态和最新盘口（超时或无法继续时盘口为nil）
+func (ts *TradingService) waitChase(ex exchange.Exchange, querier exchange.OrderQuerier, strategy *Strategy, order *models.OrderResponse, price string, interval time.Duration, deadline time.Time) (*models.OrderResponse, *models.BookTicker) {
+	provider := ex.(exchange.BookTickerProvider)
+	for {
+		if current, err := querier.QueryOrder(strategy.Symbol, order.OrderID); err == nil {
+			order = current
+		}
+		if terminalOrderStatuses[order.Status] {
+			book, _ := provider.GetBookTicker(strategy.Symbol)
+			return order, book
+		}
+		if !time.Now().Before(deadline) {
+			return cancelChase(ex, querier, strategy.Symbol, order), nil
+		}
+
+		time.Sleep(interval)
+		book, err := provider.GetBookTicker(strategy.Symbol)
+		if err != nil {
+			continue
+		}
+		if repriced, err := ts.adjustPrice(ex, strategy.Symbol, makerPrice(book, strategy.EntrySide())); err == nil && repriced != price {
+			logger.Infof("%s 盘口价格变化 %s -> %s，撤单重挂", strategy.Symbol, price, repriced)
+			return cancelChase(ex, querier, strategy.Symbol, order), book
+		}
+	}
+}
+
+// cancelChase 撤销挂单并查询撤单后的最终成交数量
+func cancelChase(ex exchange.Exchange, querier exchange.OrderQuerier, symbol string, order *models.OrderResponse) *models.OrderResponse {
+	if err := ex.CancelOrder(symbol, order); err != nil {
+		logger.Warnf("撤销开仓挂单失败: %s %d: %v", symbol, order.OrderID, err)
+	}
+	if current, err := querier.QueryOrder(symbol, order.OrderID); err == nil {
+		return current
+	}
+	return order
+}
+
+// finalOrder 下单响应不是最终状态时（例如币安默认ACK响应）查询一次订单
+func finalOrder(ex exchange.Exchange, symbol string, order *models.OrderResponse) *models.OrderResponse {
+	// 合并后的开仓单（追价开仓的子单）已是最终状态，按订单ID查询只能得到其中一个挂单
+	querier, ok := ex.(exchange.OrderQuerier)
+	if !ok || terminalOrderStatuses[order.Status] || len(order.MergedOrderIDs) > 0 {
+		return order
+	}
+	if current, err := querier.QueryOrder(symbol, order.OrderID); err == nil {
+		return current
+	}
+	return order
+}
+
+// entryQuantity 按参考价格把USDT金额换算为符合精度规则的数量
+func (ts *TradingService) entryQuantity(ex exchange.Exchange, symbol, notionalUSDT string, reference float64) (string, error) {
+	notional, err := strconv.ParseFloat(notionalUSDT, 64)
+	if err != nil || notional <= 0 {
+		return "", fmt.Errorf("无效的USDT金额: %s", notionalUSDT)
+	}
+	symbolInfo, err := ts.getSymbolInfo(ex, symbol)
+	if err != nil {
+		return "", err
+	}
+	quantity, err := binance.ValidateAndAdjustQuantity(notional/reference, symbolInfo)
+	if err != nil {
+		return "", fmt.Errorf("调整开仓数量精度失败: %w", err)
+	}
+	qty, _ := strconv.ParseFloat(quantity, 64)
+	if err := binance.CheckMinNotional(qty, reference, symbolInfo); err != nil {
+		return "", err
+	}
+	return quantity, nil
+}
+
+// orderFill 订单的成交数量和成交金额，没有成交金额时按成交均价计算
+// 已成交但响应中没有成交数量时（例如币安ACK响应）取下单数量
+func orderFill(order *models.OrderResponse) (float64, float64) {
+	qty, _ := strconv.ParseFloat(order.ExecutedQty, 64)
+	if qty == 0 && order.Status == "FILLED" {
+		qty, _ = strconv.ParseFloat(order.OrigQty, 64)
+	}
+	quote, _ := strconv.ParseFloat(order.CumQuote, 64)
+	if quote == 0 {
+		avgPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+		quote = avgPrice * qty
+	}
+	return qty, quote
+}
+
+// mergeFills 把多个订单的成交合并为一个开仓单（订单ID取最后一个有成交的订单，均价为成交量加权均价），
+// 全部有成交的订单ID记录在MergedOrderIDs中，成交数量不足total时状态为PARTIALLY_FILLED，没有成交时返回nil
+func mergeFills(fills []*models.OrderResponse, total float64) *models.OrderResponse {
+	var merged models.OrderResponse
+	var orderIDs []int64
+	qty, quote := 0.0, 0.0
+	for _, order := range fills {
+		orderQty, orderQuote := orderFill(order)
+		if orderQty <= 0 {
+			continue
+		}
+		merged = *order
+		qty += orderQty
+		quote += orderQuote
+		// 子单本身也可能是合并的开仓单（拆单的子单按post_only_chase下单）
+		if len(order.MergedOrderIDs) > 0 {
+			orderIDs = append(orderIDs, order.MergedOrderIDs...)
+		} else {
+			orderIDs = append(orderIDs, order.OrderID)
+		}
+	}
+	if qty <= 0 {
+		return nil
+	}
+
+	merged.ExecutedQty = formatAmount(qty)
+	merged.CumQuote = formatAmount(quote)
+	merged.AvgPrice = formatAmount(quote / qty)
+	merged.MergedOrderIDs = orderIDs
+	merged.Status = "FILLED"
+	if qty < total*(1-quantityTolerance) {
+		merged.Status = "PARTIALLY_FILLED"
+	}
+	return &merged
+}
+
+// formatAmount 格式化累加后的数量/金额，去掉浮点误差
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e8)/1e8, 'f', -1, 64)
+}
+
+// slippageBps 成交均价相对参考价格的不利滑点（基点），为负表示成交价优于参考价格
+func slippageBps(side string, reference, fill float64) float64 {
+	if side == "BUY" {
+		return (fill - reference) / reference * 10000
+	}
+	return (reference - fill) / reference * 10000
+}
+
+// checkSlippage 成交均价相对下单前参考价格的滑点超过 max_slippage_bps 时按 on_slippage 处理
+// 返回false表示仓位已平掉，不再创建止盈止损单
+func (ts *TradingService) checkSlippage(ex exchange.Exchange, strategy *Strategy, orderSet *OrderSet, reference, entryPrice float64, quantity string) bool {
+	entry := ts.getConfig().Trading.Entry
+	if entry.MaxSlippageBps <= 0 || reference <= 0 {
+		return true
+	}
+	slippage := slippageBps(strategy.EntrySide(), reference, entryPrice)
+	if slippage <= entry.MaxSlippageBps {
+		return true
+	}
+
+	err := fmt.Errorf("开仓滑点 %.1f 个基点超过上限 %g（参考价格 %.8f，成交均价 %.8f）", slippage, entry.MaxSlippageBps, reference, entryPrice)
+	logger.Warnf("%s: %v", strategy.Symbol, err)
+	if entry.OnSlippage == config.SlippageActionWarn {
+		ts.publishError(orderSet, err)
+		return true
+	}
+
+	unwindOrder, unwindErr := ts.unwindEntry(ex, strategy, orderSet, entryPrice, quantity, "开仓滑点超过上限")
+	metrics.Orders.Inc(ex.Name(), metrics.OrderTypeUnwind, metrics.OrderResult(unwindErr))
+	if unwindErr != nil {
+		logger.Errorf("开仓滑点超过上限后市价平仓失败，继续创建止盈止损单: %v", unwindErr)
+		orderSet.UnwindError = unwindErr
+		ts.publishError(orderSet, fmt.Errorf("%v，市价平仓也失败: %w", err, unwindErr))
+		return true
+	}
+
+	orderSet.Status = OrderSetSlippageUnwound
+	orderSet.UnwindOrder = unwindOrder
+	ts.publishError(orderSet, fmt.Errorf("%v，已市价平仓", err))
+	return false
+}
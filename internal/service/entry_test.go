@@ -0,0 +1,171 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/models"
+)
+
+// fakeEntryExchange 支持限价单的交易所：盘口按books依次返回（最后一个保持不变），
+// 第i个限价单的最终状态为fills[i-1]，最终状态为CANCELED时撤单前查询返回NEW
+type fakeEntryExchange struct {
+	fakeGuardExchange
+	books     []models.BookTicker
+	bookCalls int
+	limits    []*exchange.LimitOrderRequest
+	fills     []models.OrderResponse
+}
+
+func (f *fakeEntryExchange) GetBookTicker(symbol string) (*models.BookTicker, error) {
+	book := f.books[len(f.books)-1]
+	if f.bookCalls < len(f.books) {
+		book = f.books[f.bookCalls]
+	}
+	f.bookCalls++
+	return &book, nil
+}
+
+func (f *fakeEntryExchange) CreateLimitOrder(req *exchange.LimitOrderRequest) (*models.OrderResponse, error) {
+	f.limits = append(f.limits, req)
+	return &models.OrderResponse{OrderID: int64(len(f.limits)), Symbol: req.Symbol, Side: req.Side, Type: "LIMIT", Status: "NEW"}, nil
+}
+
+func (f *fakeEntryExchange) QueryOrder(symbol string, orderID int64) (*models.OrderResponse, error) {
+	fill := f.fills[orderID-1]
+	fill.OrderID = orderID
+	canceled := false
+	for _, id := range f.canceled {
+		canceled = canceled || id == orderID
+	}
+	if fill.Status == "CANCELED" && !canceled {
+		return &models.OrderResponse{OrderID: orderID, Symbol: symbol, Status: "NEW"}, nil
+	}
+	return &fill, nil
+}
+
+func newEntryTestService(t *testing.T, ex exchange.Exchange, entry config.EntryConfig) (*TradingService, *events.Bus) {
+	t.Helper()
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+	ts.config.Trading.Entry = entry
+	bus := events.NewBus(10)
+	ts.SetEventBus(bus)
+	return ts, bus
+}
+
+func TestSlippageBps(t *testing.T) {
+	if bps := slippageBps("SELL", 10, 9.9); bps < 99.99 || bps > 100.01 {
+		t.Errorf("做空成交价低于参考价格应为不利滑点，实际: %g", bps)
+	}
+	if bps := slippageBps("BUY", 10, 9.9); bps > 0 {
+		t.Errorf("做多成交价低于参考价格应为有利滑点，实际: %g", bps)
+	}
+}
+
+func TestLimitIOCEntry(t *testing.T) {
+	ex := &fakeEntryExchange{
+		fakeGuardExchange: fakeGuardExchange{price: "10"},
+		books:             []models.BookTicker{{BidPrice: "10", AskPrice: "10.01"}},
+		fills:             []models.OrderResponse{{Status: "FILLED", ExecutedQty: "10", AvgPrice: "9.99"}},
+	}
+	ts, _ := newEntryTestService(t, ex, config.EntryConfig{Mode: config.EntryModeLimitIOC, LimitBps: 20, MaxSlippageBps: 20})
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if len(ex.limits) != 1 || len(ex.orders) != 0 {
+		t.Fatalf("应下1个限价单且不下市价单: limits=%d orders=%d", len(ex.limits), len(ex.orders))
+	}
+	// 做空按买一价10向下偏移20个基点
+	if req := ex.limits[0]; req.TimeInForce != "IOC" || req.Side != "SELL" || req.Quantity != "10.0" || req.Price != "9.980" {
+		t.Errorf("IOC限价单参数不正确: %+v", req)
+	}
+	if orderSet.Status != OrderSetProtected || orderSet.SellOrder.AvgPrice != "9.99" {
+		t.Errorf("滑点10个基点未超过上限，应继续创建止损单: %+v", orderSet)
+	}
+}
+
+func TestLimitIOCEntryNotFilled(t *testing.T) {
+	ex := &fakeEntryExchange{
+		fakeGuardExchange: fakeGuardExchange{price: "10"},
+		books:             []models.BookTicker{{BidPrice: "10", AskPrice: "10.01"}},
+		fills:             []models.OrderResponse{{Status: "EXPIRED", ExecutedQty: "0"}},
+	}
+	ts, _ := newEntryTestService(t, ex, config.EntryConfig{Mode: config.EntryModeLimitIOC, LimitBps: 10})
+
+	if _, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", ""); err == nil {
+		t.Error("IOC限价单未成交时应返回错误")
+	}
+	if ex.stopAttempts != 0 {
+		t.Error("未开仓时不应创建止损单")
+	}
+}
+
+func TestPostOnlyChaseEntry(t *testing.T) {
+	ex := &fakeEntryExchange{
+		fakeGuardExchange: fakeGuardExchange{price: "10"},
+		// 挂单后卖一价上涨，撤单后按新价格重挂剩余数量
+		books: []models.BookTicker{{BidPrice: "9.99", AskPrice: "10"}, {BidPrice: "10.04", AskPrice: "10.05"}},
+		fills: []models.OrderResponse{
+			{Status: "CANCELED", ExecutedQty: "4", AvgPrice: "10"},
+			{Status: "FILLED", ExecutedQty: "6", AvgPrice: "10.05"},
+		},
+	}
+	ts, _ := newEntryTestService(t, ex, config.EntryConfig{Mode: config.EntryModePostOnlyChase, ChaseInterval: time.Millisecond, ChaseTimeout: time.Second})
+	ts.journal, _ = NewTradeJournal("")
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if len(ex.limits) != 2 || ex.limits[0].TimeInForce != "GTX" || ex.limits[0].Price != "10.000" || ex.limits[1].Quantity != "6.0" {
+		t.Fatalf("应在卖一价挂GTX单，价格变化后重挂剩余数量: %+v", ex.limits)
+	}
+	if len(ex.canceled) != 1 || ex.canceled[0] != 1 {
+		t.Errorf("价格变化时应撤销第一个挂单，实际: %v", ex.canceled)
+	}
+	if entry := orderSet.SellOrder; entry.ExecutedQty != "10" || entry.AvgPrice != "10.03" || entry.Status != "FILLED" {
+		t.Errorf("两次成交应合并为一个开仓单: %+v", entry)
+	}
+	// 交易日志记录全部有成交的挂单，按两个挂单的成交计算开仓数量
+	entries := ts.journal.Entries(TradeQuery{})
+	if len(entries) != 1 || len(entries[0].EntryOrderIDs) != 2 || entries[0].EntryOrderIDs[0] != 1 || entries[0].EntryOrderIDs[1] != 2 {
+		t.Fatalf("交易日志应记录两个挂单ID: %+v", entries)
+	}
+	record := MatchTrades(entries[0], []models.UserTrade{
+		{OrderID: 1, Side: "SELL", PositionSide: "BOTH", Price: "10", Qty: "4", Time: 1000},
+		{OrderID: 2, Side: "SELL", PositionSide: "BOTH", Price: "10.05", Qty: "6", Time: 1001},
+	})
+	if record.EntryQty != 10 || len(record.Fills) != 2 {
+		t.Errorf("应按全部挂单的成交计算开仓数量: %+v", record)
+	}
+}
+
+func TestMarketEntrySlippage(t *testing.T) {
+	// 参考价格10.05，成交均价10，做空滑点约50个基点
+	ex := &fakeGuardExchange{price: "10.05"}
+	ts, bus := newEntryTestService(t, ex, config.EntryConfig{MaxSlippageBps: 20})
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if orderSet.Status != OrderSetSlippageUnwound || orderSet.Open() || len(ex.orders) != 2 || !ex.orders[1].ReduceOnly || ex.stopAttempts != 0 {
+		t.Fatalf("滑点超过上限应市价平仓且不创建止损单: status=%s orders=%d", orderSet.Status, len(ex.orders))
+	}
+	if errs := bus.Recent(events.ParseFilter(events.TypeError), 10); len(errs) != 1 {
+		t.Errorf("滑点超过上限应发布错误事件，实际: %d", len(errs))
+	}
+
+	// on_slippage: warn 时只告警，继续创建止损单
+	ex = &fakeGuardExchange{price: "10.05"}
+	ts, _ = newEntryTestService(t, ex, config.EntryConfig{MaxSlippageBps: 20, OnSlippage: config.SlippageActionWarn})
+	orderSet, _ = ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if orderSet.Status != OrderSetProtected || len(ex.orders) != 1 {
+		t.Errorf("warn模式不应平仓: status=%s orders=%d", orderSet.Status, len(ex.orders))
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// DefaultFundingInterval 无法从资金费率设置或历史资金费率获取结算间隔时使用的默认值
+const DefaultFundingInterval = 8 * time.Hour
+
+// DefaultFundingHolding 未设置预计持仓时间和最长持仓时间时估算资金费使用的持仓时间
+const DefaultFundingHolding = 24 * time.Hour
+
+// FundingCheck 开仓前按预计持仓时间估算的资金费
+type FundingCheck struct {
+	RatePercent          float64 `json:"rate_percent"`           // 当前资金费率（百分比，正数为多头支付空头）
+	NextFundingTime      int64   `json:"next_funding_time"`      // 下次结算时间（毫秒）
+	IntervalHours        float64 `json:"interval_hours"`         // 结算间隔（小时）
+	Periods              int     `json:"periods"`                // 预计持仓期间的结算次数
+	ProjectedCostPercent float64 `json:"projected_cost_percent"` // 预计需要支付的资金费（占开仓金额的百分比，负数表示收取）
+}
+
+// checkFunding 按 trading.funding.max_cost_percent 估算预计持仓期间的资金费，超过上限时返回错误跳过开仓
+// 未配置或交易所不支持时返回nil；查询资金费率失败时只记录警告，不阻止开仓
+func (ts *TradingService) checkFunding(ex exchange.Exchange, strategy *Strategy) (*FundingCheck, error) {
+	funding := ts.getConfig().Trading.Funding
+	if funding.MaxCostPercent <= 0 {
+		return nil, nil
+	}
+	provider, ok := ex.(exchange.FundingRateProvider)
+	if !ok {
+		logger.Warnf("交易所 %s 不支持查询资金费率，跳过资金费检查", ex.Name())
+		return nil, nil
+	}
+	index, err := provider.GetPremiumIndex(strategy.Symbol)
+	if err != nil {
+		logger.Warnf("查询 %s 资金费率失败，跳过资金费检查: %v", strategy.Symbol, err)
+		return nil, nil
+	}
+	rate, err := strconv.ParseFloat(index.LastFundingRate, 64)
+	if err != nil {
+		logger.Warnf("解析 %s 资金费率失败，跳过资金费检查: %s", strategy.Symbol, index.LastFundingRate)
+		return nil, nil
+	}
+
+	holding := funding.ExpectedHolding
+	if holding <= 0 {
+		holding = strategy.MaxHoldingDuration
+	}
+	if holding <= 0 {
+		holding = DefaultFundingHolding
+	}
+	check := projectFunding(strategy.Direction, rate, index.NextFundingTime, fundingInterval(provider, strategy.Symbol), time.Now(), holding)
+
+	logger.Infof("%s 资金费检查: 资金费率 %.4f%%, 预计持仓 %s 内结算 %d 次, 预计资金费成本 %.4f%%",
+		strategy.Symbol, check.RatePercent, holding, check.Periods, check.ProjectedCostPercent)
+	if check.ProjectedCostPercent > funding.MaxCostPercent {
+		return check, fmt.Errorf("预计持仓 %s 内资金费成本 %.4f%%（资金费率 %.4f%%，结算 %d 次）超过上限 %g%%，跳过开仓",
+			holding, check.ProjectedCostPercent, check.RatePercent, check.Periods, funding.MaxCostPercent)
+	}
+	return check, nil
+}
+
+// fundingInterval 资金费结算间隔（新币常为1或4小时）：优先使用资金费率设置中的fundingIntervalHours，
+// 没有时按最近两次结算时间推算，新币没有足够的历史时使用默认8小时
+func fundingInterval(provider exchange.FundingRateProvider, symbol string) time.Duration {
+	if infos, err := provider.GetFundingInfo(); err != nil {
+		logger.Warnf("查询资金费率设置失败，按历史资金费率推算结算间隔: %v", err)
+	} else {
+		for _, info := range infos {
+			if info.Symbol == symbol && info.FundingIntervalHours > 0 {
+				return time.Duration(info.FundingIntervalHours) * time.Hour
+			}
+		}
+	}
+
+	rates, err := provider.GetFundingRateHistory(&models.FundingRateParams{Symbol: symbol, Limit: 2})
+	if err != nil || len(rates) < 2 {
+		return DefaultFundingInterval
+	}
+	interval := (time.Duration(rates[len(rates)-1].FundingTime-rates[len(rates)-2].FundingTime) * time.Millisecond).Round(time.Minute)
+	if interval <= 0 {
+		return DefaultFundingInterval
+	}
+	return interval
+}
+
+// projectFunding 计算从now开始持仓holding期间的结算次数和需要支付的资金费，按当前资金费率估算每次结算
+func projectFunding(direction string, rate float64, nextFundingTime int64, interval time.Duration, now time.Time, holding time.Duration) *FundingCheck {
+	check := &FundingCheck{RatePercent: rate * 100, NextFundingTime: nextFundingTime, IntervalHours: interval.Hours()}
+	end := now.Add(holding)
+	if nextFundingTime <= 0 {
+		check.Periods = int(holding / interval)
+	} else if next := time.UnixMilli(nextFundingTime); !next.After(end) {
+		check.Periods = 1 + int(end.Sub(next)/interval)
+	}
+	check.ProjectedCostPercent = fundingCostPercent(direction, rate) * float64(check.Periods)
+	return check
+}
+
+// fundingCostPercent 每次结算需要支付的资金费（占名义价值的百分比，负数表示收取）
+// 资金费率为正时多头支付空头，为负时空头支付多头
+func fundingCostPercent(direction string, rate float64) float64 {
+	if direction == DirectionLong {
+		return rate * 100
+	}
+	return -rate * 100
+}
+
+// fillFunding 填充仓位的资金费率和下次结算时间，同一币对只查询一次，查询失败时只记录警告
+func fillFunding(provider exchange.FundingRateProvider, positions []models.Position) {
+	indexes := make(map[string]*models.PremiumIndex)
+	for i := range positions {
+		p := &positions[i]
+		index, ok := indexes[p.Symbol]
+		if !ok {
+			var err error
+			if index, err = provider.GetPremiumIndex(p.Symbol); err != nil {
+				logger.Warnf("查询 %s 资金费率失败: %v", p.Symbol, err)
+			}
+			indexes[p.Symbol] = index
+		}
+		if index == nil {
+			continue
+		}
+		rate, err := strconv.ParseFloat(index.LastFundingRate, 64)
+		if err != nil {
+			continue
+		}
+		ratePercent := rate * 100
+		p.FundingRatePercent = &ratePercent
+		p.NextFundingTime = index.NextFundingTime
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/models"
+)
+
+// fakeFundingExchange 支持查询资金费率的交易所
+type fakeFundingExchange struct {
+	fakeGuardExchange
+	index   models.PremiumIndex
+	history []models.FundingRate
+	infos   []models.FundingInfo
+}
+
+func (f *fakeFundingExchange) GetPremiumIndex(symbol string) (*models.PremiumIndex, error) {
+	index := f.index
+	index.Symbol = symbol
+	return &index, nil
+}
+
+func (f *fakeFundingExchange) GetFundingRateHistory(params *models.FundingRateParams) ([]models.FundingRate, error) {
+	return f.history, nil
+}
+
+func (f *fakeFundingExchange) GetFundingInfo() ([]models.FundingInfo, error) {
+	return f.infos, nil
+}
+
+// newFundingExchange 资金费率-0.5%（空头支付），1小时后结算，历史结算间隔4小时
+func newFundingExchange(nextFunding time.Duration) *fakeFundingExchange {
+	now := time.Now()
+	return &fakeFundingExchange{
+		fakeGuardExchange: fakeGuardExchange{price: "10"},
+		index:             models.PremiumIndex{LastFundingRate: "-0.005", NextFundingTime: now.Add(nextFunding).UnixMilli()},
+		history: []models.FundingRate{
+			{FundingRate: "-0.004", FundingTime: now.Add(-7 * time.Hour).UnixMilli()},
+			{FundingRate: "-0.005", FundingTime: now.Add(-3 * time.Hour).UnixMilli()},
+		},
+	}
+}
+
+func TestProjectFunding(t *testing.T) {
+	now := time.Now()
+	next := now.Add(time.Hour).UnixMilli()
+
+	// 6小时内在1小时后和5小时后各结算一次
+	check := projectFunding(DirectionShort, -0.005, next, 4*time.Hour, now, 6*time.Hour)
+	if check.Periods != 2 || math.Abs(check.ProjectedCostPercent-1) > 1e-9 {
+		t.Errorf("做空应结算2次、支付1%%，实际: %+v", check)
+	}
+	check = projectFunding(DirectionLong, -0.005, next, 4*time.Hour, now, 6*time.Hour)
+	if check.ProjectedCostPercent >= 0 {
+		t.Errorf("资金费率为负时做多应收取资金费，实际: %g", check.ProjectedCostPercent)
+	}
+	check = projectFunding(DirectionShort, -0.005, next, 4*time.Hour, now, 30*time.Minute)
+	if check.Periods != 0 || check.ProjectedCostPercent != 0 {
+		t.Errorf("持仓期间没有结算时成本应为0，实际: %+v", check)
+	}
+}
+
+func TestFundingInterval(t *testing.T) {
+	ex := newFundingExchange(time.Hour)
+	if got := fundingInterval(ex, "ABCUSDT"); got != 4*time.Hour {
+		t.Errorf("没有资金费率设置时应按历史推算为4小时，实际: %s", got)
+	}
+
+	ex.infos = []models.FundingInfo{{Symbol: "XYZUSDT", FundingIntervalHours: 4}, {Symbol: "ABCUSDT", FundingIntervalHours: 1}}
+	if got := fundingInterval(ex, "ABCUSDT"); got != time.Hour {
+		t.Errorf("应优先使用资金费率设置中的结算间隔1小时，实际: %s", got)
+	}
+
+	ex.infos, ex.history = nil, ex.history[:1]
+	if got := fundingInterval(ex, "ABCUSDT"); got != DefaultFundingInterval {
+		t.Errorf("没有设置和足够的历史时应使用默认8小时，实际: %s", got)
+	}
+}
+
+func TestCreateOrderSetFunding(t *testing.T) {
+	ex := newFundingExchange(time.Hour)
+	ts, bus := newEntryTestService(t, ex, config.EntryConfig{})
+	ts.config.Trading.Funding = config.FundingConfig{MaxCostPercent: 0.8, ExpectedHolding: 6 * time.Hour}
+
+	if _, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", ""); err == nil || !strings.Contains(err.Error(), "资金费") {
+		t.Fatalf("预计资金费超过上限时应跳过开仓，实际: %v", err)
+	}
+	if len(ex.orders) != 0 {
+		t.Errorf("跳过开仓时不应下单，实际: %d", len(ex.orders))
+	}
+	breached := bus.Recent(events.ParseFilter(events.TypeRiskLimitBreached), 10)
+	if len(breached) != 1 || breached[0].Data == nil {
+		t.Errorf("资金费超过上限时应发布带检查结果的风控事件，实际: %+v", breached)
+	}
+
+	ts.config.Trading.Funding.MaxCostPercent = 2
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if check := orderSet.Funding; check == nil || check.Periods != 2 || check.IntervalHours != 4 {
+		t.Errorf("应按历史结算间隔4小时估算2次结算: %+v", check)
+	}
+}
+
+func TestFundingExit(t *testing.T) {
+	ex := newFundingExchange(time.Minute)
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", PositionSide: "BOTH"}}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+	ts.journal, _ = NewTradeJournal("")
+	ts.config.Trading.Funding = config.FundingConfig{CloseBeforeFunding: 2 * time.Minute, CloseMinRate: 1}
+
+	// 需要支付的资金费率0.5%未达到阈值1%
+	NewTimeExitScheduler(ts).Check()
+	if len(ex.orders) != 0 {
+		t.Fatalf("资金费率未达到阈值时不应平仓，实际: %+v", ex.orders)
+	}
+
+	ts.config.Trading.Funding.CloseMinRate = 0.5
+	NewTimeExitScheduler(ts).Check()
+	if len(ex.orders) != 1 || ex.orders[0].Side != "BUY" || ex.orders[0].Quantity != "10" {
+		t.Fatalf("结算前应市价平掉空单: %+v", ex.orders)
+	}
+
+	// 资金费率为正时空头收取资金费，不平仓
+	ex = newFundingExchange(time.Minute)
+	ex.index.LastFundingRate = "0.005"
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", PositionSide: "BOTH"}}
+	ts = newGuardTestService(t, ex, config.StopLossConfig{})
+	ts.journal, _ = NewTradeJournal("")
+	ts.config.Trading.Funding = config.FundingConfig{CloseBeforeFunding: 2 * time.Minute}
+	NewTimeExitScheduler(ts).Check()
+	if len(ex.orders) != 0 {
+		t.Errorf("收取资金费的仓位不应平仓，实际: %+v", ex.orders)
+	}
+}
+
+func TestPositionsFunding(t *testing.T) {
+	ex := newFundingExchange(time.Hour)
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", MarkPrice: "10", UnRealizedProfit: "0", PositionSide: "BOTH"}}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+
+	resp, err := ts.GetPositions(PositionQuery{})
+	if err != nil {
+		t.Fatalf("查询持仓失败: %v", err)
+	}
+	p := resp.Positions[0]
+	if p.FundingRatePercent == nil || *p.FundingRatePercent != -0.5 || p.NextFundingTime != ex.index.NextFundingTime {
+		t.Errorf("持仓应包含资金费率和下次结算时间: %+v", p)
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/metrics"
+	"new_listing_trade/internal/models"
+)
+
+// defaultStopLossRetries 止损单创建失败后的默认重试次数
+const defaultStopLossRetries = 2
+
+// stopLossRetryDelay 第一次重试前的等待时间，之后每次递增
+var stopLossRetryDelay = 500 * time.Millisecond
+
+// 开仓后的保护状态
+const (
+	OrderSetProtected    = "protected"     // 止损单已创建
+	OrderSetNoStopLoss   = "no_stop_loss"  // 策略未启用止损
+	OrderSetUnprotected  = "unprotected"   // 止损单创建失败，按配置保留仓位（裸仓）
+	OrderSetUnwound      = "unwound"       // 止损单创建失败，已市价平仓
+	OrderSetUnwindFailed = "unwind_failed" // 止损单创建失败且平仓失败（裸仓，需人工处理）
+
+	OrderSetSlippageUnwound = "slippage_unwound" // 开仓滑点超过上限，已市价平仓
+)
+
+// stopLossPolicy 止损单创建失败时的重试次数和处理方式
+func (ts *TradingService) stopLossPolicy() (int, string) {
+	stopLoss := ts.getConfig().Trading.StopLoss
+	retries := defaultStopLossRetries
+	if stopLoss.Retries != nil {
+		retries = *stopLoss.Retries
+	}
+	onFailure := stopLoss.OnFailure
+	if onFailure == "" {
+		onFailure = config.StopLossOnFailureClose
+	}
+	return retries, onFailure
+}
+
+// protectEntry 创建止损单，失败时重试；仍然失败时按配置市价平掉开仓单或保留仓位并告警
+// 返回false表示仓位已平掉，不再创建止盈单
+func (ts *TradingService) protectEntry(ex exchange.Exchange, strategy *Strategy, orderSet *OrderSet, entryPrice, qtyFloat float64, quantity string) bool {
+	if !strategy.StopLossEnabled {
+		orderSet.Status = OrderSetNoStopLoss
+		return true
+	}
+
+	stopLossOrder, err := ts.createStopLossWithRetry(ex, strategy, entryPrice, qtyFloat, quantity)
+	if err == nil {
+		orderSet.Status = OrderSetProtected
+		orderSet.StopLossOrder = stopLossOrder
+		ts.publishOrder(events.TypeOrderPlaced, orderSet, stopLossOrder, "止损单")
+		return true
+	}
+
+	logger.Errorf("创建止损订单失败: %v", err)
+	return ts.handleStopLossFailure(ex, strategy, orderSet, entryPrice, quantity, err)
+}
+
+// handleStopLossFailure 无法为已成交的仓位创建止损单时按 on_failure 处理：保留仓位或市价平仓，并发布 bracket_failed 事件
+// 仓位保留（包括平仓失败）时返回true，已平仓时返回false
+func (ts *TradingService) handleStopLossFailure(ex exchange.Exchange, strategy *Strategy, orderSet *OrderSet, entryPrice float64, quantity string, err error) bool {
+	orderSet.StopLossError = err
+
+	_, onFailure := ts.stopLossPolicy()
+	if onFailure == config.StopLossOnFailureKeep {
+		orderSet.Status = OrderSetUnprotected
+		ts.publishBracketFailed(orderSet, fmt.Errorf("创建止损订单失败，仓位没有止损保护: %w", err))
+		return true
+	}
+
+	unwindOrder, unwindErr := ts.unwindEntry(ex, strategy, orderSet, entryPrice, quantity, "止损单创建失败")
+	metrics.Orders.Inc(ex.Name(), metrics.OrderTypeUnwind, metrics.OrderResult(unwindErr))
+	if unwindErr != nil {
+		logger.Errorf("止损单创建失败后市价平仓失败，仓位没有止损保护，请立即人工处理: %v", unwindErr)
+		orderSet.Status = OrderSetUnwindFailed
+		orderSet.UnwindError = unwindErr
+		ts.publishBracketFailed(orderSet, fmt.Errorf("创建止损订单失败（%v），市价平仓也失败，请立即人工处理: %w", err, unwindErr))
+		return true
+	}
+
+	orderSet.Status = OrderSetUnwound
+	orderSet.UnwindOrder = unwindOrder
+	ts.publishBracketFailed(orderSet, fmt.Errorf("创建止损订单失败，已市价平仓: %w", err))
+	return false
+}
+
+// createStopLossWithRetry 创建止损单，失败后按最新的精度规则重新调整价格和数量再重试
+// 当前价格已越过止损价时止损单会立即触发，不再重试
+func (ts *TradingService) createStopLossWithRetry(ex exchange.Exchange, strategy *Strategy, entryPrice, qtyFloat float64, quantity string) (*models.OrderResponse, error) {
+	retries, _ := ts.stopLossPolicy()
+
+	order, err := ts.createStopLossOrder(ex, strategy, entryPrice, quantity)
+	metrics.Orders.Inc(ex.Name(), metrics.OrderTypeStopLoss, metrics.OrderResult(err))
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		logger.Warnf("创建止损订单失败，%v后第%d次重试: %v", stopLossRetryDelay*time.Duration(attempt), attempt, err)
+		time.Sleep(stopLossRetryDelay * time.Duration(attempt))
+
+		if price, crossed := ts.stopLossCrossed(ex, strategy, entryPrice); crossed {
+			return nil, fmt.Errorf("%w（当前价格 %.8f 已越过止损价 %.8f）", err, price, strategy.StopLossPrice(entryPrice))
+		}
+		if qtyFloat > 0 {
+			if symbolInfo, infoErr := ts.getSymbolInfo(ex, strategy.Symbol); infoErr == nil {
+				if adjusted, qtyErr := binance.ValidateAndAdjustOrderQuantity(qtyFloat, "STOP_MARKET", symbolInfo); qtyErr == nil {
+					quantity = adjusted
+				}
+			}
+		}
+
+		order, err = ts.createStopLossOrder(ex, strategy, entryPrice, quantity)
+		metrics.Orders.Inc(ex.Name(), metrics.OrderTypeStopLoss, metrics.OrderResult(err))
+	}
+	return order, err
+}
+
+// stopLossCrossed 当前价格是否已越过止损价（做空时高于止损价，做多时低于止损价），获取价格失败时返回false
+func (ts *TradingService) stopLossCrossed(ex exchange.Exchange, strategy *Strategy, entryPrice float64) (float64, bool) {
+	ticker, err := ex.GetTickerPrice(strategy.Symbol)
+	if err != nil {
+		return 0, false
+	}
+	price, err := strconv.ParseFloat(ticker.Price, 64)
+	if err != nil || price <= 0 {
+		return 0, false
+	}
+
+	stopPrice := strategy.StopLossPrice(entryPrice)
+	if strategy.Direction == DirectionLong {
+		return price, price <= stopPrice
+	}
+	return price, price >= stopPrice
+}
+
+// unwindEntry 市价平掉开仓单（只减仓），没有成交数量时按当前持仓数量平仓，cause为平仓原因
+func (ts *TradingService) unwindEntry(ex exchange.Exchange, strategy *Strategy, orderSet *OrderSet, entryPrice float64, quantity, cause string) (*models.OrderResponse, error) {
+	if quantity == "" {
+		positionAmt, err := positionQuantity(ex, strategy.Symbol, strategy.PositionSide)
+		if err != nil {
+			return nil, err
+		}
+		quantity = strconv.FormatFloat(positionAmt, 'f', -1, 64)
+	}
+
+	logger.Warnf("%s，市价平仓（%s，%s）: %s, 数量: %s", cause, strategy.directionLabel(), ex.Name(), strategy.Symbol, quantity)
+	order, err := ex.CreateMarketOrder(&exchange.MarketOrderRequest{
+		Symbol:       strategy.Symbol,
+		Side:         strategy.CloseSide(),
+		PositionSide: strategy.PositionSide,
+		Quantity:     quantity,
+		// 双向持仓模式下不能携带reduceOnly，按positionSide平仓
+		ReduceOnly: strategy.PositionSide == "" || strategy.PositionSide == "BOTH",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("市价平仓失败: %w", err)
+	}
+	ts.publishOrder(events.TypeOrderPlaced, orderSet, order, "平仓单")
+
+	qty, _ := strconv.ParseFloat(quantity, 64)
+	exitPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	pnl := 0.0
+	if exitPrice > 0 {
+		pnl = (exitPrice - entryPrice) * qty
+		if strategy.Direction != DirectionLong {
+			pnl = -pnl
+		}
+	}
+	publishPositionClosed(ts.bus, ex.Name(), orderSet.Account, strategy.Symbol, &ClosedPosition{
+		Reason:     CloseReasonUnwind,
+		Quantity:   qty,
+		EntryPrice: entryPrice,
+		ExitPrice:  exitPrice,
+		PnL:        pnl,
+	})
+	return order, nil
+}
+
+// positionQuantity 查询币对指定持仓方向的持仓数量（绝对值）
+func positionQuantity(ex exchange.Exchange, symbol, positionSide string) (float64, error) {
+	positionRisks, err := ex.GetPositionRisk(symbol)
+	if err != nil {
+		return 0, fmt.Errorf("查询持仓失败: %w", err)
+	}
+	for _, pr := range positionRisks {
+		if pr.Symbol != symbol || (positionSide != "" && pr.PositionSide != "" && pr.PositionSide != positionSide) {
+			continue
+		}
+		if amt, err := strconv.ParseFloat(pr.PositionAmt, 64); err == nil && amt != 0 {
+			return math.Abs(amt), nil
+		}
+	}
+	return 0, fmt.Errorf("没有 %s 的持仓", symbol)
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/models"
+)
+
+// fakeGuardExchange 开仓以10成交，止损单前stopFailures次创建失败
+type fakeGuardExchange struct {
+	fakePositionExchange
+	price          string
+	stopFailures   int
+	stopAttempts   int
+	closeErr       error
+	takeProfits    int
+	takeProfitReqs []*exchange.TriggerOrderRequest
+	filters        []models.Filter // 追加到ABCUSDT的过滤器
+}
+
+func (f *fakeGuardExchange) GetExchangeInfo() (*models.ExchangeInfo, error) {
+	info := &models.ExchangeInfo{Symbols: []models.Symbol{{
+		Symbol: "ABCUSDT",
+		Filters: []models.Filter{
+			{FilterType: "PRICE_FILTER", MinPrice: "0.001", TickSize: "0.001"},
+			{FilterType: "LOT_SIZE", MinQty: "0.1", StepSize: "0.1"},
+		},
+	}}}
+	info.Symbols[0].Filters = append(info.Symbols[0].Filters, f.filters...)
+	return info, nil
+}
+
+func (f *fakeGuardExchange) GetTickerPrice(symbol string) (*models.TickerPrice, error) {
+	return &models.TickerPrice{Symbol: symbol, Price: f.price}, nil
+}
+
+func (f *fakeGuardExchange) CreateMarketOrder(req *exchange.MarketOrderRequest) (*models.OrderResponse, error) {
+	if req.ReduceOnly && f.closeErr != nil {
+		return nil, f.closeErr
+	}
+	f.orders = append(f.orders, req)
+	return &models.OrderResponse{Symbol: req.Symbol, Side: req.Side, Type: "MARKET", Status: "FILLED", AvgPrice: "10", ExecutedQty: "10"}, nil
+}
+
+func (f *fakeGuardExchange) CreateStopOrder(req *exchange.TriggerOrderRequest) (*models.OrderResponse, error) {
+	f.stopAttempts++
+	if f.stopAttempts <= f.stopFailures {
+		return nil, errors.New("Order would immediately trigger")
+	}
+	return &models.OrderResponse{Symbol: req.Symbol, Type: "STOP_MARKET", StopPrice: req.StopPrice}, nil
+}
+
+func (f *fakeGuardExchange) CreateTakeProfitOrder(req *exchange.TriggerOrderRequest) (*models.OrderResponse, error) {
+	f.takeProfits++
+	f.takeProfitReqs = append(f.takeProfitReqs, req)
+	return &models.OrderResponse{Symbol: req.Symbol, Type: "TAKE_PROFIT_MARKET", StopPrice: req.StopPrice}, nil
+}
+
+func newGuardTestService(t *testing.T, ex exchange.Exchange, stopLoss config.StopLossConfig) *TradingService {
+	t.Helper()
+	stopLoss.Enabled, stopLoss.Percent = true, 2
+	trading := config.TradingConfig{
+		DefaultNotional: "100",
+		PositionSide:    "BOTH",
+		StopLoss:        stopLoss,
+		TakeProfit:      config.TakeProfitConfig{Enabled: true, Percent: 5},
+	}
+	resolver, err := NewStrategyResolver(trading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newPositionTestService(ex)
+	ts.resolver = resolver
+	ts.config = &config.Config{Trading: trading}
+	return ts
+}
+
+func TestProtectEntryRetry(t *testing.T) {
+	stopLossRetryDelay = 0
+	ex := &fakeGuardExchange{price: "10.05", stopFailures: 2}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if orderSet.Status != OrderSetProtected || orderSet.StopLossOrder == nil || ex.stopAttempts != 3 {
+		t.Fatalf("重试2次后止损单应创建成功: status=%s attempts=%d", orderSet.Status, ex.stopAttempts)
+	}
+	if ex.takeProfits != 1 {
+		t.Errorf("止损单创建成功后应创建止盈单，实际: %d", ex.takeProfits)
+	}
+}
+
+func TestProtectEntryUnwind(t *testing.T) {
+	stopLossRetryDelay = 0
+	retries := 1
+	ex := &fakeGuardExchange{price: "10.05", stopFailures: 5}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{Retries: &retries})
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if orderSet.Status != OrderSetUnwound || orderSet.Open() || ex.stopAttempts != 2 {
+		t.Fatalf("止损单失败后应市价平仓: status=%s attempts=%d", orderSet.Status, ex.stopAttempts)
+	}
+	if len(ex.orders) != 2 {
+		t.Fatalf("应有开仓单和平仓单，实际: %d", len(ex.orders))
+	}
+	unwind := ex.orders[1]
+	if unwind.Side != "BUY" || !unwind.ReduceOnly || unwind.Quantity != "10.0" {
+		t.Errorf("平仓单应为只减仓的买单: %+v", unwind)
+	}
+	if ex.takeProfits != 0 {
+		t.Error("平仓后不应创建止盈单")
+	}
+}
+
+func TestProtectEntryPriceCrossed(t *testing.T) {
+	stopLossRetryDelay = 0
+	ex := &fakeGuardExchange{price: "10.5", stopFailures: 5}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+
+	orderSet, _ := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	// 当前价格已越过止损价10.2，不再重试，直接平仓
+	if ex.stopAttempts != 1 || orderSet.Status != OrderSetUnwound {
+		t.Errorf("价格越过止损价时应直接平仓: status=%s attempts=%d", orderSet.Status, ex.stopAttempts)
+	}
+}
+
+func TestProtectEntryPercentPrice(t *testing.T) {
+	stopLossRetryDelay = 0
+	// 止损价10.199超过标记价格10的1.01倍，PERCENT_PRICE不限制市价触发单，只记录警告
+	ex := &fakeGuardExchange{price: "10", filters: []models.Filter{{FilterType: models.FilterTypePercentPrice, MultiplierUp: "1.01", MultiplierDown: "0.99"}}}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if ex.stopAttempts != 1 || orderSet.StopLossOrder == nil || orderSet.StopLossOrder.StopPrice != "10.199" {
+		t.Fatalf("止损价超出PERCENT_PRICE范围时应按原触发价提交: attempts=%d order=%+v", ex.stopAttempts, orderSet.StopLossOrder)
+	}
+	if orderSet.Status != OrderSetProtected || len(ex.orders) != 1 {
+		t.Errorf("不应按止损单创建失败平仓: status=%s orders=%d", orderSet.Status, len(ex.orders))
+	}
+}
+
+func TestProtectEntryKeepAndUnwindFailed(t *testing.T) {
+	stopLossRetryDelay = 0
+	retries := 0
+
+	ex := &fakeGuardExchange{price: "10", stopFailures: 5}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{Retries: &retries, OnFailure: config.StopLossOnFailureKeep})
+	orderSet, _ := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if orderSet.Status != OrderSetUnprotected || !orderSet.Open() || len(ex.orders) != 1 || ex.takeProfits != 1 {
+		t.Errorf("on_failure=keep 时应保留仓位: status=%s orders=%d", orderSet.Status, len(ex.orders))
+	}
+
+	ex = &fakeGuardExchange{price: "10", stopFailures: 5, closeErr: errors.New("ReduceOnly Order is rejected")}
+	ts = newGuardTestService(t, ex, config.StopLossConfig{Retries: &retries})
+	orderSet, _ = ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if orderSet.Status != OrderSetUnwindFailed || orderSet.UnwindError == nil || !orderSet.Open() {
+		t.Errorf("平仓失败时状态应为unwind_failed: status=%s err=%v", orderSet.Status, orderSet.UnwindError)
+	}
+}
+
+// fakeNoPriceExchange 开仓成交后订单响应和行情都没有价格
+type fakeNoPriceExchange struct {
+	fakeGuardExchange
+	filled bool
+}
+
+func (f *fakeNoPriceExchange) GetTickerPrice(symbol string) (*models.TickerPrice, error) {
+	if f.filled {
+		return nil, errors.New("timeout")
+	}
+	return f.fakeGuardExchange.GetTickerPrice(symbol)
+}
+
+func (f *fakeNoPriceExchange) CreateMarketOrder(req *exchange.MarketOrderRequest) (*models.OrderResponse, error) {
+	order, err := f.fakeGuardExchange.CreateMarketOrder(req)
+	if err == nil && !req.ReduceOnly {
+		f.filled = true
+		order.AvgPrice = ""
+	}
+	return order, err
+}
+
+func TestProtectEntryNoEntryPrice(t *testing.T) {
+	ex := &fakeNoPriceExchange{fakeGuardExchange: fakeGuardExchange{price: "10"}}
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", PositionSide: "BOTH"}}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+	bus := events.NewBus(10)
+	ts.SetEventBus(bus)
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("已成交时不应返回错误: %v", err)
+	}
+	if orderSet.Status != OrderSetUnwound || orderSet.StopLossError == nil || len(ex.orders) != 2 || ex.orders[1].Quantity != "10" {
+		t.Fatalf("无法获取开仓价格时应按持仓数量市价平仓: status=%s orders=%+v", orderSet.Status, ex.orders)
+	}
+	if failed := bus.Recent(events.ParseFilter(events.TypeBracketFailed), 10); len(failed) != 1 {
+		t.Errorf("应发布 bracket_failed 事件，实际: %d", len(failed))
+	}
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// qtyEpsilon 判断是否已完全平仓时允许的数量误差
+const qtyEpsilon = 1e-9
+
+// stableCommissionAssets 按USDT计入盈亏的手续费资产（BNB抵扣的手续费不计入）
+var stableCommissionAssets = map[string]bool{
+	"USDT":  true,
+	"USDC":  true,
+	"BUSD":  true,
+	"FDUSD": true,
+}
+
+// TradeJournal 交易日志，记录每次开仓，配置文件路径时追加写入JSON Lines文件
+type TradeJournal struct {
+	mu      sync.Mutex
+	file    string
+	entries []models.JournalEntry
+	ids     map[string]bool
+}
+
+// NewTradeJournal 创建交易日志，file不为空时加载已有记录
+func NewTradeJournal(file string) (*TradeJournal, error) {
+	j := &TradeJournal{file: file, ids: make(map[string]bool)}
+	if file == "" {
+		return j, nil
+	}
+
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开交易日志失败: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry models.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("解析交易日志第 %d 行失败: %w", line, err)
+		}
+		if !j.ids[entry.ID] {
+			j.ids[entry.ID] = true
+			j.entries = append(j.entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取交易日志失败: %w", err)
+	}
+	logger.Infof("已加载 %d 条交易日志: %s", len(j.entries), file)
+	return j, nil
+}
+
+// Record 记录一次开仓，写文件失败时只记录错误（不影响下单流程）
+func (j *TradeJournal) Record(orderSet *OrderSet, onboardDate int64) {
+	if j == nil || orderSet.SellOrder == nil {
+		return
+	}
+
+	entryTime := orderSet.SellOrder.UpdateTime
+	if entryTime == 0 {
+		entryTime = time.Now().UnixMilli()
+	}
+	entry := models.JournalEntry{
+		ID:            bracketKey(orderSet),
+		Exchange:      orderSet.Exchange,
+		Account:       orderSet.Account,
+		Symbol:        orderSet.Symbol,
+		Direction:     orderSet.Direction,
+		Rule:          orderSet.Rule,
+		EntryOrderID:  orderSet.SellOrder.OrderID,
+		EntryOrderIDs: orderSet.SellOrder.MergedOrderIDs,
+		EntryTime:     entryTime,
+		Quantity:      orderSet.SellOrder.ExecutedQty,
+		OnboardDate:   onboardDate,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ids[entry.ID] {
+		return
+	}
+	j.ids[entry.ID] = true
+	j.entries = append(j.entries, entry)
+
+	if j.file != "" {
+		if err := j.appendToFile(entry); err != nil {
+			logger.Errorf("写入交易日志失败: %v", err)
+		}
+	}
+}
+
+// appendToFile 追加一条记录到日志文件
+func (j *TradeJournal) appendToFile(entry models.JournalEntry) error {
+	if dir := filepath.Dir(j.file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(j.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Entries 返回符合条件的开仓记录（按开仓时间排序）
+func (j *TradeJournal) Entries(query TradeQuery) []models.JournalEntry {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]models.JournalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		if query.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].EntryTime < entries[b].EntryTime
+	})
+	return entries
+}
+
+// latestEntry 某个账户某个币对某个方向最近一次开仓记录
+func (j *TradeJournal) latestEntry(exchangeName, account, symbol, direction string) (models.JournalEntry, bool) {
+	if j == nil {
+		return models.JournalEntry{}, false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var latest models.JournalEntry
+	found := false
+	for _, entry := range j.entries {
+		if entry.Exchange != exchangeName || entry.Account != account || entry.Symbol != symbol || entry.Direction != direction {
+			continue
+		}
+		if !found || entry.EntryTime > latest.EntryTime {
+			latest, found = entry, true
+		}
+	}
+	return latest, found
+}
+
+// TradeQuery 交易记录查询条件，零值表示全部
+type TradeQuery struct {
+	Account string // 账户名称
+	Symbol  string // 交易对
+	From    int64  // 开仓时间下限（毫秒，包含）
+	To      int64  // 开仓时间上限（毫秒，不包含）
+}
+
+// matches 开仓记录是否符合条件
+func (q TradeQuery) matches(entry models.JournalEntry) bool {
+	if q.Account != "" && entry.Account != q.Account {
+		return false
+	}
+	if q.Symbol != "" && entry.Symbol != q.Symbol {
+		return false
+	}
+	if q.From > 0 && entry.EntryTime < q.From {
+		return false
+	}
+	if q.To > 0 && entry.EntryTime >= q.To {
+		return false
+	}
+	return true
+}
+
+// GetJournalEntries 查询交易日志中的开仓记录
+func (ts *TradingService) GetJournalEntries(query TradeQuery) ([]models.JournalEntry, error) {
+	if ts.journal == nil {
+		return nil, fmt.Errorf("交易日志未启用")
+	}
+	return ts.journal.Entries(query), nil
+}
+
+// SetTradeJournal 设置交易日志，开仓成功后记录到日志
+func (ts *TradingService) SetTradeJournal(journal *TradeJournal) {
+	ts.journal = journal
+}
+
+// GetTrades 查询交易记录，从交易所成交历史和资金流水计算每次开仓的已实现盈亏
+func (ts *TradingService) GetTrades(query TradeQuery) ([]models.TradeRecord, error) {
+	if ts.journal == nil {
+		return nil, fmt.Errorf("交易日志未启用")
+	}
+
+	entries := ts.journal.Entries(query)
+	records := make([]models.TradeRecord, 0, len(entries))
+	for _, entry := range entries {
+		record, err := ts.buildTradeRecord(entry)
+		if err != nil {
+			logger.Warnf("计算 %s 的已实现盈亏失败: %v", entry.ID, err)
+			record = models.TradeRecord{JournalEntry: entry, Error: err.Error()}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetPnLSummary 汇总时间范围内开仓的已实现盈亏
+func (ts *TradingService) GetPnLSummary(query TradeQuery) (*models.PnLSummary, error) {
+	records, err := ts.GetTrades(query)
+	if err != nil {
+		return nil, err
+	}
+	summary := SummarizeTrades(records)
+	summary.From, summary.To = query.From, query.To
+	return summary, nil
+}
+
+// SummarizeTrades 汇总交易记录的盈亏
+func SummarizeTrades(records []models.TradeRecord) *models.PnLSummary {
+	summary := &models.PnLSummary{
+		BySymbol:  make(map[string]float64),
+		ByAccount: make(map[string]float64),
+	}
+	for _, r := range records {
+		summary.TradeCount++
+		if r.Closed {
+			summary.ClosedCount++
+		}
+		switch {
+		case r.NetPnL > 0:
+			summary.WinCount++
+		case r.NetPnL < 0:
+			summary.LossCount++
+		}
+		summary.RealizedPnL += r.RealizedPnL
+		summary.Commission += r.Commission
+		summary.Funding += r.Funding
+		summary.NetPnL += r.NetPnL
+		summary.BySymbol[r.Symbol] += r.NetPnL
+		account := r.Account
+		if account == "" {
+			account = r.Exchange
+		}
+		summary.ByAccount[account] += r.NetPnL
+	}
+	return summary
+}
+
+// buildTradeRecord 查询开仓记录对应的成交和资金费并计算盈亏
+func (ts *TradingService) buildTradeRecord(entry models.JournalEntry) (models.TradeRecord, error) {
+	ex, err := ts.exchangeFor(entry.Exchange, entry.Account)
+	if err != nil {
+		return models.TradeRecord{}, err
+	}
+	history, ok := ex.(exchange.TradeHistoryProvider)
+	if !ok {
+		return models.TradeRecord{}, fmt.Errorf("交易所 %s 不支持查询成交历史", ex.Name())
+	}
+
+	// 开仓单可能在记录时间之前几秒成交，向前多查一分钟
+	trades, err := history.GetUserTrades(entry.Symbol, entry.EntryTime-time.Minute.Milliseconds(), 0)
+	if err != nil {
+		return models.TradeRecord{}, fmt.Errorf("查询成交历史失败: %w", err)
+	}
+	record := MatchTrades(entry, trades)
+
+	fundingEnd := record.ExitTime
+	if !record.Closed {
+		fundingEnd = 0
+	}
+	incomes, err := history.GetIncomeHistory(entry.Symbol, models.IncomeTypeFundingFee, entry.EntryTime, fundingEnd)
+	if err != nil {
+		return models.TradeRecord{}, fmt.Errorf("查询资金费失败: %w", err)
+	}
+	for _, income := range incomes {
+		if v, err := strconv.ParseFloat(income.Income, 64); err == nil {
+			record.Funding += v
+		}
+	}
+	record.NetPnL = record.RealizedPnL - record.Commission + record.Funding
+	return record, nil
+}
+
+// exchangeFor 按交易所名称和账户查找下单时使用的交易所
+func (ts *TradingService) exchangeFor(exchangeName, account string) (exchange.Exchange, error) {
+	if exchangeName == exchange.NameBinance || exchangeName == "" {
+		acc, err := ts.accountByName(account)
+		if err != nil {
+			return nil, err
+		}
+		return acc.Exchange, nil
+	}
+	return ts.GetExchange(exchangeName)
+}
+
+// entryOrderIDs 开仓记录的全部开仓单ID，旧记录没有EntryOrderIDs时只有EntryOrderID
+func entryOrderIDs(entry models.JournalEntry) map[int64]bool {
+	ids := map[int64]bool{entry.EntryOrderID: true}
+	for _, id := range entry.EntryOrderIDs {
+		ids[id] = true
+	}
+	return ids
+}
+
+// MatchTrades 把开仓单的成交和之后的反向成交关联起来，平仓数量达到开仓数量为止
+// 未计算资金费（NetPnL = RealizedPnL - Commission）
+func MatchTrades(entry models.JournalEntry, trades []models.UserTrade) models.TradeRecord {
+	record := models.TradeRecord{JournalEntry: entry, ExitOrderIDs: []int64{}}
+	sort.SliceStable(trades, func(a, b int) bool {
+		return trades[a].Time < trades[b].Time
+	})
+
+	// 开仓成交（拆单或追价开仓时包含全部子单的成交）
+	entryOrders := entryOrderIDs(entry)
+	entrySide, positionSide := "", ""
+	entryQuote, entryFillTime := 0.0, int64(0)
+	for _, t := range trades {
+		if !entryOrders[t.OrderID] {
+			continue
+		}
+		qty, _ := strconv.ParseFloat(t.Qty, 64)
+		price, _ := strconv.ParseFloat(t.Price, 64)
+		record.EntryQty += qty
+		record.Fills = append(record.Fills, t)
+		entryQuote += qty * price
+		record.Commission += commissionUSDT(t)
+		entrySide, positionSide = t.Side, t.PositionSide
+		if entryFillTime == 0 {
+			entryFillTime = t.Time
+		}
+	}
+	if record.EntryQty == 0 {
+		record.NetPnL = -record.Commission
+		return record
+	}
+	record.EntryPrice = entryQuote / record.EntryQty
+
+	// 平仓成交：开仓之后、方向相反、持仓方向相同
+	exitQuote := 0.0
+	for _, t := range trades {
+		remaining := record.EntryQty - record.ExitQty
+		if remaining <= qtyEpsilon {
+			break
+		}
+		if t.Time < entryFillTime || entryOrders[t.OrderID] || t.Side == entrySide || t.PositionSide != positionSide {
+			continue
+		}
+		qty, _ := strconv.ParseFloat(t.Qty, 64)
+		price, _ := strconv.ParseFloat(t.Price, 64)
+		pnl, _ := strconv.ParseFloat(t.RealizedPnl, 64)
+		commission := commissionUSDT(t)
+		if qty <= 0 {
+			continue
+		}
+
+		// 成交数量超过剩余仓位时（反手开仓）按比例计入
+		ratio := 1.0
+		if qty > remaining {
+			ratio = remaining / qty
+			qty = remaining
+		}
+		record.ExitQty += qty
+		record.Fills = append(record.Fills, t)
+		exitQuote += qty * price
+		record.RealizedPnL += pnl * ratio
+		record.Commission += commission * ratio
+		record.ExitTime = t.Time
+		if n := len(record.ExitOrderIDs); n == 0 || record.ExitOrderIDs[n-1] != t.OrderID {
+			record.ExitOrderIDs = append(record.ExitOrderIDs, t.OrderID)
+		}
+	}
+	if record.ExitQty > 0 {
+		record.ExitPrice = exitQuote / record.ExitQty
+	}
+	record.Closed = math.Abs(record.EntryQty-record.ExitQty) <= qtyEpsilon
+	record.NetPnL = record.RealizedPnL - record.Commission
+	return record
+}
+
+// commissionUSDT 以USDT计的手续费，其他资产（例如BNB）返回0
+func commissionUSDT(t models.UserTrade) float64 {
+	if !stableCommissionAssets[t.CommissionAsset] {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(t.Commission, 64)
+	return v
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/models"
+)
+
+// fakeHistoryExchange 返回预设成交和资金流水
+type fakeHistoryExchange struct {
+	fakePositionExchange
+	trades  []models.UserTrade
+	incomes []models.Income
+}
+
+func (f *fakeHistoryExchange) GetUserTrades(symbol string, startTime, endTime int64) ([]models.UserTrade, error) {
+	return f.trades, nil
+}
+
+func (f *fakeHistoryExchange) GetIncomeHistory(symbol, incomeType string, startTime, endTime int64) ([]models.Income, error) {
+	return f.incomes, nil
+}
+
+var _ exchange.TradeHistoryProvider = (*fakeHistoryExchange)(nil)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMatchTrades(t *testing.T) {
+	entry := models.JournalEntry{Symbol: "ABCUSDT", EntryOrderID: 1, EntryTime: 1000}
+	trades := []models.UserTrade{
+		// 开仓单分两笔成交：做空 10 @ 2.0 和 10 @ 1.9
+		{OrderID: 1, Side: "SELL", PositionSide: "BOTH", Price: "2.0", Qty: "10", Commission: "0.01", CommissionAsset: "USDT", Time: 1000},
+		{OrderID: 1, Side: "SELL", PositionSide: "BOTH", Price: "1.9", Qty: "10", Commission: "0.01", CommissionAsset: "USDT", Time: 1001},
+		// 止盈平掉 15
+		{OrderID: 2, Side: "BUY", PositionSide: "BOTH", Price: "1.5", Qty: "15", RealizedPnl: "6.5", Commission: "0.02", CommissionAsset: "USDT", Time: 2000},
+		// BNB抵扣手续费不计入；平仓 10 中只有 5 属于本次开仓
+		{OrderID: 3, Side: "BUY", PositionSide: "BOTH", Price: "1.0", Qty: "10", RealizedPnl: "8", Commission: "0.001", CommissionAsset: "BNB", Time: 3000},
+		{OrderID: 4, Side: "BUY", PositionSide: "BOTH", Price: "1.0", Qty: "5", RealizedPnl: "1", Time: 4000},
+	}
+
+	record := MatchTrades(entry, trades)
+	if record.EntryQty != 20 || !almostEqual(record.EntryPrice, 1.95) {
+		t.Errorf("开仓数量/均价不正确: %v @ %v", record.EntryQty, record.EntryPrice)
+	}
+	if !record.Closed || record.ExitQty != 20 || record.ExitTime != 3000 {
+		t.Errorf("应在第3000毫秒完全平仓: closed=%v qty=%v time=%v", record.Closed, record.ExitQty, record.ExitTime)
+	}
+	if len(record.ExitOrderIDs) != 2 || record.ExitOrderIDs[0] != 2 || record.ExitOrderIDs[1] != 3 {
+		t.Errorf("平仓订单不正确: %v", record.ExitOrderIDs)
+	}
+	if !almostEqual(record.ExitPrice, 1.375) {
+		t.Errorf("平仓均价应为1.375，实际: %v", record.ExitPrice)
+	}
+	if !almostEqual(record.RealizedPnL, 10.5) || !almostEqual(record.Commission, 0.04) {
+		t.Errorf("已实现盈亏/手续费不正确: %v / %v", record.RealizedPnL, record.Commission)
+	}
+	if !almostEqual(record.NetPnL, 10.46) {
+		t.Errorf("净盈亏应为10.46，实际: %v", record.NetPnL)
+	}
+
+	open := MatchTrades(entry, trades[:3])
+	if open.Closed || open.ExitQty != 15 {
+		t.Errorf("部分平仓不应标记为已平仓: closed=%v qty=%v", open.Closed, open.ExitQty)
+	}
+}
+
+func TestTradeJournalPersistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data", "journal.jsonl")
+	journal, err := NewTradeJournal(file)
+	if err != nil {
+		t.Fatalf("创建交易日志失败: %v", err)
+	}
+
+	orderSet := &OrderSet{Symbol: "ABCUSDT", Exchange: "binance", Account: "main", Direction: "SHORT",
+		SellOrder: &models.OrderResponse{OrderID: 1, UpdateTime: 1000}}
+	journal.Record(orderSet, 900)
+	journal.Record(orderSet, 900) // 重复记录忽略
+	journal.Record(&OrderSet{Symbol: "XYZUSDT", Exchange: "binance", Account: "sub1",
+		SellOrder: &models.OrderResponse{OrderID: 2, UpdateTime: 2000}}, 0)
+
+	reloaded, err := NewTradeJournal(file)
+	if err != nil {
+		t.Fatalf("重新加载交易日志失败: %v", err)
+	}
+	entries := reloaded.Entries(TradeQuery{})
+	if len(entries) != 2 || entries[0].ID != "binance/main/ABCUSDT/1" || entries[0].OnboardDate != 900 {
+		t.Fatalf("重新加载的记录不正确: %+v", entries)
+	}
+	if got := reloaded.Entries(TradeQuery{Account: "sub1"}); len(got) != 1 || got[0].Symbol != "XYZUSDT" {
+		t.Errorf("按账户筛选不正确: %+v", got)
+	}
+	if got := reloaded.Entries(TradeQuery{From: 1500}); len(got) != 1 || got[0].EntryOrderID != 2 {
+		t.Errorf("按开仓时间筛选不正确: %+v", got)
+	}
+}
+
+func TestGetPnLSummary(t *testing.T) {
+	ex := &fakeHistoryExchange{
+		trades: []models.UserTrade{
+			{OrderID: 1, Side: "SELL", Price: "2", Qty: "10", Commission: "0.02", CommissionAsset: "USDT", Time: 1000},
+			{OrderID: 2, Side: "BUY", Price: "1", Qty: "10", RealizedPnl: "10", Commission: "0.01", CommissionAsset: "USDT", Time: 5000},
+		},
+		incomes: []models.Income{{IncomeType: models.IncomeTypeFundingFee, Income: "-0.5"}},
+	}
+	ts := newPositionTestService(ex)
+	journal, _ := NewTradeJournal("")
+	ts.SetTradeJournal(journal)
+	journal.Record(&OrderSet{Symbol: "ABCUSDT", Exchange: "binance", Account: "main",
+		SellOrder: &models.OrderResponse{OrderID: 1, UpdateTime: 1000}}, 0)
+
+	summary, err := ts.GetPnLSummary(TradeQuery{})
+	if err != nil {
+		t.Fatalf("汇总盈亏失败: %v", err)
+	}
+	if summary.TradeCount != 1 || summary.ClosedCount != 1 || summary.WinCount != 1 {
+		t.Errorf("交易次数统计不正确: %+v", summary)
+	}
+	if !almostEqual(summary.NetPnL, 9.47) || !almostEqual(summary.BySymbol["ABCUSDT"], 9.47) || !almostEqual(summary.ByAccount["main"], 9.47) {
+		t.Errorf("净盈亏应为 10 - 0.03 - 0.5 = 9.47，实际: %+v", summary)
+	}
+}
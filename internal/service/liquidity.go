@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// DefaultLiquidityDepthBps 默认统计最优价50个基点以内的深度
+const DefaultLiquidityDepthBps = 50
+
+// liquidityDepthLimit 查询盘口的档位数量
+const liquidityDepthLimit = 100
+
+// 流动性检查结果
+const (
+	LiquidityPass     = "pass"     // 通过，按原金额开仓
+	LiquidityDownsize = "downsize" // 深度不足，按深度减少开仓金额
+	LiquiditySkip     = "skip"     // 深度不足或价差过大，跳过开仓
+)
+
+// LiquidityCheck 开仓前的盘口流动性检查结果和测量值
+type LiquidityCheck struct {
+	Decision          string  `json:"decision"`         // pass/downsize/skip
+	Reason            string  `json:"reason,omitempty"` // 减少金额或跳过的原因
+	BestBid           float64 `json:"best_bid"`
+	BestAsk           float64 `json:"best_ask"`
+	SpreadBps         float64 `json:"spread_bps"`         // 买一卖一价差（基点，相对中间价）
+	DepthBps          float64 `json:"depth_bps"`          // 统计深度的价格范围（基点）
+	DepthNotional     float64 `json:"depth_notional"`     // 对手方向（做空为买盘）范围内的深度（USDT）
+	RequestedNotional float64 `json:"requested_notional"` // 原开仓金额
+	Notional          float64 `json:"notional"`           // 实际开仓金额，跳过时为0
+}
+
+// LiquiditySkipError 流动性检查未通过，跳过开仓
+type LiquiditySkipError struct {
+	Check *LiquidityCheck
+}
+
+func (e *LiquiditySkipError) Error() string {
+	return "流动性检查未通过，跳过开仓: " + e.Check.Reason
+}
+
+// liquidityEnabled 是否配置了流动性检查
+func liquidityEnabled(liquidity config.LiquidityConfig) bool {
+	return liquidity.MinDepthMultiple > 0 || liquidity.MaxSpreadBps > 0
+}
+
+// checkLiquidity 按 trading.entry.liquidity 检查盘口深度和价差，返回检查结果（未配置或交易所不支持时为nil）
+// 跳过开仓时返回 *LiquiditySkipError；查询盘口失败时只记录警告，不阻止开仓
+func (ts *TradingService) checkLiquidity(ex exchange.Exchange, strategy *Strategy, notionalUSDT string) (*LiquidityCheck, error) {
+	liquidity := ts.getConfig().Trading.Entry.Liquidity
+	if !liquidityEnabled(liquidity) {
+		return nil, nil
+	}
+	provider, ok := ex.(exchange.DepthProvider)
+	if !ok {
+		logger.Warnf("交易所 %s 不支持查询盘口深度，跳过流动性检查", ex.Name())
+		return nil, nil
+	}
+	notional, err := strconv.ParseFloat(notionalUSDT, 64)
+	if err != nil || notional <= 0 {
+		return nil, fmt.Errorf("无效的USDT金额: %s", notionalUSDT)
+	}
+
+	depth, err := provider.GetDepth(strategy.Symbol, liquidityDepthLimit)
+	if err != nil {
+		logger.Warnf("查询 %s 盘口深度失败，跳过流动性检查: %v", strategy.Symbol, err)
+		return nil, nil
+	}
+
+	check := measureLiquidity(depth, strategy.EntrySide(), liquidity.DepthBps)
+	check.RequestedNotional = notional
+	check.Notional = notional
+	evaluateLiquidity(check, liquidity)
+
+	logger.Infof("%s 流动性检查: %s, 价差 %.1f 个基点, %g 个基点内深度 %.2f USDT, 开仓金额 %.2f -> %.2f USDT",
+		strategy.Symbol, check.Decision, check.SpreadBps, check.DepthBps, check.DepthNotional, check.RequestedNotional, check.Notional)
+	if check.Decision == LiquiditySkip {
+		return check, &LiquiditySkipError{Check: check}
+	}
+	return check, nil
+}
+
+// entryPrecheck 多账户下单前按合计金额完成的检查结果（各账户的金额已按比例分摊）
+type entryPrecheck struct {
+	Liquidity    *LiquidityCheck // 该账户的流动性检查结果，未检查时为nil
+	LiquidityErr error           // 跳过开仓时为 *LiquiditySkipError
+}
+
+// checkAccountsLiquidity 各账户在同一盘口开仓，分别检查会重复计算同一份深度，
+// 因此按各账户金额的合计检查一次，减少金额时按各账户金额的比例分摊
+// 金额无效时各账户的结果为nil，由各账户下单前自行检查（返回金额无效的错误）
+func (ts *TradingService) checkAccountsLiquidity(accounts []*Account, strategy *Strategy, notionalUSDT string) []*entryPrecheck {
+	prechecks := make([]*entryPrecheck, len(accounts))
+	for i := range prechecks {
+		prechecks[i] = &entryPrecheck{}
+	}
+	if len(accounts) == 0 || !liquidityEnabled(ts.getConfig().Trading.Entry.Liquidity) {
+		return prechecks
+	}
+	notional, err := strconv.ParseFloat(notionalUSDT, 64)
+	if err != nil || notional <= 0 {
+		return make([]*entryPrecheck, len(accounts))
+	}
+
+	total := 0.0
+	for _, acc := range accounts {
+		total += notional * acc.NotionalMultiplier
+	}
+	if len(accounts) > 1 {
+		logger.Infof("%s 按 %d 个账户的合计开仓金额 %.2f USDT 检查流动性", strategy.Symbol, len(accounts), total)
+	}
+	check, err := ts.checkLiquidity(accounts[0].Exchange, strategy, strconv.FormatFloat(total, 'f', -1, 64))
+	if check == nil {
+		for _, precheck := range prechecks {
+			precheck.LiquidityErr = err
+		}
+		return prechecks
+	}
+
+	for i, acc := range accounts {
+		accountCheck := *check
+		accountCheck.RequestedNotional = notional * acc.NotionalMultiplier
+		accountCheck.Notional = accountCheck.RequestedNotional * check.Notional / check.RequestedNotional
+		prechecks[i].Liquidity = &accountCheck
+		if check.Decision == LiquiditySkip {
+			prechecks[i].LiquidityErr = &LiquiditySkipError{Check: &accountCheck}
+		}
+	}
+	return prechecks
+}
+
+// measureLiquidity 计算买一卖一价差和对手方向最优价depthBps个基点以内的深度（卖出统计买盘，买入统计卖盘）
+func measureLiquidity(depth *models.Depth, side string, depthBps float64) *LiquidityCheck {
+	if depthBps <= 0 {
+		depthBps = DefaultLiquidityDepthBps
+	}
+	check := &LiquidityCheck{DepthBps: depthBps}
+	check.BestBid = levelPrice(depth.Bids)
+	check.BestAsk = levelPrice(depth.Asks)
+	if check.BestBid > 0 && check.BestAsk > 0 {
+		mid := (check.BestBid + check.BestAsk) / 2
+		check.SpreadBps = (check.BestAsk - check.BestBid) / mid * 10000
+	}
+
+	levels, limit := depth.Bids, check.BestBid*(1-depthBps/10000)
+	if side == "BUY" {
+		levels, limit = depth.Asks, check.BestAsk*(1+depthBps/10000)
+	}
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(level[0], 64)
+		qty, _ := strconv.ParseFloat(level[1], 64)
+		if (side == "BUY" && price > limit) || (side != "BUY" && price < limit) {
+			break
+		}
+		check.DepthNotional += price * qty
+	}
+	return check
+}
+
+// evaluateLiquidity 按配置决定通过、减少金额或跳过
+func evaluateLiquidity(check *LiquidityCheck, liquidity config.LiquidityConfig) {
+	check.Decision = LiquidityPass
+	switch {
+	case check.BestBid <= 0 || check.BestAsk <= 0:
+		check.Decision, check.Reason = LiquiditySkip, "盘口为空"
+	case liquidity.MaxSpreadBps > 0 && check.SpreadBps > liquidity.MaxSpreadBps:
+		check.Decision = LiquiditySkip
+		check.Reason = fmt.Sprintf("价差 %.1f 个基点超过上限 %g", check.SpreadBps, liquidity.MaxSpreadBps)
+	case liquidity.MinDepthMultiple > 0 && check.DepthNotional < check.RequestedNotional*liquidity.MinDepthMultiple:
+		reason := fmt.Sprintf("%g 个基点内深度 %.2f USDT 低于开仓金额的 %g 倍", check.DepthBps, check.DepthNotional, liquidity.MinDepthMultiple)
+		allowed := check.DepthNotional / liquidity.MinDepthMultiple
+		if liquidity.Action == config.LiquidityActionDownsize && allowed > 0 {
+			check.Decision, check.Notional = LiquidityDownsize, allowed
+			check.Reason = fmt.Sprintf("%s，开仓金额减少为 %.2f USDT", reason, allowed)
+			return
+		}
+		check.Decision, check.Reason = LiquiditySkip, reason
+	}
+	if check.Decision == LiquiditySkip {
+		check.Notional = 0
+	}
+}
+
+// levelPrice 盘口第一档的价格，没有挂单时为0
+func levelPrice(levels [][]string) float64 {
+	if len(levels) == 0 || len(levels[0]) == 0 {
+		return 0
+	}
+	price, _ := strconv.ParseFloat(levels[0][0], 64)
+	return price
+}
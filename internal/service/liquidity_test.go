@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/models"
+)
+
+// fakeDepthExchange 支持查询盘口深度的交易所
+type fakeDepthExchange struct {
+	fakeGuardExchange
+	depth models.Depth
+}
+
+func (f *fakeDepthExchange) GetDepth(symbol string, limit int) (*models.Depth, error) {
+	return &f.depth, nil
+}
+
+// testDepth 买一10、卖一10.01，买盘在10和9.95各有100个，9.9有1000个
+func testDepth() models.Depth {
+	return models.Depth{
+		Bids: [][]string{{"10", "100"}, {"9.95", "100"}, {"9.9", "1000"}},
+		Asks: [][]string{{"10.01", "50"}, {"10.1", "500"}},
+	}
+}
+
+func TestMeasureLiquidity(t *testing.T) {
+	depth := testDepth()
+
+	// 做空统计买一价以下50个基点（9.95）以内的买盘
+	check := measureLiquidity(&depth, "SELL", 0)
+	if check.DepthBps != DefaultLiquidityDepthBps || check.DepthNotional != 1995 {
+		t.Errorf("做空应统计9.95以上的买盘深度1995，实际: %+v", check)
+	}
+	if check.SpreadBps < 9.99 || check.SpreadBps > 10.01 {
+		t.Errorf("价差应约为10个基点，实际: %g", check.SpreadBps)
+	}
+
+	// 做多统计卖一价以上50个基点以内的卖盘
+	check = measureLiquidity(&depth, "BUY", 50)
+	if check.DepthNotional != 500.5 {
+		t.Errorf("做多应只统计10.01的卖盘深度500.5，实际: %g", check.DepthNotional)
+	}
+}
+
+func TestEvaluateLiquidity(t *testing.T) {
+	tests := []struct {
+		name      string
+		liquidity config.LiquidityConfig
+		decision  string
+		notional  float64
+	}{
+		{"深度充足", config.LiquidityConfig{MinDepthMultiple: 10}, LiquidityPass, 100},
+		{"深度不足跳过", config.LiquidityConfig{MinDepthMultiple: 40}, LiquiditySkip, 0},
+		{"深度不足减少金额", config.LiquidityConfig{MinDepthMultiple: 40, Action: config.LiquidityActionDownsize}, LiquidityDownsize, 49.875},
+		{"价差过大", config.LiquidityConfig{MaxSpreadBps: 5, Action: config.LiquidityActionDownsize}, LiquiditySkip, 0},
+	}
+	for _, tt := range tests {
+		depth := testDepth()
+		check := measureLiquidity(&depth, "SELL", 0)
+		check.RequestedNotional, check.Notional = 100, 100
+		evaluateLiquidity(check, tt.liquidity)
+		if check.Decision != tt.decision || check.Notional != tt.notional {
+			t.Errorf("%s: 期望 %s/%g，实际 %s/%g (%s)", tt.name, tt.decision, tt.notional, check.Decision, check.Notional, check.Reason)
+		}
+	}
+
+	check := &LiquidityCheck{RequestedNotional: 100, Notional: 100}
+	evaluateLiquidity(check, config.LiquidityConfig{MinDepthMultiple: 1})
+	if check.Decision != LiquiditySkip {
+		t.Errorf("盘口为空时应跳过开仓，实际: %s", check.Decision)
+	}
+}
+
+func TestCreateOrderSetLiquidity(t *testing.T) {
+	ex := &fakeDepthExchange{fakeGuardExchange: fakeGuardExchange{price: "10"}, depth: testDepth()}
+	ts, bus := newEntryTestService(t, ex, config.EntryConfig{Liquidity: config.LiquidityConfig{MinDepthMultiple: 40}})
+
+	_, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "100")
+	var skipErr *LiquiditySkipError
+	if !errors.As(err, &skipErr) || skipErr.Check.DepthNotional != 1995 {
+		t.Fatalf("深度不足时应返回带测量值的跳过错误，实际: %v", err)
+	}
+	if len(ex.orders) != 0 {
+		t.Errorf("跳过开仓时不应下单，实际: %d", len(ex.orders))
+	}
+	if breached := bus.Recent(events.ParseFilter(events.TypeRiskLimitBreached), 10); len(breached) != 1 {
+		t.Errorf("跳过开仓时应发布1个风控事件，实际: %d", len(breached))
+	}
+
+	// downsize 时按深度减少开仓金额
+	ex = &fakeDepthExchange{fakeGuardExchange: fakeGuardExchange{price: "10"}, depth: testDepth()}
+	ts, _ = newEntryTestService(t, ex, config.EntryConfig{Liquidity: config.LiquidityConfig{MinDepthMultiple: 40, Action: config.LiquidityActionDownsize}})
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "100")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if len(ex.orders) == 0 || ex.orders[0].Notional != "49.88" {
+		t.Fatalf("开仓金额应减少为49.88，实际: %+v", ex.orders)
+	}
+	if orderSet.Liquidity == nil || orderSet.Liquidity.Decision != LiquidityDownsize {
+		t.Errorf("订单结果应记录流动性检查结果: %+v", orderSet.Liquidity)
+	}
+}
+
+func TestCreateOrdersForAccountsLiquidity(t *testing.T) {
+	mainEx := &fakeDepthExchange{fakeGuardExchange: fakeGuardExchange{price: "10"}, depth: testDepth()}
+	subEx := &fakeDepthExchange{fakeGuardExchange: fakeGuardExchange{price: "10"}, depth: testDepth()}
+	ts, bus := newEntryTestService(t, mainEx, config.EntryConfig{Liquidity: config.LiquidityConfig{MinDepthMultiple: 20, Action: config.LiquidityActionDownsize}})
+	ts.accounts = []*Account{{Name: "main", Exchange: mainEx, NotionalMultiplier: 1}, {Name: "sub", Exchange: subEx, NotionalMultiplier: 1}}
+
+	// 单个账户50 USDT只需要1000深度，两个账户合计100 USDT需要2000，超过深度1995
+	results, err := ts.CreateOrdersForAccounts(ts.ResolveStrategy("ABCUSDT", 0), "50", nil)
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	for i, ex := range []*fakeDepthExchange{mainEx, subEx} {
+		if results[i].Err != nil || len(ex.orders) == 0 || ex.orders[0].Notional != "49.88" {
+			t.Fatalf("合计深度不足时应按比例减少各账户金额为49.88: %v %+v", results[i].Err, ex.orders)
+		}
+		if check := results[i].OrderSet.Liquidity; check == nil || check.RequestedNotional != 50 || check.Decision != LiquidityDownsize {
+			t.Errorf("账户结果应记录该账户的流动性检查: %+v", check)
+		}
+	}
+
+	// 合计金额超过深度时各账户都跳过
+	mainEx.orders, subEx.orders = nil, nil
+	ts.config.Trading.Entry.Liquidity.Action = ""
+	results, _ = ts.CreateOrdersForAccounts(ts.ResolveStrategy("ABCUSDT", 0), "50", nil)
+	var skipErr *LiquiditySkipError
+	if !errors.As(results[0].Err, &skipErr) || !errors.As(results[1].Err, &skipErr) || len(mainEx.orders)+len(subEx.orders) != 0 {
+		t.Errorf("合计深度不足时各账户都应跳过开仓: %v %v", results[0].Err, results[1].Err)
+	}
+	breached := bus.Recent(events.ParseFilter(events.TypeRiskLimitBreached), 10)
+	if len(breached) != 2 || breached[0].Account == breached[1].Account {
+		t.Errorf("每个跳过的账户应各发布1个风控事件，实际: %+v", breached)
+	}
+}
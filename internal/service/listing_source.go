@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// 新币来源标识
+const (
+	SourceExchangeInfo = "exchange_info" // exchangeInfo轮询（内置）
+	SourceAnnouncement = "announcement"  // 公告解析
+	SourceWebhook      = "webhook"       // webhook推送
+	SourceManual       = "manual"        // 手动添加（模拟接口）
+	// 其他交易所的合约列表以交易所名称作为来源（bybit/okx）
+)
+
+// ListingSink 接收来源发现的新币对
+type ListingSink func(listings []*models.NewListingSymbol)
+
+// ListingSource 新币来源
+// Run 阻塞运行直到ctx取消，发现的新币对统一归一化为NewListingSymbol并交给sink
+// exchangeInfo轮询不实现该接口：它维护全量币对表、负责首轮同步与确认其他来源的新币，
+// 并根据待上线新币调整轮询间隔，这些都依赖SymbolMonitor内部状态，无法只通过sink表达
+type ListingSource interface {
+	Name() string
+	Run(ctx context.Context, sink ListingSink) error
+}
+
+// WebhookListingPayload webhook推送的新币数据
+type WebhookListingPayload struct {
+	Symbol      string   `json:"symbol,omitempty"`       // 单个币对
+	Symbols     []string `json:"symbols,omitempty"`      // 多个币对
+	OnboardDate int64    `json:"onboard_date,omitempty"` // 上线时间（毫秒时间戳），未知填0
+	Provider    string   `json:"provider,omitempty"`     // 推送方标识，记录在Source中
+	Confidence  float64  `json:"confidence,omitempty"`   // 置信度（0~1），留空默认0.5
+}
+
+// WebhookSource webhook推送来源，由HTTP接口调用Ingest写入
+type WebhookSource struct {
+	mu   sync.RWMutex
+	sink ListingSink
+}
+
+// NewWebhookSource 创建webhook来源
+func NewWebhookSource() *WebhookSource {
+	return &WebhookSource{}
+}
+
+// Name 来源名称
+func (ws *WebhookSource) Name() string {
+	return SourceWebhook
+}
+
+// Run 注册sink并等待ctx取消
+func (ws *WebhookSource) Run(ctx context.Context, sink ListingSink) error {
+	ws.mu.Lock()
+	ws.sink = sink
+	ws.mu.Unlock()
+
+	<-ctx.Done()
+
+	ws.mu.Lock()
+	ws.sink = nil
+	ws.mu.Unlock()
+	return nil
+}
+
+// Ingest 接收一次webhook推送，返回写入的币对数量
+func (ws *WebhookSource) Ingest(payload *WebhookListingPayload) (int, error) {
+	ws.mu.RLock()
+	sink := ws.sink
+	ws.mu.RUnlock()
+	if sink == nil {
+		return 0, fmt.Errorf("webhook来源未启动")
+	}
+
+	symbols := payload.Symbols
+	if payload.Symbol != "" {
+		symbols = append(symbols, payload.Symbol)
+	}
+	if len(symbols) == 0 {
+		return 0, fmt.Errorf("请提供币对(symbol)或币对列表(symbols)")
+	}
+
+	confidence := payload.Confidence
+	if confidence <= 0 || confidence > 1 {
+		confidence = 0.5
+	}
+	source := SourceWebhook
+	if payload.Provider != "" {
+		source = SourceWebhook + ":" + payload.Provider
+	}
+
+	now := time.Now()
+	listings := make([]*models.NewListingSymbol, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+		listings = append(listings, &models.NewListingSymbol{
+			Symbol:      symbol,
+			OnboardDate: payload.OnboardDate,
+			Status:      "ANNOUNCED",
+			FoundTime:   now,
+			Source:      source,
+			Confidence:  confidence,
+		})
+	}
+
+	sink(listings)
+	return len(listings), nil
+}
+
+// ExchangeListingSource 轮询其他交易所的合约列表，发现新上线的合约
+type ExchangeListingSource struct {
+	ex       exchange.Exchange
+	interval time.Duration
+	known    map[string]bool // 已知合约
+}
+
+// NewExchangeListingSource 创建交易所合约列表来源
+func NewExchangeListingSource(ex exchange.Exchange, interval time.Duration) *ExchangeListingSource {
+	if interval <= 0 {
+		interval = 2 * time.Minute
+	}
+	return &ExchangeListingSource{
+		ex:       ex,
+		interval: interval,
+		known:    make(map[string]bool),
+	}
+}
+
+// Name 来源名称（交易所名称）
+func (es *ExchangeListingSource) Name() string {
+	return es.ex.Name()
+}
+
+// Run 定时拉取合约列表
+// 首次拉取只上报尚未上线（OnboardDate大于当前时间）的合约，之后上报所有新出现的合约
+func (es *ExchangeListingSource) Run(ctx context.Context, sink ListingSink) error {
+	ticker := time.NewTicker(es.interval)
+	defer ticker.Stop()
+
+	initialized := false
+	for {
+		if err := es.poll(sink, !initialized); err != nil {
+			logger.Errorf("拉取 %s 合约列表失败: %v", es.ex.Name(), err)
+		} else {
+			initialized = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll 拉取并比对一次合约列表
+func (es *ExchangeListingSource) poll(sink ListingSink, isInitial bool) error {
+	exchangeInfo, err := es.ex.GetExchangeInfo()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var listings []*models.NewListingSymbol
+	for _, symbol := range exchangeInfo.Symbols {
+		if symbol.Status != "TRADING" && symbol.Status != "PENDING_TRADING" {
+			continue
+		}
+		if es.known[symbol.Symbol] {
+			continue
+		}
+		es.known[symbol.Symbol] = true
+
+		if isInitial && symbol.OnboardDate <= now.UnixMilli() {
+			continue
+		}
+		listings = append(listings, &models.NewListingSymbol{
+			Symbol:      symbol.Symbol,
+			OnboardDate: symbol.OnboardDate,
+			Status:      symbol.Status,
+			FoundTime:   now,
+			Source:      es.ex.Name(),
+			Confidence:  1,
+		})
+	}
+
+	if len(listings) > 0 {
+		sink(listings)
+	}
+	return nil
+}
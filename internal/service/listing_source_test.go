@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/models"
+)
+
+func TestParseAnnouncementJSON(t *testing.T) {
+	body, err := os.ReadFile("testdata/binance_announcements.json")
+	if err != nil {
+		t.Fatalf("读取fixture失败: %v", err)
+	}
+
+	listings, err := ParseAnnouncementJSON(body)
+	if err != nil {
+		t.Fatalf("解析公告失败: %v", err)
+	}
+
+	got := make(map[string]*models.NewListingSymbol)
+	for _, listing := range listings {
+		got[listing.Symbol] = listing
+	}
+	if len(got) != 3 {
+		t.Fatalf("期望解析出3个合约币对，实际: %v", listings)
+	}
+	for _, symbol := range []string{"ZORAUSDT", "ESPORTSUSDT", "TAUSDT"} {
+		listing, ok := got[symbol]
+		if !ok {
+			t.Fatalf("缺少币对 %s", symbol)
+		}
+		if listing.Source != SourceAnnouncement || listing.Confidence != 0.9 {
+			t.Errorf("%s 来源/置信度不正确: %s %.2f", symbol, listing.Source, listing.Confidence)
+		}
+	}
+	if want := time.UnixMilli(1752490800000); !got["ZORAUSDT"].FoundTime.Equal(want) {
+		t.Errorf("发现时间应为公告发布时间 %v，实际 %v", want, got["ZORAUSDT"].FoundTime)
+	}
+}
+
+func TestParseAnnouncementRSS(t *testing.T) {
+	body, err := os.ReadFile("testdata/binance_announcements.rss")
+	if err != nil {
+		t.Fatalf("读取fixture失败: %v", err)
+	}
+
+	listings, err := ParseAnnouncementRSS(body)
+	if err != nil {
+		t.Fatalf("解析公告失败: %v", err)
+	}
+	if len(listings) != 1 || listings[0].Symbol != "ZORAUSDT" {
+		t.Fatalf("期望只解析出ZORAUSDT，实际: %v", listings)
+	}
+
+	wantOnboard := time.Date(2025, 7, 15, 11, 30, 0, 0, time.UTC).UnixMilli()
+	if listings[0].OnboardDate != wantOnboard {
+		t.Errorf("上线时间解析错误: 期望 %d，实际 %d", wantOnboard, listings[0].OnboardDate)
+	}
+}
+
+func TestIngestListingsMergesSources(t *testing.T) {
+	sm := NewSymbolMonitor()
+	webhook := NewWebhookSource()
+	sm.AddSource(webhook)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go webhook.Run(ctx, sm.ingestListings)
+
+	// 等待webhook来源注册sink
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := webhook.Ingest(&WebhookListingPayload{Symbol: "zorausdt", Provider: "tg"}); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("webhook来源未启动")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	listing, ok := sm.GetNewListing("ZORAUSDT")
+	if !ok {
+		t.Fatal("webhook推送的币对未写入新币列表")
+	}
+	if listing.Source != "webhook:tg" || listing.Confidence != 0.5 {
+		t.Errorf("来源/置信度不正确: %s %.2f", listing.Source, listing.Confidence)
+	}
+
+	// 公告带来更高的置信度和上线时间，来源保持首次发现的webhook
+	sm.ingestListings([]*models.NewListingSymbol{{
+		Symbol:      "ZORAUSDT",
+		OnboardDate: 1752579000000,
+		Source:      SourceAnnouncement,
+		Confidence:  0.9,
+	}})
+	listing, _ = sm.GetNewListing("ZORAUSDT")
+	if listing.Confidence != 0.9 || listing.OnboardDate != 1752579000000 || listing.Source != "webhook:tg" {
+		t.Errorf("合并结果不正确: %+v", listing)
+	}
+}
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// 持仓排序字段
+const (
+	PositionSortPnL                 = "pnl"                  // 未实现盈亏
+	PositionSortPnLPercent          = "pnl_percent"          // 收益率
+	PositionSortNotional            = "notional"             // 名义价值（绝对值）
+	PositionSortLiquidationDistance = "liquidation_distance" // 距强平价格百分比
+)
+
+// 持仓盈亏筛选
+const (
+	PositionSignPositive = "positive" // 只看盈利仓位
+	PositionSignNegative = "negative" // 只看亏损仓位
+)
+
+// 排序方向
+const (
+	positionSortOrderAsc  = "asc"
+	positionSortOrderDesc = "desc"
+)
+
+// PositionQuery 持仓查询条件，零值表示查询所有账户的所有持仓并按未实现盈亏从小到大排序
+type PositionQuery struct {
+	Account     string   // 账户名称，留空查询所有账户
+	Sign        string   // positive/negative，留空不按盈亏筛选
+	Symbols     []string // 币对，留空不筛选
+	MinNotional float64  // 最小名义价值（绝对值，USDT）
+	SortBy      string   // 排序字段，默认pnl
+	Order       string   // asc/desc，默认名义价值从大到小，其余从小到大
+}
+
+// Validate 检查查询条件
+func (q *PositionQuery) Validate() error {
+	switch q.Sign {
+	case "", PositionSignPositive, PositionSignNegative:
+	default:
+		return fmt.Errorf("sign必须是 %s 或 %s", PositionSignPositive, PositionSignNegative)
+	}
+	switch q.SortBy {
+	case "", PositionSortPnL, PositionSortPnLPercent, PositionSortNotional, PositionSortLiquidationDistance:
+	default:
+		return fmt.Errorf("sort必须是 %s/%s/%s/%s 之一", PositionSortPnL, PositionSortPnLPercent, PositionSortNotional, PositionSortLiquidationDistance)
+	}
+	switch q.Order {
+	case "", positionSortOrderAsc, positionSortOrderDesc:
+	default:
+		return fmt.Errorf("order必须是 %s 或 %s", positionSortOrderAsc, positionSortOrderDesc)
+	}
+	if q.MinNotional < 0 {
+		return fmt.Errorf("min_notional不能为负数")
+	}
+	return nil
+}
+
+// GetPositions 按条件查询持仓，返回筛选、排序后的仓位及汇总
+func (ts *TradingService) GetPositions(query PositionQuery) (*models.PositionsResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	accounts, err := ts.selectAccounts(query.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]models.Position, 0)
+	for _, acc := range accounts {
+		accPositions, err := ts.getPositions(acc)
+		if err != nil {
+			return nil, fmt.Errorf("账户 %s: %w", acc.Name, err)
+		}
+		positions = append(positions, accPositions...)
+	}
+
+	positions = FilterPositions(positions, query)
+	SortPositions(positions, query.SortBy, query.Order)
+	return &models.PositionsResponse{
+		TotalCount: len(positions),
+		Summary:    SummarizePositions(positions),
+		Positions:  positions,
+	}, nil
+}
+
+// FilterPositions 按盈亏方向、币对和最小名义价值筛选仓位
+func FilterPositions(positions []models.Position, query PositionQuery) []models.Position {
+	symbols := make(map[string]bool, len(query.Symbols))
+	for _, symbol := range query.Symbols {
+		symbols[strings.ToUpper(symbol)] = true
+	}
+
+	filtered := make([]models.Position, 0, len(positions))
+	for _, p := range positions {
+		if query.Sign == PositionSignPositive && p.UnRealizedProfit <= 0 {
+			continue
+		}
+		if query.Sign == PositionSignNegative && p.UnRealizedProfit >= 0 {
+			continue
+		}
+		if len(symbols) > 0 && !symbols[p.Symbol] {
+			continue
+		}
+		if math.Abs(p.Notional) < query.MinNotional {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
+// SortPositions 按指定字段排序，没有强平价格的仓位按距强平价格排序时总是排在最后
+func SortPositions(positions []models.Position, sortBy, order string) {
+	if sortBy == "" {
+		sortBy = PositionSortPnL
+	}
+	if order == "" {
+		order = positionSortOrderAsc
+		if sortBy == PositionSortNotional {
+			order = positionSortOrderDesc
+		}
+	}
+	desc := order == positionSortOrderDesc
+
+	key := func(p *models.Position) float64 {
+		switch sortBy {
+		case PositionSortPnLPercent:
+			return p.ProfitPercent
+		case PositionSortNotional:
+			return math.Abs(p.Notional)
+		case PositionSortLiquidationDistance:
+			return *p.DistanceToLiquidationPercent
+		default:
+			return p.UnRealizedProfit
+		}
+	}
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := &positions[i], &positions[j]
+		if sortBy == PositionSortLiquidationDistance {
+			if a.DistanceToLiquidationPercent == nil || b.DistanceToLiquidationPercent == nil {
+				return a.DistanceToLiquidationPercent != nil && b.DistanceToLiquidationPercent == nil
+			}
+		}
+		if desc {
+			return key(a) > key(b)
+		}
+		return key(a) < key(b)
+	})
+}
+
+// SummarizePositions 汇总名义价值、未实现盈亏和盈亏仓位数
+func SummarizePositions(positions []models.Position) models.PositionSummary {
+	var summary models.PositionSummary
+	for _, p := range positions {
+		summary.TotalNotional += math.Abs(p.Notional)
+		summary.TotalUnrealizedProfit += p.UnRealizedProfit
+		switch {
+		case p.UnRealizedProfit > 0:
+			summary.ProfitableCount++
+		case p.UnRealizedProfit < 0:
+			summary.LosingCount++
+		}
+	}
+	return summary
+}
+
+// getPositions 查询单个账户持仓数量不为0的仓位
+func (ts *TradingService) getPositions(acc *Account) ([]models.Position, error) {
+	// 查询所有持仓（symbol为空表示查询所有）
+	positionRisks, err := acc.Exchange.GetPositionRisk("")
+	if err != nil {
+		return nil, fmt.Errorf("查询持仓失败: %w", err)
+	}
+
+	positions := make([]models.Position, 0)
+	for _, pr := range positionRisks {
+		// 解析持仓数量
+		positionAmt, err := strconv.ParseFloat(pr.PositionAmt, 64)
+		if err != nil || positionAmt == 0 {
+			// 跳过持仓数量为0的仓位
+			continue
+		}
+
+		// 解析未实现盈亏
+		unRealizedProfit, err := strconv.ParseFloat(pr.UnRealizedProfit, 64)
+		if err != nil {
+			logger.Warnf("解析未实现盈亏失败: %s, symbol: %s", pr.UnRealizedProfit, pr.Symbol)
+			continue
+		}
+
+		// 解析其他字段
+		entryPrice, _ := strconv.ParseFloat(pr.EntryPrice, 64)
+		markPrice, _ := strconv.ParseFloat(pr.MarkPrice, 64)
+		liquidationPrice, _ := strconv.ParseFloat(pr.LiquidationPrice, 64)
+		leverage, _ := strconv.ParseFloat(pr.Leverage, 64)
+		notional, _ := strconv.ParseFloat(pr.Notional, 64)
+
+		// 计算收益率百分比（空单的名义价值为负数，取绝对值）
+		profitPercent := 0.0
+		if entryPrice > 0 && notional != 0 {
+			// 收益率 = 未实现盈亏 / 持仓名义价值 * 100
+			profitPercent = (unRealizedProfit / math.Abs(notional)) * 100
+		}
+
+		// 计算距强平价格百分比
+		var distanceToLiquidation *float64
+		if liquidationPrice > 0 && markPrice > 0 {
+			distance := math.Abs(markPrice-liquidationPrice) / markPrice * 100
+			distanceToLiquidation = &distance
+		}
+
+		positions = append(positions, models.Position{
+			Account:          acc.Name,
+			Symbol:           pr.Symbol,
+			PositionAmt:      positionAmt,
+			EntryPrice:       entryPrice,
+			MarkPrice:        markPrice,
+			UnRealizedProfit: unRealizedProfit,
+			LiquidationPrice: liquidationPrice,
+			Leverage:         leverage,
+			MarginType:       pr.MarginType,
+			PositionSide:     pr.PositionSide,
+			Notional:         notional,
+			UpdateTime:       pr.UpdateTime,
+			ProfitPercent:    profitPercent,
+
+			DistanceToLiquidationPercent: distanceToLiquidation,
+		})
+	}
+
+	if provider, ok := acc.Exchange.(exchange.FundingRateProvider); ok {
+		fillFunding(provider, positions)
+	}
+	return positions, nil
+}
+
+// 平仓原因
+const (
+	CloseReasonManual      = "manual"       // 手动平仓
+	CloseReasonStopLoss    = "stop_loss"    // 止损触发
+	CloseReasonTakeProfit  = "take_profit"  // 止盈触发
+	CloseReasonUnwind      = "unwind"       // 止损单创建失败后市价平仓
+	CloseReasonTimeExit    = "time_exit"    // 超过最长持仓时间
+	CloseReasonPartialExit = "partial_exit" // 按时间分批平仓
+	CloseReasonFunding     = "funding"      // 资金费结算前平仓
+)
+
+// closeReasonLabels 平仓原因的中文说明
+var closeReasonLabels = map[string]string{
+	CloseReasonManual:      "手动平仓",
+	CloseReasonStopLoss:    "止损",
+	CloseReasonTakeProfit:  "止盈",
+	CloseReasonUnwind:      "止损单创建失败",
+	CloseReasonTimeExit:    "持仓超时",
+	CloseReasonPartialExit: "分批平仓",
+	CloseReasonFunding:     "资金费结算前平仓",
+}
+
+// ClosedPosition position_closed 事件详情
+type ClosedPosition struct {
+	Reason     string  `json:"reason"`      // 平仓原因
+	Quantity   float64 `json:"quantity"`    // 平仓数量
+	EntryPrice float64 `json:"entry_price"` // 开仓均价
+	ExitPrice  float64 `json:"exit_price"`  // 平仓均价
+	PnL        float64 `json:"pnl"`         // 估算盈亏（USDT，不含手续费和资金费）
+}
+
+// publishPositionClosed 发布仓位已平事件
+func publishPositionClosed(bus *events.Bus, exchangeName, account, symbol string, closed *ClosedPosition) {
+	bus.Publish(events.Event{
+		Type:     events.TypePositionClosed,
+		Symbol:   symbol,
+		Exchange: exchangeName,
+		Account:  account,
+		Message: fmt.Sprintf("%s 已平仓（%s），数量 %g，开仓价 %g，平仓价 %g，盈亏 %.2f USDT",
+			symbol, closeReasonLabels[closed.Reason], closed.Quantity, closed.EntryPrice, closed.ExitPrice, closed.PnL),
+		Data: closed,
+	})
+}
+
+// accountByName 按名称获取单个账户，name留空返回主账户
+func (ts *TradingService) accountByName(name string) (*Account, error) {
+	if name == "" {
+		return ts.accounts[0], nil
+	}
+	accounts, err := ts.selectAccounts(name)
+	if err != nil {
+		return nil, err
+	}
+	return accounts[0], nil
+}
+
+// ClosePosition 市价平掉指定账户的仓位，并撤销该币对的止盈止损单
+// positionSide: 双向持仓时指定LONG/SHORT，留空平掉该币对所有方向的仓位
+func (ts *TradingService) ClosePosition(account, symbol, positionSide string) ([]*models.OrderResponse, error) {
+	return ts.closePosition(account, symbol, positionSide, CloseReasonManual)
+}
+
+// closePosition 市价平仓并撤销止盈止损单，reason为 position_closed 事件中的平仓原因
+func (ts *TradingService) closePosition(account, symbol, positionSide, reason string) ([]*models.OrderResponse, error) {
+	acc, err := ts.accountByName(account)
+	if err != nil {
+		return nil, err
+	}
+
+	positionRisks, err := acc.Exchange.GetPositionRisk(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("查询持仓失败: %w", err)
+	}
+
+	var orders []*models.OrderResponse
+	for _, pr := range positionRisks {
+		if pr.Symbol != symbol || (positionSide != "" && pr.PositionSide != positionSide) {
+			continue
+		}
+		positionAmt, err := strconv.ParseFloat(pr.PositionAmt, 64)
+		if err != nil || positionAmt == 0 {
+			continue
+		}
+
+		side := "SELL"
+		if positionAmt < 0 {
+			side = "BUY"
+		}
+		req := &exchange.MarketOrderRequest{
+			Symbol:       symbol,
+			Side:         side,
+			PositionSide: pr.PositionSide,
+			Quantity:     strings.TrimPrefix(pr.PositionAmt, "-"),
+			// 双向持仓模式下不能携带reduceOnly，按positionSide平仓
+			ReduceOnly: pr.PositionSide == "" || pr.PositionSide == "BOTH",
+		}
+
+		logger.Infof("账户 %s 市价平仓: %s, 方向: %s, 持仓方向: %s, 数量: %s", acc.Name, symbol, side, pr.PositionSide, req.Quantity)
+		order, err := acc.Exchange.CreateMarketOrder(req)
+		if err != nil {
+			err = fmt.Errorf("平仓失败: %w", err)
+			ts.bus.Publish(events.Event{
+				Type:     events.TypeError,
+				Symbol:   symbol,
+				Exchange: acc.Exchange.Name(),
+				Account:  acc.Name,
+				Message:  err.Error(),
+			})
+			return orders, err
+		}
+		ts.publishOrder(events.TypeOrderPlaced, &OrderSet{Symbol: symbol, Exchange: acc.Exchange.Name(), Account: acc.Name}, order, "平仓单")
+		orders = append(orders, order)
+
+		// 按开仓价和平仓成交均价估算盈亏，没有成交均价时使用标记价格
+		entryPrice, _ := strconv.ParseFloat(pr.EntryPrice, 64)
+		exitPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+		if exitPrice == 0 {
+			exitPrice, _ = strconv.ParseFloat(pr.MarkPrice, 64)
+		}
+		publishPositionClosed(ts.bus, acc.Exchange.Name(), acc.Name, symbol, &ClosedPosition{
+			Reason:     reason,
+			Quantity:   math.Abs(positionAmt),
+			EntryPrice: entryPrice,
+			ExitPrice:  exitPrice,
+			PnL:        (exitPrice - entryPrice) * positionAmt,
+		})
+	}
+
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("账户 %s 没有 %s 的持仓", acc.Name, symbol)
+	}
+
+	// 仓位已平掉，撤销剩余的止盈止损单
+	if _, err := ts.CancelBrackets(acc.Name, symbol, positionSide); err != nil {
+		logger.Warnf("平仓后撤销止盈止损单失败: %v", err)
+	}
+	return orders, nil
+}
+
+// CancelBrackets 撤销指定账户某个币对的止盈止损单，返回已撤销的订单
+// positionSide: 双向持仓时指定LONG/SHORT只撤销该方向的条件单，留空撤销所有方向
+func (ts *TradingService) CancelBrackets(account, symbol, positionSide string) ([]models.OrderResponse, error) {
+	acc, err := ts.accountByName(account)
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := acc.Exchange.(exchange.OpenOrderLister)
+	if !ok {
+		return nil, fmt.Errorf("交易所 %s 不支持查询挂单", acc.Exchange.Name())
+	}
+	openOrders, err := lister.GetOpenOrders(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("查询挂单失败: %w", err)
+	}
+
+	filterSide := positionSide != "" && positionSide != "BOTH"
+	canceled := make([]models.OrderResponse, 0)
+	remaining := 0
+	for i := range openOrders {
+		order := &openOrders[i]
+		if !exchange.IsStopOrderType(order.Type) {
+			continue
+		}
+		// 另一方向的仓位仍需要它自己的止盈止损单
+		if filterSide && order.PositionSide != positionSide {
+			remaining++
+			continue
+		}
+		if err := acc.Exchange.CancelOrder(symbol, order); err != nil {
+			return canceled, fmt.Errorf("撤销订单 %d 失败: %w", order.OrderID, err)
+		}
+		logger.Infof("账户 %s 已撤销 %s 的条件单 #%d（%s，触发价格: %s）", acc.Name, symbol, order.OrderID, order.Type, order.StopPrice)
+		canceled = append(canceled, *order)
+	}
+
+	// 另一方向还有条件单时继续跟踪，避免漏掉它们的成交
+	if remaining == 0 {
+		ts.brackets.Untrack(acc.Name, symbol)
+	}
+	return canceled, nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"testing"
+
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/models"
+)
+
+// fakePositionExchange 返回预设持仓和挂单，记录下单和撤单
+type fakePositionExchange struct {
+	exchange.Exchange
+	positions  []models.PositionRisk
+	openOrders []models.OrderResponse
+	orders     []*exchange.MarketOrderRequest
+	canceled   []int64
+}
+
+func (f *fakePositionExchange) Name() string { return exchange.NameBinance }
+
+func (f *fakePositionExchange) GetPositionRisk(symbol string) ([]models.PositionRisk, error) {
+	return f.positions, nil
+}
+
+func (f *fakePositionExchange) CreateMarketOrder(req *exchange.MarketOrderRequest) (*models.OrderResponse, error) {
+	f.orders = append(f.orders, req)
+	return &models.OrderResponse{Symbol: req.Symbol, Side: req.Side, Type: "MARKET", Status: "FILLED"}, nil
+}
+
+func (f *fakePositionExchange) GetOpenOrders(symbol string) ([]models.OrderResponse, error) {
+	return f.openOrders, nil
+}
+
+func (f *fakePositionExchange) CancelOrder(symbol string, order *models.OrderResponse) error {
+	f.canceled = append(f.canceled, order.OrderID)
+	return nil
+}
+
+func newPositionTestService(ex exchange.Exchange) *TradingService {
+	return &TradingService{
+		exchange: ex,
+		accounts: []*Account{{Name: "main", Exchange: ex, NotionalMultiplier: 1}},
+		brackets: NewBracketMonitor(nil),
+	}
+}
+
+func TestGetPositionsSorted(t *testing.T) {
+	ex := &fakePositionExchange{positions: []models.PositionRisk{
+		{Symbol: "AAAUSDT", PositionAmt: "-10", UnRealizedProfit: "1.5", Notional: "-100", EntryPrice: "10", MarkPrice: "10", LiquidationPrice: "12"},
+		{Symbol: "BBBUSDT", PositionAmt: "0", UnRealizedProfit: "0"},
+		{Symbol: "CCCUSDT", PositionAmt: "5", UnRealizedProfit: "-2", Notional: "50", EntryPrice: "10", MarkPrice: "10"},
+	}}
+	result, err := newPositionTestService(ex).GetPositions(PositionQuery{})
+	if err != nil {
+		t.Fatalf("查询持仓失败: %v", err)
+	}
+	positions := result.Positions
+	if len(positions) != 2 || positions[0].Symbol != "CCCUSDT" || positions[1].Symbol != "AAAUSDT" {
+		t.Fatalf("持仓应排除空仓并按未实现盈亏排序: %+v", positions)
+	}
+
+	short := positions[1]
+	if short.ProfitPercent != 1.5 {
+		t.Errorf("空单收益率应按名义价值绝对值计算，实际: %v", short.ProfitPercent)
+	}
+	if short.DistanceToLiquidationPercent == nil || *short.DistanceToLiquidationPercent != 20 {
+		t.Errorf("距强平价格百分比应为20，实际: %v", short.DistanceToLiquidationPercent)
+	}
+	if positions[0].DistanceToLiquidationPercent != nil {
+		t.Error("没有强平价格时距强平价格百分比应为空")
+	}
+
+	want := models.PositionSummary{TotalNotional: 150, TotalUnrealizedProfit: -0.5, ProfitableCount: 1, LosingCount: 1}
+	if result.Summary != want {
+		t.Errorf("汇总不正确: %+v", result.Summary)
+	}
+}
+
+func TestFilterAndSortPositions(t *testing.T) {
+	distance := func(v float64) *float64 { return &v }
+	positions := []models.Position{
+		{Symbol: "AAAUSDT", UnRealizedProfit: 3, ProfitPercent: 1, Notional: -300, DistanceToLiquidationPercent: distance(15)},
+		{Symbol: "BBBUSDT", UnRealizedProfit: -1, ProfitPercent: -10, Notional: 10},
+		{Symbol: "CCCUSDT", UnRealizedProfit: -5, ProfitPercent: -2, Notional: 250, DistanceToLiquidationPercent: distance(40)},
+		{Symbol: "DDDUSDT", UnRealizedProfit: 0, Notional: -80, DistanceToLiquidationPercent: distance(5)},
+	}
+	symbols := func(positions []models.Position) string {
+		var s string
+		for _, p := range positions {
+			s += p.Symbol[:1]
+		}
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		query PositionQuery
+		want  string
+	}{
+		{"默认按盈亏从小到大", PositionQuery{}, "CBDA"},
+		{"只看亏损", PositionQuery{Sign: PositionSignNegative}, "CB"},
+		{"只看盈利", PositionQuery{Sign: PositionSignPositive}, "A"},
+		{"按币对筛选", PositionQuery{Symbols: []string{"aaausdt", "DDDUSDT"}}, "DA"},
+		{"最小名义价值", PositionQuery{MinNotional: 80}, "CDA"},
+		{"收益率从大到小", PositionQuery{SortBy: PositionSortPnLPercent, Order: "desc"}, "ADCB"},
+		{"名义价值默认从大到小", PositionQuery{SortBy: PositionSortNotional}, "ACDB"},
+		{"距强平最近优先，无强平价排最后", PositionQuery{SortBy: PositionSortLiquidationDistance}, "DACB"},
+		{"距强平最远优先，无强平价排最后", PositionQuery{SortBy: PositionSortLiquidationDistance, Order: "desc"}, "CADB"},
+	}
+	for _, tt := range tests {
+		got := FilterPositions(positions, tt.query)
+		SortPositions(got, tt.query.SortBy, tt.query.Order)
+		if symbols(got) != tt.want {
+			t.Errorf("%s: 期望 %s，实际 %s", tt.name, tt.want, symbols(got))
+		}
+	}
+
+	for _, q := range []PositionQuery{{Sign: "zero"}, {SortBy: "price"}, {Order: "up"}, {MinNotional: -1}} {
+		if err := q.Validate(); err == nil {
+			t.Errorf("无效查询条件应返回错误: %+v", q)
+		}
+	}
+}
+
+func TestClosePositionCancelsBrackets(t *testing.T) {
+	ex := &fakePositionExchange{
+		positions: []models.PositionRisk{
+			{Symbol: "AAAUSDT", PositionAmt: "-12.5", PositionSide: "BOTH", UnRealizedProfit: "-1"},
+		},
+		openOrders: []models.OrderResponse{
+			{OrderID: 7, Type: "STOP_MARKET"},
+			{OrderID: 8, Type: "LIMIT"},
+			{OrderID: 9, Type: "TAKE_PROFIT_MARKET"},
+		},
+	}
+	ts := newPositionTestService(ex)
+
+	if _, err := ts.ClosePosition("", "AAAUSDT", ""); err != nil {
+		t.Fatalf("平仓失败: %v", err)
+	}
+	if len(ex.orders) != 1 {
+		t.Fatalf("应下1个平仓单，实际: %d", len(ex.orders))
+	}
+	order := ex.orders[0]
+	if order.Side != "BUY" || order.Quantity != "12.5" || !order.ReduceOnly {
+		t.Errorf("平仓单参数不正确: %+v", order)
+	}
+	if len(ex.canceled) != 2 || ex.canceled[0] != 7 || ex.canceled[1] != 9 {
+		t.Errorf("应只撤销止盈止损单，实际: %v", ex.canceled)
+	}
+
+	if _, err := ts.ClosePosition("other", "AAAUSDT", ""); err == nil {
+		t.Error("不存在的账户应返回错误")
+	}
+}
+
+func TestClosePositionHedgeModeKeepsOtherSideBrackets(t *testing.T) {
+	ex := &fakePositionExchange{
+		positions: []models.PositionRisk{
+			{Symbol: "AAAUSDT", PositionAmt: "-5", PositionSide: "SHORT"},
+			{Symbol: "AAAUSDT", PositionAmt: "3", PositionSide: "LONG"},
+		},
+		openOrders: []models.OrderResponse{
+			{OrderID: 7, Type: "STOP_MARKET", PositionSide: "SHORT"},
+			{OrderID: 8, Type: "STOP_MARKET", PositionSide: "LONG"},
+			{OrderID: 9, Type: "TAKE_PROFIT_MARKET", PositionSide: "SHORT"},
+			{OrderID: 10, Type: "TAKE_PROFIT_MARKET", PositionSide: "LONG"},
+		},
+	}
+	ts := newPositionTestService(ex)
+	ts.brackets.brackets["long"] = &trackedBracket{orderSet: &OrderSet{Symbol: "AAAUSDT", Account: "main"}}
+
+	if _, err := ts.ClosePosition("", "AAAUSDT", "SHORT"); err != nil {
+		t.Fatalf("平仓失败: %v", err)
+	}
+	if len(ex.orders) != 1 || ex.orders[0].PositionSide != "SHORT" {
+		t.Fatalf("应只平空头仓位，实际: %+v", ex.orders)
+	}
+	if len(ex.canceled) != 2 || ex.canceled[0] != 7 || ex.canceled[1] != 9 {
+		t.Errorf("应只撤销空头的止盈止损单，实际: %v", ex.canceled)
+	}
+	if len(ts.brackets.brackets) != 1 {
+		t.Error("多头仍有止盈止损单时不应停止跟踪")
+	}
+
+	ex.canceled = nil
+	if _, err := ts.CancelBrackets("", "AAAUSDT", ""); err != nil {
+		t.Fatalf("撤销止盈止损单失败: %v", err)
+	}
+	if len(ex.canceled) != 4 {
+		t.Errorf("不指定方向应撤销所有止盈止损单，实际: %v", ex.canceled)
+	}
+	if len(ts.brackets.brackets) != 0 {
+		t.Error("所有方向的止盈止损单撤销后应停止跟踪")
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// 拆单的默认子单数量和时间跨度
+const (
+	DefaultEntrySlices   = 5
+	DefaultSliceDuration = 30 * time.Second
+)
+
+// 子单之间的最小间隔，以及限流后重试同一个子单的等待时间和次数
+var (
+	minSliceInterval  = 200 * time.Millisecond
+	rateLimitBackoff  = time.Second
+	rateLimitAttempts = 3
+)
+
+// sliceRand 子单金额和间隔的随机数（多个账户并发下单，需要加锁）
+var (
+	sliceRandMu sync.Mutex
+	sliceRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitter 返回 1 ± percent% 范围内的随机系数
+func jitter(percent float64) float64 {
+	if percent <= 0 {
+		return 1
+	}
+	sliceRandMu.Lock()
+	defer sliceRandMu.Unlock()
+	return 1 + percent/100*(2*sliceRand.Float64()-1)
+}
+
+// sliceEntry 开仓金额是否需要拆单
+func sliceEntry(slicing config.EntrySlicingConfig, notional float64) bool {
+	return slicing.MinNotional > 0 && notional >= slicing.MinNotional
+}
+
+// sliceNotionals 把开仓金额拆成n份，每份按size_jitter随机浮动后归一化，保证总金额不变
+func sliceNotionals(notional float64, n int, sizeJitter float64) []float64 {
+	weights := make([]float64, n)
+	total := 0.0
+	for i := range weights {
+		weights[i] = jitter(sizeJitter)
+		total += weights[i]
+	}
+	slices := make([]float64, n)
+	for i, weight := range weights {
+		slices[i] = notional * weight / total
+	}
+	return slices
+}
+
+// slicedEntry 把开仓金额拆成多个子单在duration内依次下单（每个子单按trading.entry.mode下单），
+// 价格向不利方向变动超过max_price_move_bps或子单失败时停止，按已成交的部分合并为一个开仓单（成交量加权均价）
+func (ts *TradingService) slicedEntry(ex exchange.Exchange, strategy *Strategy, notional float64, entry config.EntryConfig) (*models.OrderResponse, float64, error) {
+	slicing := entry.Slicing
+	n, duration := slicing.Slices, slicing.Duration
+	if n <= 0 {
+		n = DefaultEntrySlices
+	}
+	if duration <= 0 {
+		duration = DefaultSliceDuration
+	}
+	interval := duration / time.Duration(n-1)
+
+	// 第一个子单前的参考价格，用于价格上限和滑点检查
+	reference := 0.0
+	if slicing.MaxPriceMoveBps > 0 || entry.MaxSlippageBps > 0 {
+		price, _, err := entryReference(ex, strategy)
+		if err != nil {
+			return nil, 0, fmt.Errorf("获取开仓参考价格失败: %w", err)
+		}
+		reference = price
+	}
+
+	notionals := sliceNotionals(notional, n, slicing.SizeJitter)
+	logger.Infof("拆单开仓（%s，%s）: %s, 总金额: %.2f USDT, 子单数: %d, 时间跨度: %s",
+		strategy.directionLabel(), ex.Name(), strategy.Symbol, notional, n, duration)
+
+	var fills []*models.OrderResponse
+	var stopErr error
+	for i, childNotional := range notionals {
+		if i > 0 {
+			wait := time.Duration(float64(interval) * jitter(slicing.TimeJitter))
+			if wait < minSliceInterval {
+				wait = minSliceInterval
+			}
+			time.Sleep(wait)
+
+			if slicing.MaxPriceMoveBps > 0 {
+				if price, _, err := entryReference(ex, strategy); err == nil {
+					if move := slippageBps(strategy.EntrySide(), reference, price); move > slicing.MaxPriceMoveBps {
+						stopErr = fmt.Errorf("价格 %.8f 相对参考价格 %.8f 不利变动 %.1f 个基点，超过上限 %g", price, reference, move, slicing.MaxPriceMoveBps)
+						break
+					}
+				}
+			}
+		}
+
+		order, childReference, err := ts.placeSlice(ex, strategy, strconv.FormatFloat(childNotional, 'f', 2, 64), entry)
+		if err != nil {
+			stopErr = fmt.Errorf("第%d个子单失败: %w", i+1, err)
+			break
+		}
+		if reference == 0 {
+			reference = childReference
+		}
+		order = finalOrder(ex, strategy.Symbol, order)
+		qty, _ := orderFill(order)
+		logger.Infof("拆单开仓 %s 第%d/%d个子单: 金额 %.2f USDT, 成交数量 %g, 均价 %s", strategy.Symbol, i+1, n, childNotional, qty, order.AvgPrice)
+		fills = append(fills, order)
+	}
+
+	merged := mergeFills(fills, 0)
+	if merged == nil {
+		if stopErr == nil {
+			stopErr = fmt.Errorf("子单均未成交")
+		}
+		return nil, 0, fmt.Errorf("拆单开仓失败: %w", stopErr)
+	}
+	if stopErr != nil {
+		logger.Warnf("拆单开仓 %s 提前结束，已完成 %d/%d 个子单，按已成交数量 %s 继续: %v", strategy.Symbol, len(fills), n, merged.ExecutedQty, stopErr)
+		merged.Status = "PARTIALLY_FILLED"
+	}
+	return merged, reference, nil
+}
+
+// placeSlice 下一个子单，限流时等待后重试
+func (ts *TradingService) placeSlice(ex exchange.Exchange, strategy *Strategy, notionalUSDT string, entry config.EntryConfig) (*models.OrderResponse, float64, error) {
+	backoff := rateLimitBackoff
+	for attempt := 1; ; attempt++ {
+		order, reference, err := ts.placeEntry(ex, strategy, notionalUSDT, entry)
+		if err == nil || !binance.IsRateLimited(err) || attempt >= rateLimitAttempts {
+			return order, reference, err
+		}
+		logger.Warnf("拆单开仓触发限流，%s后重试: %v", backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/models"
+)
+
+func TestSliceNotionals(t *testing.T) {
+	slices := sliceNotionals(1000, 5, 20)
+	total := 0.0
+	for _, notional := range slices {
+		// 归一化后单个子单最多偏离平均值 1.2/0.8 倍
+		if notional < 200*0.8/1.2 || notional > 200*1.2/0.8 {
+			t.Errorf("子单金额超出随机浮动范围: %g", notional)
+		}
+		total += notional
+	}
+	if len(slices) != 5 || math.Abs(total-1000) > 1e-6 {
+		t.Errorf("拆单后总金额应保持不变: %v", slices)
+	}
+}
+
+func TestSlicedEntry(t *testing.T) {
+	minSliceInterval = 0
+	ex := &fakeGuardExchange{price: "10"}
+	ts, _ := newEntryTestService(t, ex, config.EntryConfig{Slicing: config.EntrySlicingConfig{MinNotional: 50, Slices: 4, Duration: 3 * time.Millisecond}})
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if len(ex.orders) != 4 {
+		t.Fatalf("100 USDT应拆成4个子单，实际: %d", len(ex.orders))
+	}
+	for _, order := range ex.orders {
+		if order.Notional != "25.00" {
+			t.Errorf("子单金额应为25 USDT: %+v", order)
+		}
+	}
+	// 每个子单成交10，止盈止损按合计数量创建
+	if entry := orderSet.SellOrder; entry.ExecutedQty != "40" || entry.AvgPrice != "10" || entry.Status != "FILLED" {
+		t.Errorf("子单成交应合并为一个开仓单: %+v", entry)
+	}
+	if ex.stopAttempts != 1 || orderSet.Status != OrderSetProtected {
+		t.Errorf("应为合计数量创建一个止损单: attempts=%d status=%s", ex.stopAttempts, orderSet.Status)
+	}
+
+	// 金额低于阈值时不拆单
+	ex = &fakeGuardExchange{price: "10"}
+	ts, _ = newEntryTestService(t, ex, config.EntryConfig{Slicing: config.EntrySlicingConfig{MinNotional: 500, Slices: 4}})
+	ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if len(ex.orders) != 1 {
+		t.Errorf("金额低于阈值时不应拆单，实际: %d", len(ex.orders))
+	}
+}
+
+func TestSlicedEntryPriceCap(t *testing.T) {
+	minSliceInterval = 0
+	ex := &fakeEntryExchange{
+		fakeGuardExchange: fakeGuardExchange{price: "10"},
+		// 第三个子单前买一价下跌2%，超过1%的上限
+		books: []models.BookTicker{{BidPrice: "10", AskPrice: "10.01"}, {BidPrice: "9.99", AskPrice: "10"}, {BidPrice: "9.8", AskPrice: "9.81"}},
+	}
+	ts, _ := newEntryTestService(t, ex, config.EntryConfig{Slicing: config.EntrySlicingConfig{MinNotional: 50, Slices: 4, Duration: 3 * time.Millisecond, MaxPriceMoveBps: 100}})
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if len(ex.orders) != 2 || orderSet.SellOrder.Status != "PARTIALLY_FILLED" || orderSet.SellOrder.ExecutedQty != "20" {
+		t.Errorf("价格超过上限后应停止拆单: orders=%d entry=%+v", len(ex.orders), orderSet.SellOrder)
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// DefaultStopRatchetInterval 默认的移动止损检查间隔
+const DefaultStopRatchetInterval = 10 * time.Second
+
+// StopMove stop_moved 事件详情
+type StopMove struct {
+	OldStopPrice  string                `json:"old_stop_price"`
+	NewStopPrice  string                `json:"new_stop_price"`
+	ProfitPercent float64               `json:"profit_percent"` // 移动时的浮盈百分比
+	LockPercent   float64               `json:"lock_percent"`   // 锁定的利润百分比，0表示移到开仓价
+	Order         *models.OrderResponse `json:"order"`          // 新的止损单
+}
+
+// StopManager 移动止损：定期按标记价格计算持仓浮盈，达到 trading.stop_loss.ratchet 的阈值后
+// 撤销止损单并在收紧后的价格重建；只看当前止损单的触发价，止损只会收紧，重启后仍然有效
+type StopManager struct {
+	ts *TradingService
+	mu sync.Mutex
+}
+
+// NewStopManager 创建移动止损管理
+func NewStopManager(ts *TradingService) *StopManager {
+	return &StopManager{ts: ts}
+}
+
+// Run 定时检查，直到ctx取消
+func (m *StopManager) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultStopRatchetInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Check 检查一次所有账户的持仓，未配置移动止损时不查询持仓
+func (m *StopManager) Check() {
+	ratchet := m.ts.getConfig().Trading.StopLoss.Ratchet
+	if ratchet.BreakevenPercent <= 0 && len(ratchet.Steps) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, acc := range m.ts.accounts {
+		if err := m.ts.ratchetStops(acc, ratchet); err != nil {
+			logger.Warnf("移动止损检查失败，账户 %s: %v", acc.Name, err)
+		}
+	}
+}
+
+// ratchetStops 检查单个账户的持仓
+func (ts *TradingService) ratchetStops(acc *Account, ratchet config.StopRatchetConfig) error {
+	lister, ok := acc.Exchange.(exchange.OpenOrderLister)
+	if !ok {
+		return fmt.Errorf("交易所 %s 不支持查询挂单", acc.Exchange.Name())
+	}
+	positionRisks, err := acc.Exchange.GetPositionRisk("")
+	if err != nil {
+		return fmt.Errorf("查询持仓失败: %w", err)
+	}
+	openOrders, err := lister.GetOpenOrders("")
+	if err != nil {
+		return fmt.Errorf("查询挂单失败: %w", err)
+	}
+
+	for _, pr := range positionRisks {
+		positionAmt, err := strconv.ParseFloat(pr.PositionAmt, 64)
+		if err != nil || positionAmt == 0 {
+			continue
+		}
+		ts.ratchetStop(acc, pr, positionAmt, openOrders, ratchet)
+	}
+	return nil
+}
+
+// ratchetLock 按浮盈确定应锁定的利润百分比（0表示移到开仓价），未达到任何阈值时返回false
+func ratchetLock(ratchet config.StopRatchetConfig, profitPercent float64) (float64, bool) {
+	lock, reached := 0.0, false
+	if ratchet.BreakevenPercent > 0 && profitPercent >= ratchet.BreakevenPercent {
+		reached = true
+	}
+	for _, step := range ratchet.Steps {
+		if profitPercent >= step.ProfitPercent {
+			lock, reached = step.LockPercent, true
+		}
+	}
+	return lock, reached
+}
+
+// tighterStop 止损价a是否比b更紧（做多时更高，做空时更低）
+func tighterStop(a, b float64, long bool) bool {
+	if long {
+		return a > b
+	}
+	return a < b
+}
+
+// ratchetStop 浮盈达到阈值且目标止损价比当前止损单更紧时，撤销并重建止损单
+// 没有止损单的持仓不处理（由仓位保护检查补建）
+func (ts *TradingService) ratchetStop(acc *Account, pr models.PositionRisk, positionAmt float64, openOrders []models.OrderResponse, ratchet config.StopRatchetConfig) {
+	entryPrice, _ := strconv.ParseFloat(pr.EntryPrice, 64)
+	markPrice, _ := strconv.ParseFloat(pr.MarkPrice, 64)
+	if entryPrice <= 0 || markPrice <= 0 {
+		return
+	}
+
+	long := positionAmt > 0
+	profitPercent := (markPrice - entryPrice) / entryPrice * 100
+	closeSide := "SELL"
+	if !long {
+		profitPercent = -profitPercent
+		closeSide = "BUY"
+	}
+	lock, reached := ratchetLock(ratchet, profitPercent)
+	if !reached {
+		return
+	}
+
+	// 当前止损取覆盖该持仓的止损单中最紧的一个（不含跟踪止损单）
+	var current *models.OrderResponse
+	currentPrice := 0.0
+	for i := range openOrders {
+		order := &openOrders[i]
+		if (order.Type != "STOP_MARKET" && order.Type != "STOP") || !coversPosition(order, pr, closeSide) {
+			continue
+		}
+		price, err := strconv.ParseFloat(order.StopPrice, 64)
+		if err != nil {
+			continue
+		}
+		if current == nil || tighterStop(price, currentPrice, long) {
+			current, currentPrice = order, price
+		}
+	}
+	if current == nil {
+		return
+	}
+
+	target := entryPrice * (1 - lock/100)
+	if long {
+		target = entryPrice * (1 + lock/100)
+	}
+	stopPrice, err := ts.adjustTriggerPrice(acc.Exchange, pr.Symbol, target)
+	if err != nil {
+		logger.Warnf("调整移动止损价格失败: %s: %v", pr.Symbol, err)
+		return
+	}
+	newPrice, _ := strconv.ParseFloat(stopPrice, 64)
+	if !tighterStop(newPrice, currentPrice, long) {
+		return
+	}
+	// 新止损价已越过标记价格时止损单会立即触发，等待下次检查
+	if (long && newPrice >= markPrice) || (!long && newPrice <= markPrice) {
+		return
+	}
+
+	quantity, err := ts.adjustQuantity(acc.Exchange, pr.Symbol, math.Abs(positionAmt))
+	if err != nil {
+		quantity = current.OrigQty
+	}
+	orderSet := &OrderSet{Symbol: pr.Symbol, Exchange: acc.Exchange.Name(), Account: acc.Name}
+	unlock := ts.lockSymbol(acc.Name, pr.Symbol)
+	newOrder, err := ts.replaceTriggerOrder(acc, current, stopPrice, quantity)
+	unlock()
+	if err != nil {
+		logger.Errorf("移动止损失败: %v", err)
+		ts.publishBracketFailed(orderSet, fmt.Errorf("移动止损失败: %w", err))
+		return
+	}
+	ts.brackets.ReplaceStopLoss(acc.Name, pr.Symbol, current.OrderID, newOrder)
+
+	label := fmt.Sprintf("锁定 %g%% 利润", lock)
+	if lock == 0 {
+		label = "移到开仓价"
+	}
+	logger.Infof("账户 %s 的 %s 浮盈 %.2f%%，止损%s: %s -> %s", acc.Name, pr.Symbol, profitPercent, label, current.StopPrice, stopPrice)
+	ts.bus.Publish(events.Event{
+		Type:     events.TypeStopMoved,
+		Symbol:   pr.Symbol,
+		Exchange: acc.Exchange.Name(),
+		Account:  acc.Name,
+		Message:  fmt.Sprintf("%s 浮盈 %.2f%%，止损%s: %s -> %s", pr.Symbol, profitPercent, label, current.StopPrice, stopPrice),
+		Data: &StopMove{
+			OldStopPrice:  current.StopPrice,
+			NewStopPrice:  stopPrice,
+			ProfitPercent: profitPercent,
+			LockPercent:   lock,
+			Order:         newOrder,
+		},
+	})
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/models"
+)
+
+var testRatchet = config.StopRatchetConfig{
+	BreakevenPercent: 3,
+	Steps:            []config.StopStep{{ProfitPercent: 6, LockPercent: 3}},
+}
+
+func TestRatchetLock(t *testing.T) {
+	cases := []struct {
+		profit  float64
+		lock    float64
+		reached bool
+	}{
+		{2, 0, false},
+		{3, 0, true},
+		{5.9, 0, true},
+		{8, 3, true},
+	}
+	for _, c := range cases {
+		lock, reached := ratchetLock(testRatchet, c.profit)
+		if lock != c.lock || reached != c.reached {
+			t.Errorf("浮盈 %g%%: 期望 (%g, %v)，实际 (%g, %v)", c.profit, c.lock, c.reached, lock, reached)
+		}
+	}
+}
+
+func newRatchetTestService(t *testing.T, ex *fakeGuardExchange) (*TradingService, *events.Bus) {
+	t.Helper()
+	ts := newGuardTestService(t, ex, config.StopLossConfig{Ratchet: testRatchet})
+	bus := events.NewBus(10)
+	ts.SetEventBus(bus)
+	return ts, bus
+}
+
+func TestStopManagerRatchet(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", MarkPrice: "9.6", PositionSide: "BOTH"}}
+	ex.openOrders = []models.OrderResponse{
+		{OrderID: 1, Symbol: "ABCUSDT", Type: "STOP_MARKET", Side: "BUY", PositionSide: "BOTH", ClosePosition: true, StopPrice: "10.2"},
+		{OrderID: 2, Symbol: "ABCUSDT", Type: "TAKE_PROFIT_MARKET", Side: "BUY", PositionSide: "BOTH", ClosePosition: true, StopPrice: "9.5"},
+	}
+	ts, bus := newRatchetTestService(t, ex)
+	manager := NewStopManager(ts)
+
+	// 浮盈4%，止损移到开仓价
+	manager.Check()
+	if len(ex.canceled) != 1 || ex.canceled[0] != 1 || ex.stopAttempts != 1 {
+		t.Fatalf("应撤销并重建止损单: canceled=%v attempts=%d", ex.canceled, ex.stopAttempts)
+	}
+	moved := bus.Recent(events.ParseFilter(events.TypeStopMoved), 10)
+	if len(moved) != 1 || moved[0].Data.(*StopMove).NewStopPrice != "10.000" {
+		t.Fatalf("应发布止损移到开仓价的事件: %+v", moved)
+	}
+
+	// 回落到浮盈5%时目标止损没有更紧，不移动
+	ex.openOrders[0] = models.OrderResponse{OrderID: 3, Symbol: "ABCUSDT", Type: "STOP_MARKET", Side: "BUY", PositionSide: "BOTH", ClosePosition: true, StopPrice: "10.000"}
+	ex.positions[0].MarkPrice = "9.5"
+	manager.Check()
+	if ex.stopAttempts != 1 {
+		t.Fatalf("止损不应放宽或重复移动，实际创建次数: %d", ex.stopAttempts)
+	}
+
+	// 浮盈8%，锁定3%利润（10×0.97按tickSize向下取整为9.699）
+	ex.positions[0].MarkPrice = "9.2"
+	manager.Check()
+	moved = bus.Recent(events.ParseFilter(events.TypeStopMoved), 10)
+	if ex.stopAttempts != 2 || len(moved) != 2 || moved[1].Data.(*StopMove).NewStopPrice != "9.699" {
+		t.Errorf("应按阶梯锁定3%%利润: attempts=%d events=%d", ex.stopAttempts, len(moved))
+	}
+}
+
+func TestStopManagerRestoresOnFailure(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10", stopFailures: 1}
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "10", EntryPrice: "10", MarkPrice: "10.5", PositionSide: "BOTH"}}
+	ex.openOrders = []models.OrderResponse{{OrderID: 1, Symbol: "ABCUSDT", Type: "STOP_MARKET", Side: "SELL", PositionSide: "BOTH", ClosePosition: true, StopPrice: "9.8"}}
+	ts, bus := newRatchetTestService(t, ex)
+
+	NewStopManager(ts).Check()
+	// 新止损单创建失败后按原触发价恢复
+	if ex.stopAttempts != 2 {
+		t.Errorf("重建失败后应按原触发价恢复，实际创建次数: %d", ex.stopAttempts)
+	}
+	if failed := bus.Recent(events.ParseFilter(events.TypeBracketFailed), 10); len(failed) != 1 {
+		t.Errorf("移动止损失败应发布 bracket_failed 事件，实际: %d", len(failed))
+	}
+	if moved := bus.Recent(events.ParseFilter(events.TypeStopMoved), 10); len(moved) != 0 {
+		t.Errorf("移动失败时不应发布 stop_moved 事件")
+	}
+}
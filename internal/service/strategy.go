@@ -0,0 +1,295 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"new_listing_trade/internal/config"
+)
+
+// 开仓方向
+const (
+	DirectionShort = "SHORT"
+	DirectionLong  = "LONG"
+)
+
+// Strategy 某个币对最终生效的交易参数
+type Strategy struct {
+	Symbol                string                   `json:"symbol"`
+	OnboardDate           int64                    `json:"onboard_date,omitempty"` // 上线时间（毫秒时间戳），未知时为0
+	Rule                  string                   `json:"rule"`                   // 生效的规则名称，空表示使用默认配置
+	Direction             string                   `json:"direction"`
+	PositionSide          string                   `json:"position_side"` // 下单使用的持仓方向
+	Notional              string                   `json:"notional"`
+	StopLossEnabled       bool                     `json:"stop_loss_enabled"`
+	StopLossPercent       float64                  `json:"stop_loss_percent"`
+	StopLossWorkingType   string                   `json:"stop_loss_working_type"`
+	TakeProfitEnabled     bool                     `json:"take_profit_enabled"`
+	TakeProfitPercent     float64                  `json:"take_profit_percent"`
+	TakeProfitWorkingType string                   `json:"take_profit_working_type"`
+	TakeProfitLadder      []config.TakeProfitLevel `json:"take_profit_ladder,omitempty"` // 分档止盈，设置时替代take_profit_percent
+	MaxHoldingDuration    time.Duration            `json:"-"`                            // 最长持仓时间，0表示不限制
+	PartialExits          []config.PartialExit     `json:"-"`                            // 分批平仓检查点
+	Evaluations           []RuleEvaluation         `json:"evaluations"`                  // 每条规则的匹配过程
+}
+
+// RuleEvaluation 单条规则的匹配结果
+type RuleEvaluation struct {
+	Rule     string `json:"rule"`
+	Matched  bool   `json:"matched"`
+	Selected bool   `json:"selected"` // 是否为生效的规则（第一条匹配的规则）
+	Reason   string `json:"reason"`
+}
+
+// EntrySide 开仓方向对应的下单方向
+func (s *Strategy) EntrySide() string {
+	if s.Direction == DirectionLong {
+		return "BUY"
+	}
+	return "SELL"
+}
+
+// CloseSide 平仓方向对应的下单方向
+func (s *Strategy) CloseSide() string {
+	if s.Direction == DirectionLong {
+		return "SELL"
+	}
+	return "BUY"
+}
+
+// StopLossPrice 计算止损价格（做空时价格上涨触发，做多时价格下跌触发）
+func (s *Strategy) StopLossPrice(entryPrice float64) float64 {
+	if s.Direction == DirectionLong {
+		return entryPrice * (1 - s.StopLossPercent/100.0)
+	}
+	return entryPrice * (1 + s.StopLossPercent/100.0)
+}
+
+// TakeProfitPrice 计算止盈价格（做空时价格下跌触发，做多时价格上涨触发）
+func (s *Strategy) TakeProfitPrice(entryPrice float64) float64 {
+	return s.takeProfitPriceAt(entryPrice, s.TakeProfitPercent)
+}
+
+// takeProfitPriceAt 按指定止盈百分比计算止盈价格
+func (s *Strategy) takeProfitPriceAt(entryPrice, percent float64) float64 {
+	if s.Direction == DirectionLong {
+		return entryPrice * (1 + percent/100.0)
+	}
+	return entryPrice * (1 - percent/100.0)
+}
+
+// positionSideFor 下单使用的持仓方向：单向持仓（BOTH）保持不变，双向持仓按开仓方向取LONG/SHORT
+func positionSideFor(direction, configured string) string {
+	if configured == "" || configured == "BOTH" {
+		return "BOTH"
+	}
+	return direction
+}
+
+// directionLabel 日志中的方向描述
+func (s *Strategy) directionLabel() string {
+	if s.Direction == DirectionLong {
+		return "做多"
+	}
+	return "做空"
+}
+
+// compiledRule 预编译的规则
+type compiledRule struct {
+	config.StrategyRule
+	regex *regexp.Regexp
+}
+
+// StrategyResolver 按规则解析币对的交易参数
+type StrategyResolver struct {
+	trading config.TradingConfig
+	rules   []compiledRule
+}
+
+// NewStrategyResolver 创建策略解析器，校验并预编译规则
+func NewStrategyResolver(trading config.TradingConfig) (*StrategyResolver, error) {
+	if _, err := normalizeDirection(trading.Direction); err != nil {
+		return nil, fmt.Errorf("trading.direction: %w", err)
+	}
+
+	rules := make([]compiledRule, 0, len(trading.Rules))
+	for i, rule := range trading.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule#%d", i+1)
+		}
+		compiled := compiledRule{StrategyRule: rule}
+
+		if rule.Symbol != "" {
+			if _, err := path.Match(rule.Symbol, ""); err != nil {
+				return nil, fmt.Errorf("规则 %s 的币对通配符无效: %w", rule.Name, err)
+			}
+		}
+		if rule.SymbolRegex != "" {
+			re, err := regexp.Compile(rule.SymbolRegex)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %s 的正则表达式无效: %w", rule.Name, err)
+			}
+			compiled.regex = re
+		}
+		for _, hour := range rule.OnboardHours {
+			if hour < 0 || hour > 23 {
+				return nil, fmt.Errorf("规则 %s 的上线小时无效: %d", rule.Name, hour)
+			}
+		}
+		if _, err := normalizeDirection(rule.Direction); err != nil {
+			return nil, fmt.Errorf("规则 %s: %w", rule.Name, err)
+		}
+
+		rules = append(rules, compiled)
+	}
+
+	return &StrategyResolver{
+		trading: trading,
+		rules:   rules,
+	}, nil
+}
+
+// Resolve 解析币对的交易参数
+// onboardDate: 上线时间（毫秒时间戳），未知时传0，此时设置了onboard_hours的规则不匹配
+func (r *StrategyResolver) Resolve(symbol string, onboardDate int64) *Strategy {
+	direction, _ := normalizeDirection(r.trading.Direction)
+	strategy := &Strategy{
+		Symbol:                symbol,
+		OnboardDate:           onboardDate,
+		Direction:             direction,
+		Notional:              r.trading.DefaultNotional,
+		StopLossEnabled:       r.trading.StopLoss.Enabled,
+		StopLossPercent:       r.trading.StopLoss.Percent,
+		StopLossWorkingType:   r.trading.StopLoss.WorkingType,
+		TakeProfitEnabled:     r.trading.TakeProfit.Enabled,
+		TakeProfitPercent:     r.trading.TakeProfit.Percent,
+		TakeProfitWorkingType: r.trading.TakeProfit.WorkingType,
+		TakeProfitLadder:      r.trading.TakeProfit.Ladder,
+		MaxHoldingDuration:    r.trading.MaxHoldingDuration,
+		PartialExits:          r.trading.PartialExits,
+		Evaluations:           make([]RuleEvaluation, 0, len(r.rules)),
+	}
+
+	var selected *compiledRule
+	for i := range r.rules {
+		rule := &r.rules[i]
+		matched, reason := rule.match(symbol, onboardDate)
+		evaluation := RuleEvaluation{
+			Rule:    rule.Name,
+			Matched: matched,
+			Reason:  reason,
+		}
+		if matched && selected == nil {
+			selected = rule
+			evaluation.Selected = true
+		}
+		strategy.Evaluations = append(strategy.Evaluations, evaluation)
+	}
+
+	if selected != nil {
+		selected.apply(strategy)
+	}
+	strategy.PositionSide = positionSideFor(strategy.Direction, r.trading.PositionSide)
+	return strategy
+}
+
+// match 判断规则是否匹配，返回匹配说明
+func (rule *compiledRule) match(symbol string, onboardDate int64) (bool, string) {
+	var reasons []string
+
+	if rule.Symbol != "" {
+		if ok, _ := path.Match(rule.Symbol, symbol); !ok {
+			return false, fmt.Sprintf("币对不匹配通配符 %s", rule.Symbol)
+		}
+		reasons = append(reasons, fmt.Sprintf("币对匹配通配符 %s", rule.Symbol))
+	}
+
+	if rule.regex != nil {
+		if !rule.regex.MatchString(symbol) {
+			return false, fmt.Sprintf("币对不匹配正则 %s", rule.SymbolRegex)
+		}
+		reasons = append(reasons, fmt.Sprintf("币对匹配正则 %s", rule.SymbolRegex))
+	}
+
+	if len(rule.OnboardHours) > 0 {
+		if onboardDate <= 0 {
+			return false, "上线时间未知"
+		}
+		hour := time.UnixMilli(onboardDate).UTC().Hour()
+		found := false
+		for _, h := range rule.OnboardHours {
+			if h == hour {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, fmt.Sprintf("上线时间 %d时(UTC) 不在 %v 中", hour, rule.OnboardHours)
+		}
+		reasons = append(reasons, fmt.Sprintf("上线时间 %d时(UTC) 在 %v 中", hour, rule.OnboardHours))
+	}
+
+	if len(reasons) == 0 {
+		return true, "规则未设置匹配条件，匹配所有币对"
+	}
+	return true, strings.Join(reasons, "，")
+}
+
+// apply 将规则的覆盖参数写入策略
+func (rule *compiledRule) apply(strategy *Strategy) {
+	strategy.Rule = rule.Name
+	if rule.Direction != "" {
+		strategy.Direction, _ = normalizeDirection(rule.Direction)
+	}
+	if rule.Notional != "" {
+		strategy.Notional = rule.Notional
+	}
+	if rule.StopLossPercent != nil {
+		strategy.StopLossPercent = *rule.StopLossPercent
+		strategy.StopLossEnabled = *rule.StopLossPercent > 0
+	}
+	if rule.TakeProfitPercent != nil {
+		// 规则指定的止盈百分比替代默认的分档止盈
+		strategy.TakeProfitPercent = *rule.TakeProfitPercent
+		strategy.TakeProfitEnabled = *rule.TakeProfitPercent > 0
+		strategy.TakeProfitLadder = nil
+	}
+	if rule.WorkingType != "" {
+		strategy.StopLossWorkingType = rule.WorkingType
+		strategy.TakeProfitWorkingType = rule.WorkingType
+	}
+	if rule.MaxHoldingDuration != nil {
+		strategy.MaxHoldingDuration = *rule.MaxHoldingDuration
+	}
+	if rule.PartialExits != nil {
+		strategy.PartialExits = rule.PartialExits
+	}
+}
+
+// hasTimeExits 默认配置或任一规则是否设置了按持仓时间平仓
+func (r *StrategyResolver) hasTimeExits() bool {
+	if r.trading.MaxHoldingDuration > 0 || len(r.trading.PartialExits) > 0 {
+		return true
+	}
+	for _, rule := range r.rules {
+		if (rule.MaxHoldingDuration != nil && *rule.MaxHoldingDuration > 0) || len(rule.PartialExits) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDirection 校验并规范化开仓方向，留空默认做空
+func normalizeDirection(direction string) (string, error) {
+	switch strings.ToUpper(direction) {
+	case "", DirectionShort:
+		return DirectionShort, nil
+	case DirectionLong:
+		return DirectionLong, nil
+	default:
+		return "", fmt.Errorf("无效的开仓方向: %s（可选 SHORT/LONG）", direction)
+	}
+}
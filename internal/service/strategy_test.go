@@ -0,0 +1,103 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/config"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func testTradingConfig() config.TradingConfig {
+	return config.TradingConfig{
+		DefaultNotional: "10",
+		PositionSide:    "BOTH",
+		StopLoss:        config.StopLossConfig{Enabled: true, Percent: 2, WorkingType: "MARK_PRICE"},
+		TakeProfit:      config.TakeProfitConfig{Enabled: true, Percent: 5, WorkingType: "MARK_PRICE"},
+		Rules: []config.StrategyRule{
+			{Name: "meme", Symbol: "1000*", Notional: "5", StopLossPercent: floatPtr(8)},
+			{Name: "asia-open", OnboardHours: []int{0, 1}, Direction: "long", TakeProfitPercent: floatPtr(0)},
+			{Name: "ai", SymbolRegex: "^(AI|GPT)", WorkingType: "CONTRACT_PRICE"},
+		},
+	}
+}
+
+func TestStrategyResolve(t *testing.T) {
+	resolver, err := NewStrategyResolver(testTradingConfig())
+	if err != nil {
+		t.Fatalf("创建策略解析器失败: %v", err)
+	}
+
+	// 未匹配任何规则：使用默认配置
+	s := resolver.Resolve("BTCUSDT", 0)
+	if s.Rule != "" || s.Direction != DirectionShort || s.Notional != "10" || s.StopLossPercent != 2 {
+		t.Errorf("默认策略不正确: %+v", s)
+	}
+	if len(s.Evaluations) != 3 {
+		t.Errorf("应记录每条规则的匹配过程: %+v", s.Evaluations)
+	}
+
+	// 通配符匹配
+	s = resolver.Resolve("1000PEPEUSDT", 0)
+	if s.Rule != "meme" || s.Notional != "5" || s.StopLossPercent != 8 || s.TakeProfitPercent != 5 {
+		t.Errorf("通配符规则不正确: %+v", s)
+	}
+
+	// 上线小时匹配，0%止盈表示关闭止盈
+	onboard := time.Date(2026, 1, 2, 1, 30, 0, 0, time.UTC).UnixMilli()
+	s = resolver.Resolve("XYZUSDT", onboard)
+	if s.Rule != "asia-open" || s.Direction != DirectionLong || s.TakeProfitEnabled {
+		t.Errorf("上线小时规则不正确: %+v", s)
+	}
+
+	// 通配符和上线小时同时匹配时，第一条匹配的规则生效
+	s = resolver.Resolve("1000PEPEUSDT", onboard)
+	if s.Rule != "meme" || !s.Evaluations[0].Selected || !s.Evaluations[1].Matched || s.Evaluations[1].Selected {
+		t.Errorf("规则优先级不正确: %+v", s)
+	}
+
+	// 正则匹配
+	s = resolver.Resolve("AIXBTUSDT", 0)
+	if s.Rule != "ai" || s.StopLossWorkingType != "CONTRACT_PRICE" || s.TakeProfitWorkingType != "CONTRACT_PRICE" {
+		t.Errorf("正则规则不正确: %+v", s)
+	}
+}
+
+func TestStrategyPrices(t *testing.T) {
+	short := &Strategy{Direction: DirectionShort, StopLossPercent: 2, TakeProfitPercent: 5}
+	long := &Strategy{Direction: DirectionLong, StopLossPercent: 2, TakeProfitPercent: 5}
+
+	almostEqual := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !almostEqual(short.StopLossPrice(100), 102) || !almostEqual(short.TakeProfitPrice(100), 95) {
+		t.Errorf("做空止盈止损价格不正确")
+	}
+	if !almostEqual(long.StopLossPrice(100), 98) || !almostEqual(long.TakeProfitPrice(100), 105) {
+		t.Errorf("做多止盈止损价格不正确")
+	}
+	if short.EntrySide() != "SELL" || short.CloseSide() != "BUY" || long.EntrySide() != "BUY" || long.CloseSide() != "SELL" {
+		t.Errorf("下单方向不正确")
+	}
+	if positionSideFor(DirectionLong, "BOTH") != "BOTH" || positionSideFor(DirectionLong, "SHORT") != "LONG" {
+		t.Errorf("持仓方向不正确")
+	}
+}
+
+func TestStrategyResolverInvalidRules(t *testing.T) {
+	cases := []config.StrategyRule{
+		{Name: "bad-regex", SymbolRegex: "("},
+		{Name: "bad-glob", Symbol: "["},
+		{Name: "bad-hour", OnboardHours: []int{24}},
+		{Name: "bad-direction", Direction: "UP"},
+	}
+	for _, rule := range cases {
+		cfg := testTradingConfig()
+		cfg.Rules = []config.StrategyRule{rule}
+		if _, err := NewStrategyResolver(cfg); err == nil {
+			t.Errorf("规则 %s 应校验失败", rule.Name)
+		}
+	}
+}
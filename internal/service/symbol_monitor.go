@@ -1,11 +1,17 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
 	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/metrics"
 	"new_listing_trade/internal/models"
 )
 
@@ -18,14 +24,42 @@ type SymbolMonitor struct {
 	lastUpdateTime time.Time                           // 最后更新时间
 	onNewSymbols   func([]*models.Symbol)              // 发现新币对时的回调函数
 	isInitialized  bool                                // 是否已完成初始化
+
+	pollInterval     time.Duration          // 常规轮询间隔
+	fastPollInterval time.Duration          // 临近上线时的快速轮询间隔
+	fastPollWindow   time.Duration          // 距离上线多久以内切换为快速轮询
+	cacheValidator   binance.CacheValidator // exchangeInfo条件请求的ETag/Last-Modified
+	sources          []ListingSource        // 额外的新币来源（公告、webhook等）
+	bus              *events.Bus            // 事件总线（未设置时不发布事件）
+	cancel           context.CancelFunc     // 停止监控循环
+	wg               sync.WaitGroup         // 等待监控循环和新币来源退出
 }
 
-// NewSymbolMonitor 创建新的币对监控服务
+// NewSymbolMonitor 创建新的币对监控服务（使用默认轮询配置）
 func NewSymbolMonitor() *SymbolMonitor {
+	return NewSymbolMonitorWithConfig(config.GetDefaultConfig().Monitor)
+}
+
+// NewSymbolMonitorWithConfig 根据配置创建币对监控服务
+func NewSymbolMonitorWithConfig(cfg config.MonitorConfig) *SymbolMonitor {
+	defaults := config.GetDefaultConfig().Monitor
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.FastPollInterval <= 0 {
+		cfg.FastPollInterval = defaults.FastPollInterval
+	}
+	if cfg.FastPollWindow < 0 {
+		cfg.FastPollWindow = 0
+	}
+
 	return &SymbolMonitor{
-		client:      binance.NewClient(),
-		symbols:     make(map[string]*models.Symbol),
-		newListings: make(map[string]*models.NewListingSymbol),
+		client:           binance.NewClient(),
+		symbols:          make(map[string]*models.Symbol),
+		newListings:      make(map[string]*models.NewListingSymbol),
+		pollInterval:     cfg.PollInterval,
+		fastPollInterval: cfg.FastPollInterval,
+		fastPollWindow:   cfg.FastPollWindow,
 	}
 }
 
@@ -34,8 +68,25 @@ func (sm *SymbolMonitor) SetOnNewSymbolsCallback(callback func([]*models.Symbol)
 	sm.onNewSymbols = callback
 }
 
+// SetEventBus 设置事件总线，发现新币对时发布 listing_discovered 事件
+func (sm *SymbolMonitor) SetEventBus(bus *events.Bus) {
+	sm.bus = bus
+}
+
+// AddSource 注册额外的新币来源，需要在Start之前调用
+func (sm *SymbolMonitor) AddSource(source ListingSource) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.sources = append(sm.sources, source)
+}
+
 // Start 启动监控服务
 func (sm *SymbolMonitor) Start() error {
+	return sm.StartWithContext(context.Background())
+}
+
+// StartWithContext 启动监控服务，ctx取消或调用Stop时监控循环退出
+func (sm *SymbolMonitor) StartWithContext(ctx context.Context) error {
 	logger.Info("启动币对监控服务...")
 
 	// 启动时立即拉取一次（初始化）
@@ -43,18 +94,102 @@ func (sm *SymbolMonitor) Start() error {
 		return err
 	}
 
-	// 启动定时任务，每2分钟拉取一次
-	ticker := time.NewTicker(2 * time.Minute)
-	go func() {
-		for range ticker.C {
+	ctx, cancel := context.WithCancel(ctx)
+	sm.mu.Lock()
+	sm.cancel = cancel
+	sources := sm.sources
+	sm.mu.Unlock()
+
+	// 启动定时任务，轮询间隔根据临近上线的币对自适应调整
+	sm.wg.Add(1)
+	go sm.run(ctx)
+
+	// 启动额外的新币来源
+	for _, source := range sources {
+		source := source
+		sm.wg.Add(1)
+		go func() {
+			defer sm.wg.Done()
+			logger.Infof("启动新币来源: %s", source.Name())
+			if err := source.Run(ctx, sm.ingestListings); err != nil && ctx.Err() == nil {
+				logger.Errorf("新币来源 %s 退出: %v", source.Name(), err)
+			}
+		}()
+	}
+
+	logger.Infof("币对监控服务启动成功，轮询间隔: %v，临近上线（%v内）时轮询间隔: %v",
+		sm.pollInterval, sm.fastPollWindow, sm.fastPollInterval)
+	return nil
+}
+
+// Stop 停止监控服务，等待监控循环退出
+func (sm *SymbolMonitor) Stop() {
+	sm.mu.Lock()
+	cancel := sm.cancel
+	sm.cancel = nil
+	sm.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	sm.wg.Wait()
+	logger.Info("币对监控服务已停止")
+}
+
+// run 监控循环
+func (sm *SymbolMonitor) run(ctx context.Context) {
+	defer sm.wg.Done()
+
+	interval := sm.nextPollInterval()
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
 			if err := sm.fetchAndUpdate(false); err != nil {
 				logger.Errorf("定时拉取币对数据失败: %v", err)
 			}
+
+			next := sm.nextPollInterval()
+			if next != interval {
+				logger.Infof("币对轮询间隔调整: %v -> %v", interval, next)
+				interval = next
+			}
+			timer.Reset(interval)
 		}
-	}()
+	}
+}
 
-	logger.Info("币对监控服务启动成功")
-	return nil
+// nextPollInterval 计算下一次轮询间隔：有币对即将在fastPollWindow内上线时使用快速轮询
+// 除exchangeInfo中的币对外，也检查公告、webhook等来源提前发现的新币
+func (sm *SymbolMonitor) nextPollInterval() time.Duration {
+	if sm.fastPollWindow <= 0 {
+		return sm.pollInterval
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	now := time.Now().UnixMilli()
+	windowMillis := sm.fastPollWindow.Milliseconds()
+	soon := func(onboardDate int64) bool {
+		return onboardDate >= now && onboardDate-now <= windowMillis
+	}
+	for _, symbol := range sm.symbols {
+		if soon(symbol.OnboardDate) {
+			return sm.fastPollInterval
+		}
+	}
+	for _, listing := range sm.newListings {
+		if soon(listing.OnboardDate) {
+			return sm.fastPollInterval
+		}
+	}
+	return sm.pollInterval
 }
 
 // fetchAndUpdate 拉取并更新币对数据
@@ -65,7 +200,17 @@ func (sm *SymbolMonitor) fetchAndUpdate(isInitial bool) error {
 		logger.Info("开始拉取币对数据...")
 	}
 
-	exchangeInfo, err := sm.client.GetExchangeInfo()
+	pollStart := time.Now()
+	exchangeInfo, err := sm.client.GetExchangeInfoIfModified(&sm.cacheValidator)
+	metrics.ExchangeInfoPollDuration.Observe(time.Since(pollStart).Seconds(), pollResult(err))
+	if errors.Is(err, binance.ErrNotModified) {
+		// 交易所信息未变化，无需重新比对
+		sm.mu.Lock()
+		sm.lastUpdateTime = time.Now()
+		sm.mu.Unlock()
+		logger.Debug("交易所信息未变化（304），跳过本次比对")
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -80,6 +225,8 @@ func (sm *SymbolMonitor) fetchAndUpdate(isInitial bool) error {
 	currentTimeMillis := foundTime.UnixMilli() // 当前时间（毫秒）
 
 	for _, symbol := range exchangeInfo.Symbols {
+		symbol := symbol // 下面会保存&symbol，每次迭代需要独立的变量
+
 		// 只处理状态为TRADING的币对
 		if symbol.Status != "TRADING" && symbol.Status != "PENDING_TRADING" {
 			continue
@@ -116,12 +263,25 @@ func (sm *SymbolMonitor) fetchAndUpdate(isInitial bool) error {
 					FoundTime:   foundTime,
 					IsOrdered:   false,
 					OrderTime:   nil,
+					Source:      SourceExchangeInfo,
+					Confidence:  1,
 				}
 				sm.newListings[symbol.Symbol] = newListing
 				newListings = append(newListings, newListing)
+				sm.recordListing(newListing)
 			}
 		}
 
+		// 其他来源提前发现的币对，exchangeInfo出现后补全上线时间并确认
+		if listing, exists := sm.newListings[symbol.Symbol]; exists && listing.Confidence < 1 {
+			listing.OnboardDate = symbol.OnboardDate
+			listing.Status = symbol.Status
+			listing.Confidence = 1
+			logger.Infof("exchangeInfo确认新币对: %s（来源: %s），上线时间: %s",
+				symbol.Symbol, listing.Source,
+				time.Unix(symbol.OnboardDate/1000, 0).Format("2006-01-02 15:04:05"))
+		}
+
 		// 更新或添加币对信息
 		sm.symbols[symbol.Symbol] = &symbol
 	}
@@ -153,6 +313,61 @@ func (sm *SymbolMonitor) fetchAndUpdate(isInitial bool) error {
 	return nil
 }
 
+// recordListing 记录新币对指标并发布事件
+func (sm *SymbolMonitor) recordListing(listing *models.NewListingSymbol) {
+	metrics.NewListings.Inc(listing.Source)
+	sm.bus.Publish(events.Event{
+		Type:    events.TypeListingDiscovered,
+		Symbol:  listing.Symbol,
+		Message: fmt.Sprintf("发现新币对 %s（来源: %s）", listing.Symbol, listing.Source),
+		Data:    *listing,
+	})
+}
+
+// pollResult exchangeInfo轮询结果，用于指标标签
+func pollResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, binance.ErrNotModified):
+		return "not_modified"
+	default:
+		return "error"
+	}
+}
+
+// ingestListings 合并其他来源发现的新币对
+// 已存在的币对只提升置信度、补全上线时间，保留最早的发现时间和来源
+func (sm *SymbolMonitor) ingestListings(listings []*models.NewListingSymbol) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	for _, listing := range listings {
+		existing, exists := sm.newListings[listing.Symbol]
+		if !exists {
+			// exchangeInfo中已存在且不是新币的币对不再加入
+			if _, known := sm.symbols[listing.Symbol]; known && sm.isInitialized {
+				continue
+			}
+			listingCopy := *listing
+			if listingCopy.FoundTime.IsZero() {
+				listingCopy.FoundTime = time.Now()
+			}
+			sm.newListings[listing.Symbol] = &listingCopy
+			sm.recordListing(&listingCopy)
+			logger.Infof("新币来源 %s 发现币对: %s，置信度: %.2f", listing.Source, listing.Symbol, listing.Confidence)
+			continue
+		}
+
+		if listing.Confidence > existing.Confidence {
+			existing.Confidence = listing.Confidence
+		}
+		if existing.OnboardDate == 0 && listing.OnboardDate > 0 {
+			existing.OnboardDate = listing.OnboardDate
+		}
+	}
+}
+
 // GetSymbols 获取所有币对
 func (sm *SymbolMonitor) GetSymbols() map[string]*models.Symbol {
 	sm.mu.RLock()
@@ -291,8 +506,11 @@ func (sm *SymbolMonitor) AddNewListing(symbol string, onboardDate int64) bool {
 		FoundTime:   time.Now(),
 		IsOrdered:   false,
 		OrderTime:   nil,
+		Source:      SourceManual,
+		Confidence:  1,
 	}
 	sm.newListings[symbol] = newListing
+	sm.recordListing(newListing)
 
 	logger.Infof("手动添加新币对: %s", symbol)
 	return true
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/models"
+)
+
+func TestNextPollInterval(t *testing.T) {
+	sm := NewSymbolMonitorWithConfig(config.MonitorConfig{
+		PollInterval:     2 * time.Minute,
+		FastPollInterval: 3 * time.Second,
+		FastPollWindow:   10 * time.Minute,
+	})
+
+	if got := sm.nextPollInterval(); got != 2*time.Minute {
+		t.Fatalf("没有临近上线的币对时应使用常规间隔，实际: %v", got)
+	}
+
+	// 1小时后上线，不在快速轮询窗口内
+	sm.symbols["LATERUSDT"] = &models.Symbol{Symbol: "LATERUSDT", OnboardDate: time.Now().Add(time.Hour).UnixMilli()}
+	if got := sm.nextPollInterval(); got != 2*time.Minute {
+		t.Fatalf("上线时间在窗口外时应使用常规间隔，实际: %v", got)
+	}
+
+	// 公告来源提前发现的币对5分钟后上线，exchangeInfo中还没有，也切换为快速轮询
+	sm.newListings["ANNOUNCEDUSDT"] = &models.NewListingSymbol{Symbol: "ANNOUNCEDUSDT", OnboardDate: time.Now().Add(5 * time.Minute).UnixMilli(), Source: SourceAnnouncement}
+	if got := sm.nextPollInterval(); got != 3*time.Second {
+		t.Fatalf("其他来源的币对临近上线时应使用快速轮询间隔，实际: %v", got)
+	}
+	delete(sm.newListings, "ANNOUNCEDUSDT")
+
+	// 5分钟后上线，切换为快速轮询
+	sm.symbols["SOONUSDT"] = &models.Symbol{Symbol: "SOONUSDT", OnboardDate: time.Now().Add(5 * time.Minute).UnixMilli()}
+	if got := sm.nextPollInterval(); got != 3*time.Second {
+		t.Fatalf("临近上线时应使用快速轮询间隔，实际: %v", got)
+	}
+}
+
+func TestStopWithoutStart(t *testing.T) {
+	sm := NewSymbolMonitor()
+	// 未启动时调用Stop不应阻塞
+	sm.Stop()
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/metrics"
+	"new_listing_trade/internal/models"
+)
+
+// ladderLeg 分档止盈拆分后的一档
+type ladderLeg struct {
+	Level    config.TakeProfitLevel
+	Quantity string
+}
+
+// ladderOrderType 分档止盈单的订单类型
+const ladderOrderType = "TAKE_PROFIT_MARKET"
+
+// splitLadder 按各档比例拆分平仓数量：每档按stepSize向下取整，不足minQty的档并入下一档，
+// 取整剩下的零头并入最后一档（最后一档不足minQty时并入前一档）
+func splitLadder(quantity float64, ladder []config.TakeProfitLevel, symbolInfo *models.Symbol) ([]ladderLeg, error) {
+	// 止盈单为TAKE_PROFIT_MARKET，按市价单的数量规则（MARKET_LOT_SIZE）拆分
+	stepSize, err := binance.GetOrderStepSize(symbolInfo, ladderOrderType)
+	if err != nil {
+		return nil, err
+	}
+	minQty, err := binance.GetOrderMinQty(symbolInfo, ladderOrderType)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以stepSize为单位计算，避免浮点误差
+	totalUnits := int64(math.Floor(quantity/stepSize + 1e-9))
+	minUnits := int64(math.Ceil(minQty/stepSize - 1e-9))
+	if totalUnits <= 0 || totalUnits < minUnits {
+		return nil, fmt.Errorf("平仓数量 %.8f 小于最小交易量 %.8f", quantity, minQty)
+	}
+
+	units := make([]int64, len(ladder))
+	allocated, carry := int64(0), int64(0)
+	for i, level := range ladder[:len(ladder)-1] {
+		legUnits := int64(math.Floor(float64(totalUnits)*float64(level.Size)/100+1e-9)) + carry
+		if legUnits < minUnits {
+			carry = legUnits
+			continue
+		}
+		units[i], carry = legUnits, 0
+		allocated += legUnits
+	}
+	last := len(ladder) - 1
+	units[last] = totalUnits - allocated
+	if units[last] < minUnits {
+		// 最后一档不足minQty，并入前面最近的一档
+		for i := last - 1; i >= 0; i-- {
+			if units[i] > 0 {
+				units[i] += units[last]
+				units[last] = 0
+				break
+			}
+		}
+	}
+
+	legs := make([]ladderLeg, 0, len(ladder))
+	for i, legUnits := range units {
+		if legUnits == 0 {
+			continue
+		}
+		// 加半个stepSize，避免向下取整时因浮点误差少一个stepSize
+		qty, err := binance.ValidateAndAdjustOrderQuantity((float64(legUnits)+0.5)*stepSize, ladderOrderType, symbolInfo)
+		if err != nil {
+			return nil, fmt.Errorf("第%d档止盈数量无效: %w", i+1, err)
+		}
+		legs = append(legs, ladderLeg{Level: ladder[i], Quantity: qty})
+	}
+	return legs, nil
+}
+
+// createTakeProfitLadder 按分档配置拆分数量，为每一档创建只减仓的止盈单
+// 某一档创建失败时继续创建其余各档，返回已创建的止盈单和所有失败原因
+func (ts *TradingService) createTakeProfitLadder(ex exchange.Exchange, strategy *Strategy, entryPrice, quantity float64) ([]*models.OrderResponse, error) {
+	symbolInfo, err := ts.getSymbolInfo(ex, strategy.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	legs, err := splitLadder(quantity, strategy.TakeProfitLadder, symbolInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []*models.OrderResponse
+	var errs []string
+	for i, leg := range legs {
+		stopPrice, err := binance.ValidateAndAdjustPrice(strategy.takeProfitPriceAt(entryPrice, leg.Level.Percent), symbolInfo)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("第%d档调整止盈价格精度失败: %v", i+1, err))
+			continue
+		}
+		ts.warnPercentPrice(ex, symbolInfo, stopPrice)
+
+		logger.Infof("创建第%d档止盈订单（%s，%s）: %s, 开仓价格: %.8f, 止盈价格: %s, 数量: %s, 止盈百分比: %.2f%%",
+			i+1, strategy.directionLabel(), ex.Name(), strategy.Symbol, entryPrice, stopPrice, leg.Quantity, leg.Level.Percent)
+		order, err := ex.CreateTakeProfitOrder(&exchange.TriggerOrderRequest{
+			Symbol:       strategy.Symbol,
+			Side:         strategy.CloseSide(),
+			PositionSide: strategy.PositionSide,
+			StopPrice:    stopPrice,
+			Quantity:     leg.Quantity, // 不使用closePosition，按数量只减仓
+			WorkingType:  strategy.TakeProfitWorkingType,
+		})
+		metrics.Orders.Inc(ex.Name(), metrics.OrderTypeTakeProfit, metrics.OrderResult(err))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("第%d档: %v", i+1, err))
+			continue
+		}
+		orders = append(orders, order)
+	}
+	if len(errs) > 0 {
+		return orders, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return orders, nil
+}
+
+// createTakeProfitOrders 创建止盈单：配置了分档止盈且已知平仓数量时按档创建，否则创建单个止盈单
+func (ts *TradingService) createTakeProfitOrders(ex exchange.Exchange, strategy *Strategy, orderSet *OrderSet, entryPrice, qtyFloat float64, quantity string) error {
+	if len(strategy.TakeProfitLadder) > 0 && qtyFloat > 0 {
+		orders, err := ts.createTakeProfitLadder(ex, strategy, entryPrice, qtyFloat)
+		for i, order := range orders {
+			ts.publishOrder(events.TypeOrderPlaced, orderSet, order, fmt.Sprintf("第%d档止盈单", i+1))
+		}
+		orderSet.TakeProfitOrders = append(orderSet.TakeProfitOrders, orders...)
+		return err
+	}
+	if len(strategy.TakeProfitLadder) > 0 && strategy.TakeProfitPercent <= 0 {
+		// 策略可能被多个账户共用，复制后修改
+		logger.Warnf("无法获取平仓数量，%s 改为按最后一档创建单个止盈单", strategy.Symbol)
+		single := *strategy
+		single.TakeProfitPercent = strategy.TakeProfitLadder[len(strategy.TakeProfitLadder)-1].Percent
+		strategy = &single
+	}
+
+	order, err := ts.createTakeProfitOrder(ex, strategy, entryPrice, quantity)
+	metrics.Orders.Inc(ex.Name(), metrics.OrderTypeTakeProfit, metrics.OrderResult(err))
+	if err != nil {
+		return err
+	}
+	orderSet.TakeProfitOrder = order
+	ts.publishOrder(events.TypeOrderPlaced, orderSet, order, "止盈单")
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/models"
+)
+
+// ladderSymbol stepSize为0.1，最小交易量为minQty
+func ladderSymbol(minQty string) *models.Symbol {
+	return &models.Symbol{
+		Symbol:  "ABCUSDT",
+		Filters: []models.Filter{{FilterType: "LOT_SIZE", MinQty: minQty, StepSize: "0.1"}},
+	}
+}
+
+func ladderOf(sizes ...float64) []config.TakeProfitLevel {
+	ladder := make([]config.TakeProfitLevel, len(sizes))
+	for i, size := range sizes {
+		ladder[i] = config.TakeProfitLevel{Percent: float64(i + 1), Size: config.SizePercent(size)}
+	}
+	return ladder
+}
+
+func TestSplitLadder(t *testing.T) {
+	cases := []struct {
+		name     string
+		quantity float64
+		minQty   string
+		sizes    []float64
+		want     []string
+		percents []float64
+	}{
+		{"按比例拆分", 10, "0.1", []float64{40, 30, 30}, []string{"4.0", "3.0", "3.0"}, []float64{1, 2, 3}},
+		{"零头并入最后一档", 1.05, "0.1", []float64{33, 33, 34}, []string{"0.3", "0.3", "0.4"}, []float64{1, 2, 3}},
+		{"不足最小交易量的档并入下一档", 1, "0.3", []float64{20, 20, 60}, []string{"0.4", "0.6"}, []float64{2, 3}},
+		{"最后一档不足最小交易量时并入前一档", 1, "0.3", []float64{45, 45, 10}, []string{"0.4", "0.6"}, []float64{1, 2}},
+	}
+	for _, c := range cases {
+		legs, err := splitLadder(c.quantity, ladderOf(c.sizes...), ladderSymbol(c.minQty))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(legs) != len(c.want) {
+			t.Errorf("%s: 期望 %v，实际 %+v", c.name, c.want, legs)
+			continue
+		}
+		for i, leg := range legs {
+			if leg.Quantity != c.want[i] || leg.Level.Percent != c.percents[i] {
+				t.Errorf("%s: 第%d档期望 %s@%g%%，实际 %s@%g%%", c.name, i+1, c.want[i], c.percents[i], leg.Quantity, leg.Level.Percent)
+			}
+		}
+	}
+
+	if _, err := splitLadder(0.05, ladderOf(50, 50), ladderSymbol("0.1")); err == nil {
+		t.Error("数量小于最小交易量时应返回错误")
+	}
+}
+
+func TestCreateOrderSetLadder(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+	trading := ts.config.Trading
+	trading.TakeProfit = config.TakeProfitConfig{Enabled: true, Ladder: []config.TakeProfitLevel{
+		{Percent: 3, Size: 40}, {Percent: 6, Size: 30}, {Percent: 10, Size: 30},
+	}}
+	resolver, err := NewStrategyResolver(trading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.resolver = resolver
+	ts.config = &config.Config{Trading: trading}
+
+	orderSet, err := ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", "")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if orderSet.TakeProfitOrder != nil || len(orderSet.TakeProfitOrders) != 3 || orderSet.TakeProfitError != nil {
+		t.Fatalf("应按档创建3个止盈单: %+v", orderSet)
+	}
+	for i, want := range []string{"4.0", "3.0", "3.0"} {
+		if req := ex.takeProfitReqs[i]; req.Quantity != want || req.ClosePosition {
+			t.Errorf("第%d档止盈单应只减仓 %s: %+v", i+1, want, req)
+		}
+	}
+
+	// 双向持仓模式下各档止盈单按positionSide平仓
+	ex = &fakeGuardExchange{price: "10"}
+	ts = newGuardTestService(t, ex, config.StopLossConfig{})
+	trading.PositionSide = "SHORT"
+	if ts.resolver, err = NewStrategyResolver(trading); err != nil {
+		t.Fatal(err)
+	}
+	ts.config = &config.Config{Trading: trading}
+	if orderSet, err = ts.CreateOrdersWithStopLossAndTakeProfit("ABCUSDT", ""); err != nil || len(orderSet.TakeProfitOrders) != 3 {
+		t.Fatalf("双向持仓应按档创建3个止盈单: %v", err)
+	}
+	for i, req := range ex.takeProfitReqs {
+		if req.PositionSide != "SHORT" || req.ClosePosition {
+			t.Errorf("第%d档止盈单应按SHORT持仓平仓: %+v", i+1, req)
+		}
+	}
+}
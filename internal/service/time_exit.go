@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// DefaultTimeExitInterval 默认的持仓时间检查间隔
+const DefaultTimeExitInterval = 30 * time.Second
+
+// 开仓时间来源
+const (
+	EntryTimeFromJournal  = "journal"  // 交易日志中的开仓时间
+	EntryTimeFromPosition = "position" // 持仓更新时间（交易日志中没有记录时）
+)
+
+// heldPosition 按持仓时间平仓跟踪中的持仓
+type heldPosition struct {
+	entryTime   int64   // 开仓时间（毫秒）
+	source      string  // 开仓时间来源
+	onboardDate int64   // 币对上线时间（毫秒），用于匹配策略规则
+	initialQty  float64 // 开仓数量，分批平仓按该数量计算
+	skipExits   int     // 开始跟踪时已经过、不再执行的检查点数，-1表示尚未计算
+}
+
+// TimeExitScheduler 按持仓时间平仓：超过最长持仓时间时市价平仓并撤销止盈止损单，到达检查点时分批平仓；
+// 配置了 trading.funding.close_before_funding 时，在资金费结算前平掉需要支付高额资金费的仓位
+// 开仓时间和数量优先取交易日志（重启后仍然有效），没有记录时取第一次检查到该持仓时的持仓更新时间和数量
+type TimeExitScheduler struct {
+	ts        *TradingService
+	mu        sync.Mutex
+	positions map[string]*heldPosition // key为 账户/币对/持仓方向
+}
+
+// NewTimeExitScheduler 创建按持仓时间平仓的调度器
+func NewTimeExitScheduler(ts *TradingService) *TimeExitScheduler {
+	return &TimeExitScheduler{ts: ts, positions: make(map[string]*heldPosition)}
+}
+
+// Run 定时检查，直到ctx取消
+func (s *TimeExitScheduler) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultTimeExitInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Check()
+		}
+	}
+}
+
+// Check 检查一次所有账户的持仓，未配置按持仓时间平仓和结算前平仓时不查询持仓
+func (s *TimeExitScheduler) Check() {
+	s.ts.mu.RLock()
+	resolver := s.ts.resolver
+	funding := s.ts.config.Trading.Funding
+	s.ts.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !resolver.hasTimeExits() && funding.CloseBeforeFunding <= 0 {
+		s.positions = make(map[string]*heldPosition)
+		return
+	}
+
+	now := time.Now()
+	for _, acc := range s.ts.accounts {
+		positionRisks, err := acc.Exchange.GetPositionRisk("")
+		if err != nil {
+			logger.Warnf("按持仓时间平仓检查失败，账户 %s: %v", acc.Name, err)
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, pr := range positionRisks {
+			positionAmt, err := strconv.ParseFloat(pr.PositionAmt, 64)
+			if err != nil || positionAmt == 0 {
+				continue
+			}
+			key := fmt.Sprintf("%s/%s/%s", acc.Name, pr.Symbol, pr.PositionSide)
+			seen[key] = true
+			if closed := s.checkPosition(acc, key, pr, positionAmt, funding, now); closed {
+				delete(s.positions, key)
+			}
+		}
+
+		// 已平掉的持仓停止跟踪，下次开仓重新取开仓时间
+		for key := range s.positions {
+			if strings.HasPrefix(key, acc.Name+"/") && !seen[key] {
+				delete(s.positions, key)
+			}
+		}
+	}
+}
+
+// checkPosition 检查单个持仓，返回是否已全部平仓
+func (s *TimeExitScheduler) checkPosition(acc *Account, key string, pr models.PositionRisk, positionAmt float64, funding config.FundingConfig, now time.Time) bool {
+	direction := DirectionLong
+	if positionAmt < 0 {
+		direction = DirectionShort
+	}
+	quantity := math.Abs(positionAmt)
+
+	held, ok := s.positions[key]
+	if !ok {
+		held = s.track(acc, pr, direction, quantity, now)
+		s.positions[key] = held
+	}
+
+	// 与策略开仓方向相反的持仓（例如手动开的仓）不处理
+	strategy := s.ts.ResolveStrategy(pr.Symbol, held.onboardDate)
+	if strategy.Direction != direction {
+		return false
+	}
+	elapsed := now.Sub(time.UnixMilli(held.entryTime))
+
+	if held.skipExits < 0 {
+		// 不知道开仓数量时无法确定之前是否已经分批平仓（例如重启前），已经过的检查点不再执行
+		held.skipExits = 0
+		for _, exit := range strategy.PartialExits {
+			if elapsed >= exit.After {
+				held.skipExits++
+			}
+		}
+	}
+
+	if strategy.MaxHoldingDuration > 0 && elapsed >= strategy.MaxHoldingDuration {
+		logger.Infof("账户 %s 的 %s 已持仓 %s，超过最长持仓时间 %s，市价平仓", acc.Name, pr.Symbol, elapsed.Truncate(time.Second), strategy.MaxHoldingDuration)
+		if _, err := s.ts.closePosition(acc.Name, pr.Symbol, pr.PositionSide, CloseReasonTimeExit); err != nil {
+			logger.Errorf("持仓超时平仓失败，下次检查时重试: %v", err)
+			return false
+		}
+		return true
+	}
+	if s.fundingExit(acc, pr, direction, funding, now) {
+		return true
+	}
+
+	s.partialExit(acc, pr, held, strategy, positionAmt, elapsed)
+	return false
+}
+
+// fundingExit 距下次资金费结算不足 close_before_funding，且需要支付的资金费率达到 close_min_rate 时市价平仓，返回是否已平仓
+func (s *TimeExitScheduler) fundingExit(acc *Account, pr models.PositionRisk, direction string, funding config.FundingConfig, now time.Time) bool {
+	if funding.CloseBeforeFunding <= 0 {
+		return false
+	}
+	provider, ok := acc.Exchange.(exchange.FundingRateProvider)
+	if !ok {
+		return false
+	}
+	index, err := provider.GetPremiumIndex(pr.Symbol)
+	if err != nil {
+		logger.Warnf("查询 %s 资金费率失败，下次检查时重试: %v", pr.Symbol, err)
+		return false
+	}
+	rate, err := strconv.ParseFloat(index.LastFundingRate, 64)
+	if err != nil || index.NextFundingTime == 0 {
+		return false
+	}
+	untilFunding := time.UnixMilli(index.NextFundingTime).Sub(now)
+	cost := fundingCostPercent(direction, rate)
+	if untilFunding <= 0 || untilFunding > funding.CloseBeforeFunding || cost <= 0 || cost < funding.CloseMinRate {
+		return false
+	}
+
+	logger.Infof("账户 %s 的 %s 距资金费结算还有 %s，需要支付资金费 %.4f%%，市价平仓", acc.Name, pr.Symbol, untilFunding.Truncate(time.Second), cost)
+	if _, err := s.ts.closePosition(acc.Name, pr.Symbol, pr.PositionSide, CloseReasonFunding); err != nil {
+		logger.Errorf("资金费结算前平仓失败，下次检查时重试: %v", err)
+		return false
+	}
+	return true
+}
+
+// track 开始跟踪持仓，取交易日志中最近一次同方向开仓的时间和数量（币安主账户的开仓记录可能没有账户名称）
+func (s *TimeExitScheduler) track(acc *Account, pr models.PositionRisk, direction string, quantity float64, now time.Time) *heldPosition {
+	entry, found := s.ts.journal.latestEntry(acc.Exchange.Name(), acc.Name, pr.Symbol, direction)
+	if !found && acc == s.ts.accounts[0] {
+		entry, found = s.ts.journal.latestEntry(acc.Exchange.Name(), "", pr.Symbol, direction)
+	}
+	if found {
+		held := &heldPosition{entryTime: entry.EntryTime, source: EntryTimeFromJournal, onboardDate: entry.OnboardDate, initialQty: quantity, skipExits: -1}
+		if qty, err := strconv.ParseFloat(entry.Quantity, 64); err == nil && qty > 0 {
+			held.initialQty, held.skipExits = qty, 0
+		}
+		return held
+	}
+
+	entryTime := pr.UpdateTime
+	if entryTime == 0 {
+		entryTime = now.UnixMilli()
+	}
+	return &heldPosition{entryTime: entryTime, source: EntryTimeFromPosition, initialQty: quantity, skipExits: -1}
+}
+
+// partialExit 按已到达的检查点计算应保留的数量（开仓数量 × (1 - 累计百分比)），市价平掉超出的部分
+// 按目标数量计算，重复检查或重启后不会重复平仓
+func (s *TimeExitScheduler) partialExit(acc *Account, pr models.PositionRisk, held *heldPosition, strategy *Strategy, positionAmt float64, elapsed time.Duration) {
+	percent := 0.0
+	for i, exit := range strategy.PartialExits {
+		if elapsed < exit.After {
+			break
+		}
+		if i >= held.skipExits {
+			percent += exit.Percent
+		}
+	}
+	if percent == 0 {
+		return
+	}
+
+	remaining := held.initialQty * (1 - percent/100)
+	excess := math.Abs(positionAmt) - remaining
+	if excess <= held.initialQty*quantityTolerance {
+		return
+	}
+	quantity, err := s.ts.adjustQuantity(acc.Exchange, pr.Symbol, excess)
+	if err != nil {
+		logger.Debugf("账户 %s 的 %s 分批平仓数量无效，跳过: %v", acc.Name, pr.Symbol, err)
+		return
+	}
+
+	side := "SELL"
+	if positionAmt < 0 {
+		side = "BUY"
+	}
+	logger.Infof("账户 %s 的 %s 已持仓 %s，分批平仓 %s（累计 %g%%）", acc.Name, pr.Symbol, elapsed.Truncate(time.Second), quantity, percent)
+	order, err := acc.Exchange.CreateMarketOrder(&exchange.MarketOrderRequest{
+		Symbol:       pr.Symbol,
+		Side:         side,
+		PositionSide: pr.PositionSide,
+		Quantity:     quantity,
+		// 双向持仓模式下不能携带reduceOnly，按positionSide平仓
+		ReduceOnly: pr.PositionSide == "" || pr.PositionSide == "BOTH",
+	})
+	if err != nil {
+		logger.Errorf("分批平仓失败，下次检查时重试: %v", err)
+		s.ts.bus.Publish(events.Event{
+			Type:     events.TypeError,
+			Symbol:   pr.Symbol,
+			Exchange: acc.Exchange.Name(),
+			Account:  acc.Name,
+			Message:  fmt.Sprintf("分批平仓失败: %v", err),
+		})
+		return
+	}
+	s.ts.publishOrder(events.TypeOrderPlaced, &OrderSet{Symbol: pr.Symbol, Exchange: acc.Exchange.Name(), Account: acc.Name}, order, "分批平仓单")
+
+	// 按开仓价和平仓成交均价估算盈亏，没有成交均价时使用标记价格
+	closedQty, _ := strconv.ParseFloat(quantity, 64)
+	entryPrice, _ := strconv.ParseFloat(pr.EntryPrice, 64)
+	exitPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	if exitPrice == 0 {
+		exitPrice, _ = strconv.ParseFloat(pr.MarkPrice, 64)
+	}
+	pnl := (exitPrice - entryPrice) * closedQty
+	if positionAmt < 0 {
+		pnl = -pnl
+	}
+	publishPositionClosed(s.ts.bus, acc.Exchange.Name(), acc.Name, pr.Symbol, &ClosedPosition{
+		Reason:     CloseReasonPartialExit,
+		Quantity:   closedQty,
+		EntryPrice: entryPrice,
+		ExitPrice:  exitPrice,
+		PnL:        pnl,
+	})
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/models"
+)
+
+// newTimeExitTestService 开仓后1小时平掉30%，4小时全部平仓
+func newTimeExitTestService(t *testing.T, ex *fakeGuardExchange) *TradingService {
+	t.Helper()
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+	trading := ts.config.Trading
+	trading.MaxHoldingDuration = 4 * time.Hour
+	trading.PartialExits = []config.PartialExit{{After: time.Hour, Percent: 30}}
+	resolver, err := NewStrategyResolver(trading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.resolver = resolver
+	ts.config = &config.Config{Trading: trading}
+	return ts
+}
+
+// recordEntry 在交易日志中记录一次开仓
+func recordEntry(ts *TradingService, entryTime time.Time, quantity string) {
+	ts.journal.Record(&OrderSet{
+		Symbol:    "ABCUSDT",
+		Exchange:  ts.exchange.Name(),
+		Account:   "main",
+		Direction: DirectionShort,
+		SellOrder: &models.OrderResponse{OrderID: 1, UpdateTime: entryTime.UnixMilli(), ExecutedQty: quantity},
+	}, 0)
+}
+
+func TestTimeExitPartial(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", PositionSide: "BOTH"}}
+	ts := newTimeExitTestService(t, ex)
+	ts.journal, _ = NewTradeJournal("")
+	recordEntry(ts, time.Now().Add(-90*time.Minute), "10")
+
+	NewTimeExitScheduler(ts).Check()
+	if len(ex.orders) != 1 {
+		t.Fatalf("到达检查点应分批平仓，实际订单数: %d", len(ex.orders))
+	}
+	if order := ex.orders[0]; order.Side != "BUY" || order.Quantity != "3.0" || !order.ReduceOnly {
+		t.Errorf("应只减仓买入开仓数量的30%%: %+v", order)
+	}
+
+	// 已平掉30%后（包括重启后按交易日志重新计算）不再重复平仓
+	ex.positions[0].PositionAmt = "-7"
+	NewTimeExitScheduler(ts).Check()
+	if len(ex.orders) != 1 {
+		t.Errorf("已完成的检查点不应重复平仓，实际订单数: %d", len(ex.orders))
+	}
+}
+
+func TestTimeExitMaxHolding(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-7", EntryPrice: "10", PositionSide: "BOTH"}}
+	ex.openOrders = []models.OrderResponse{{OrderID: 5, Symbol: "ABCUSDT", Type: "STOP_MARKET", Side: "BUY"}}
+	ts := newTimeExitTestService(t, ex)
+	ts.journal, _ = NewTradeJournal("")
+	recordEntry(ts, time.Now().Add(-5*time.Hour), "10")
+
+	NewTimeExitScheduler(ts).Check()
+	if len(ex.orders) != 1 || ex.orders[0].Quantity != "7" {
+		t.Fatalf("超过最长持仓时间应平掉全部持仓: %+v", ex.orders)
+	}
+	if len(ex.canceled) != 1 || ex.canceled[0] != 5 {
+		t.Errorf("平仓后应撤销止盈止损单，实际: %v", ex.canceled)
+	}
+}
+
+func TestTimeExitWithoutJournal(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	updated := time.Now().Add(-90 * time.Minute).UnixMilli()
+	ex.positions = []models.PositionRisk{
+		{Symbol: "ABCUSDT", PositionAmt: "-7", EntryPrice: "10", PositionSide: "BOTH", UpdateTime: updated},
+		// 与策略方向相反的持仓不处理
+		{Symbol: "XYZUSDT", PositionAmt: "5", EntryPrice: "10", PositionSide: "BOTH", UpdateTime: time.Now().Add(-5 * time.Hour).UnixMilli()},
+	}
+	ts := newTimeExitTestService(t, ex)
+
+	scheduler := NewTimeExitScheduler(ts)
+	scheduler.Check()
+	if len(ex.orders) != 0 {
+		t.Fatalf("没有交易日志时已经过的检查点不应执行: %+v", ex.orders)
+	}
+
+	// 按持仓更新时间计算，超过最长持仓时间后平仓
+	scheduler.positions["main/ABCUSDT/BOTH"].entryTime = time.Now().Add(-5 * time.Hour).UnixMilli()
+	scheduler.Check()
+	if len(ex.orders) != 1 || ex.orders[0].Symbol != "ABCUSDT" {
+		t.Errorf("超过最长持仓时间应平仓: %+v", ex.orders)
+	}
+}
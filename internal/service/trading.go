@@ -1,161 +1,244 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"new_listing_trade/internal/api/binance"
 	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
 	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/metrics"
 	"new_listing_trade/internal/models"
 )
 
 // TradingService 交易服务
 type TradingService struct {
-	client *binance.Client
-	config *config.Config
-	mu     sync.RWMutex
+	exchange exchange.Exchange            // 主交易所（第一个币安账户）
+	accounts []*Account                   // 已启用的币安账户（按配置顺序）
+	venues   map[string]exchange.Exchange // 已启用的交易所，key为交易所名称（binance/bybit/okx）
+	resolver *StrategyResolver            // 按币对解析交易参数
+	brackets *BracketMonitor              // 跟踪止盈止损单状态
+	bus      *events.Bus                  // 事件总线（未设置时不发布事件）
+	journal  *TradeJournal                // 交易日志（未设置时不记录）
+	config   *config.Config
+	mu       sync.RWMutex
+
+	symbolLocks sync.Map // key为 账户/币对，串行化移动止损和仓位保护检查对条件单的修改
 }
 
 // NewTradingService 创建交易服务
 func NewTradingService(cfg *config.Config) (*TradingService, error) {
-	if cfg.Binance.APIKey == "" || cfg.Binance.SecretKey == "" {
+	accountConfigs := cfg.BinanceAccounts()
+	if len(accountConfigs) == 0 {
 		return nil, fmt.Errorf("币安API密钥未配置")
 	}
 
-	// 根据配置创建客户端
-	apiType := cfg.Binance.APIType
-	if apiType == "" {
-		apiType = "fapi" // 默认使用fapi
+	resolver, err := NewStrategyResolver(cfg.Trading)
+	if err != nil {
+		return nil, fmt.Errorf("策略规则配置错误: %w", err)
 	}
-	client := binance.NewClientWithConfig(cfg.Binance.APIKey, cfg.Binance.SecretKey, apiType, cfg.Binance.BaseURL)
 
-	// 记录使用的API类型和baseURL
-	if apiType == "papi" {
-		logger.Infof("使用统一账户接口 (papi): %s", binance.BinancePortfolioBaseURL)
-	} else {
-		logger.Infof("使用U本位合约接口 (fapi): %s", binance.BinanceFuturesBaseURL)
+	// 根据配置为每个账户创建币安适配器
+	accounts := make([]*Account, 0, len(accountConfigs))
+	for _, accountCfg := range accountConfigs {
+		accounts = append(accounts, newAccount(accountCfg))
+
+		// 记录使用的API类型和baseURL
+		if accountCfg.APIType == "papi" {
+			logger.Infof("账户 %s 使用统一账户接口 (papi): %s", accountCfg.Name, binance.BinancePortfolioBaseURL)
+		} else {
+			logger.Infof("账户 %s 使用U本位合约接口 (fapi): %s", accountCfg.Name, binance.BinanceFuturesBaseURL)
+		}
+	}
+	primary := accounts[0].Exchange
+
+	venues := map[string]exchange.Exchange{
+		exchange.NameBinance: primary,
+	}
+	if cfg.Bybit.Enabled && cfg.Bybit.APIKey != "" && cfg.Bybit.SecretKey != "" {
+		venues[exchange.NameBybit] = exchange.NewBybit(cfg.Bybit)
+		logger.Info("已启用Bybit USDT永续交易")
+	}
+	if cfg.OKX.Enabled && cfg.OKX.APIKey != "" && cfg.OKX.SecretKey != "" && cfg.OKX.Passphrase != "" {
+		venues[exchange.NameOKX] = exchange.NewOKX(cfg.OKX)
+		logger.Info("已启用OKX USDT永续交易")
 	}
 
 	return &TradingService{
-		client: client,
-		config: cfg,
+		exchange: primary,
+		accounts: accounts,
+		venues:   venues,
+		resolver: resolver,
+		brackets: NewBracketMonitor(nil),
+		config:   cfg,
 	}, nil
 }
 
+// getConfig 获取当前配置（热更新时整体替换，调用方不应修改返回值）
+func (ts *TradingService) getConfig() *config.Config {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.config
+}
+
+// ApplyConfig 热更新交易配置，返回所有变更的字段
+// 只有trading配置会生效，其他字段（API密钥、账户、端口等）的变更标记为需要重启且不会应用
+func (ts *TradingService) ApplyConfig(newCfg *config.Config) ([]config.Change, error) {
+	resolver, err := NewStrategyResolver(newCfg.Trading)
+	if err != nil {
+		return nil, fmt.Errorf("策略规则配置错误: %w", err)
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	changes := config.Diff(ts.config, newCfg)
+
+	// 复制当前配置，只替换可以热更新的部分
+	merged := *ts.config
+	merged.Trading = newCfg.Trading
+	ts.config = &merged
+	ts.resolver = resolver
+
+	return changes, nil
+}
+
+// ResolveStrategy 解析币对生效的交易参数
+// onboardDate: 上线时间（毫秒时间戳），未知时传0
+func (ts *TradingService) ResolveStrategy(symbol string, onboardDate int64) *Strategy {
+	ts.mu.RLock()
+	resolver := ts.resolver
+	ts.mu.RUnlock()
+	return resolver.Resolve(symbol, onboardDate)
+}
+
+// GetExchange 获取指定名称的交易所，name留空返回主交易所
+func (ts *TradingService) GetExchange(name string) (exchange.Exchange, error) {
+	if name == "" {
+		return ts.exchange, nil
+	}
+	ex, ok := ts.venues[name]
+	if !ok {
+		return nil, fmt.Errorf("交易所 %s 未启用", name)
+	}
+	return ex, nil
+}
+
 // CreateMarketSellOrder 创建市价卖单（做空，按USDT金额）
 func (ts *TradingService) CreateMarketSellOrder(symbol string, notionalUSDT string) (*models.OrderResponse, error) {
+	strategy := ts.ResolveStrategy(symbol, 0)
+	strategy.Direction = DirectionShort
+	strategy.PositionSide = positionSideFor(DirectionShort, ts.getConfig().Trading.PositionSide)
 	if notionalUSDT == "" {
-		notionalUSDT = ts.config.Trading.DefaultNotional
+		notionalUSDT = strategy.Notional
 	}
-
-	// 检查是否使用统一账户接口（papi），统一账户接口不支持notional参数，需要使用quantity
-	apiType := ts.config.Binance.APIType
-	if apiType == "" {
-		apiType = "fapi"
+	if _, err := ts.checkFunding(ts.exchange, strategy); err != nil {
+		return nil, err
 	}
-
-	req := &models.OrderRequest{
-		Symbol:       symbol,
-		Side:         "SELL", // 做空
-		Type:         "MARKET",
-		PositionSide: ts.config.Trading.PositionSide,
+	check, err := ts.checkLiquidity(ts.exchange, strategy, notionalUSDT)
+	if err != nil {
+		return nil, err
 	}
+	if check != nil && check.Decision == LiquidityDownsize {
+		notionalUSDT = strconv.FormatFloat(check.Notional, 'f', 2, 64)
+	}
+	return ts.createMarketEntry(ts.exchange, strategy, notionalUSDT)
+}
 
-	// 如果是统一账户接口（papi），需要将notional转换为quantity
-	if apiType == "papi" {
-		// 获取交易所信息，用于获取交易对精度规则
-		exchangeInfo, err := ts.client.GetExchangeInfo()
-		if err != nil {
-			return nil, fmt.Errorf("获取交易所信息失败: %w", err)
-		}
-
-		// 获取交易对信息
-		symbolInfo, err := binance.GetSymbolInfo(exchangeInfo, symbol)
-		if err != nil {
-			return nil, fmt.Errorf("获取交易对信息失败: %w", err)
-		}
+// createMarketEntry 在指定交易所创建市价开仓单（按USDT金额，是否换算为数量由适配器决定）
+func (ts *TradingService) createMarketEntry(ex exchange.Exchange, strategy *Strategy, notionalUSDT string) (*models.OrderResponse, error) {
+	logger.Infof("创建市价开仓单（%s，%s）: %s, USDT金额: %s", strategy.directionLabel(), ex.Name(), strategy.Symbol, notionalUSDT)
+	return ex.CreateMarketOrder(&exchange.MarketOrderRequest{
+		Symbol:       strategy.Symbol,
+		Side:         strategy.EntrySide(),
+		PositionSide: strategy.PositionSide,
+		Notional:     notionalUSDT,
+	})
+}
 
-		// 获取当前价格
-		tickerPrice, err := ts.client.GetTickerPrice(symbol)
-		if err != nil {
-			return nil, fmt.Errorf("获取当前价格失败，无法计算quantity: %w", err)
-		}
+// CreateStopLossOrder 创建止损订单（做空时价格上涨触发，做多时价格下跌触发）
+// quantity: 平仓数量（不支持closePosition的接口需要，例如papi，可选参数）
+func (ts *TradingService) CreateStopLossOrder(symbol string, entryPrice float64, quantity ...string) (*models.OrderResponse, error) {
+	quantityStr := ""
+	if len(quantity) > 0 {
+		quantityStr = quantity[0]
+	}
+	return ts.createStopLossOrder(ts.exchange, ts.ResolveStrategy(symbol, 0), entryPrice, quantityStr)
+}
 
-		// 计算quantity
-		notionalFloat, err := strconv.ParseFloat(notionalUSDT, 64)
-		if err != nil {
-			return nil, fmt.Errorf("无效的USDT金额: %s", notionalUSDT)
-		}
+// createStopLossOrder 在指定交易所创建止损订单
+func (ts *TradingService) createStopLossOrder(ex exchange.Exchange, strategy *Strategy, entryPrice float64, quantity string) (*models.OrderResponse, error) {
+	if !strategy.StopLossEnabled {
+		return nil, fmt.Errorf("止损功能未启用")
+	}
 
-		priceFloat, err := strconv.ParseFloat(tickerPrice.Price, 64)
-		if err != nil {
-			return nil, fmt.Errorf("无效的价格: %s", tickerPrice.Price)
-		}
+	// 计算止损价格并调整stopPrice精度
+	stopPriceStr, err := ts.adjustTriggerPrice(ex, strategy.Symbol, strategy.StopLossPrice(entryPrice))
+	if err != nil {
+		return nil, fmt.Errorf("调整止损价格失败: %w", err)
+	}
 
-		if priceFloat <= 0 {
-			return nil, fmt.Errorf("价格无效: %f", priceFloat)
-		}
+	logger.Infof("创建止损订单（%s，%s）: %s, 开仓价格: %.8f, 止损价格: %s, 数量: %s, 止损百分比: %.2f%%",
+		strategy.directionLabel(), ex.Name(), strategy.Symbol, entryPrice, stopPriceStr, quantity, strategy.StopLossPercent)
 
-		// quantity = notional / price
-		quantityFloat := notionalFloat / priceFloat
+	return ex.CreateStopOrder(&exchange.TriggerOrderRequest{
+		Symbol:        strategy.Symbol,
+		Side:          strategy.CloseSide(), // 止损是开仓的反向操作
+		PositionSide:  strategy.PositionSide,
+		StopPrice:     stopPriceStr,
+		Quantity:      quantity,
+		ClosePosition: true, // 支持时自动平掉整个持仓
+		WorkingType:   strategy.StopLossWorkingType,
+	})
+}
 
-		// 验证并调整quantity精度
-		quantityStr, err := binance.ValidateAndAdjustQuantity(quantityFloat, symbolInfo)
-		if err != nil {
-			return nil, fmt.Errorf("调整quantity精度失败: %w", err)
-		}
+// CreateTakeProfitOrder 创建止盈订单（做空时价格下跌触发，做多时价格上涨触发）
+// quantity: 平仓数量（不支持closePosition的接口需要，例如papi，可选参数）
+func (ts *TradingService) CreateTakeProfitOrder(symbol string, entryPrice float64, quantity ...string) (*models.OrderResponse, error) {
+	quantityStr := ""
+	if len(quantity) > 0 {
+		quantityStr = quantity[0]
+	}
+	return ts.createTakeProfitOrder(ts.exchange, ts.ResolveStrategy(symbol, 0), entryPrice, quantityStr)
+}
 
-		req.Quantity = quantityStr
+// createTakeProfitOrder 在指定交易所创建止盈订单
+func (ts *TradingService) createTakeProfitOrder(ex exchange.Exchange, strategy *Strategy, entryPrice float64, quantity string) (*models.OrderResponse, error) {
+	if !strategy.TakeProfitEnabled {
+		return nil, fmt.Errorf("止盈功能未启用")
+	}
 
-		logger.Infof("创建市价卖单（做空，统一账户）: %s, USDT金额: %s, 当前价格: %s, 计算数量: %s",
-			symbol, notionalUSDT, tickerPrice.Price, req.Quantity)
-	} else {
-		// fapi接口可以使用notional
-		req.Notional = notionalUSDT
-		logger.Infof("创建市价卖单（做空）: %s, USDT金额: %s", symbol, notionalUSDT)
-	}
-
-	return ts.client.CreateOrder(req)
-}
-
-// convertConditionalOrderToOrderResponse 将条件单响应转换为订单响应（用于兼容性）
-func convertConditionalOrderToOrderResponse(condResp *models.ConditionalOrderResponse) *models.OrderResponse {
-	return &models.OrderResponse{
-		OrderID:       condResp.StrategyID,
-		Symbol:        condResp.Symbol,
-		Status:        condResp.StrategyStatus,
-		ClientOrderID: condResp.NewClientStrategyId,
-		Price:         condResp.Price,
-		OrigQty:       condResp.OrigQty,
-		TimeInForce:   condResp.TimeInForce,
-		Type:          condResp.StrategyType,
-		ReduceOnly:    condResp.ReduceOnly,
-		Side:          condResp.Side,
-		PositionSide:  condResp.PositionSide,
-		StopPrice:     condResp.StopPrice,
-		WorkingType:   condResp.WorkingType,
-		PriceProtect:  condResp.PriceProtect,
-		UpdateTime:    condResp.UpdateTime,
-		Time:          condResp.BookTime,
-	}
-}
-
-// CreateStopLossOrder 创建止损订单（做空时，价格上涨触发止损）
-// quantity: 平仓数量（仅papi需要，可选参数）
-func (ts *TradingService) CreateStopLossOrder(symbol string, entryPrice float64, quantity ...string) (*models.OrderResponse, error) {
-	if !ts.config.Trading.StopLoss.Enabled {
-		return nil, fmt.Errorf("止损功能未启用")
+	// 计算止盈价格并调整stopPrice精度
+	stopPriceStr, err := ts.adjustTriggerPrice(ex, strategy.Symbol, strategy.TakeProfitPrice(entryPrice))
+	if err != nil {
+		return nil, fmt.Errorf("调整止盈价格失败: %w", err)
 	}
 
-	// 计算止损价格（做空时，止损价高于开仓价）
-	stopLossPercent := ts.config.Trading.StopLoss.Percent / 100.0
-	stopLossPrice := entryPrice * (1 + stopLossPercent) // 做空时价格上涨触发止损
+	logger.Infof("创建止盈订单（%s，%s）: %s, 开仓价格: %.8f, 止盈价格: %s, 数量: %s, 止盈百分比: %.2f%%",
+		strategy.directionLabel(), ex.Name(), strategy.Symbol, entryPrice, stopPriceStr, quantity, strategy.TakeProfitPercent)
 
+	return ex.CreateTakeProfitOrder(&exchange.TriggerOrderRequest{
+		Symbol:        strategy.Symbol,
+		Side:          strategy.CloseSide(), // 止盈是开仓的反向操作
+		PositionSide:  strategy.PositionSide,
+		StopPrice:     stopPriceStr,
+		Quantity:      quantity,
+		ClosePosition: true, // 支持时自动平掉整个持仓
+		WorkingType:   strategy.TakeProfitWorkingType,
+	})
+}
+
+// getSymbolInfo 获取交易对精度规则
+func (ts *TradingService) getSymbolInfo(ex exchange.Exchange, symbol string) (*models.Symbol, error) {
 	// 获取交易所信息，用于获取交易对精度规则
-	exchangeInfo, err := ts.client.GetExchangeInfo()
+	exchangeInfo, err := ex.GetExchangeInfo()
 	if err != nil {
 		return nil, fmt.Errorf("获取交易所信息失败: %w", err)
 	}
@@ -165,170 +248,148 @@ func (ts *TradingService) CreateStopLossOrder(symbol string, entryPrice float64,
 	if err != nil {
 		return nil, fmt.Errorf("获取交易对信息失败: %w", err)
 	}
+	return symbolInfo, nil
+}
 
-	// 调整stopPrice精度
-	stopPriceStr, err := binance.ValidateAndAdjustPrice(stopLossPrice, symbolInfo)
+// adjustPrice 按交易对tickSize调整价格精度
+func (ts *TradingService) adjustPrice(ex exchange.Exchange, symbol string, price float64) (string, error) {
+	symbolInfo, err := ts.getSymbolInfo(ex, symbol)
 	if err != nil {
-		return nil, fmt.Errorf("调整止损价格精度失败: %w", err)
+		return "", err
 	}
+	return binance.ValidateAndAdjustPrice(price, symbolInfo)
+}
 
-	// 检查API类型，统一账户接口使用条件单接口
-	apiType := ts.config.Binance.APIType
-	if apiType == "" {
-		apiType = "fapi"
+// adjustTriggerPrice 按tickSize调整止盈止损触发价格，超出PERCENT_PRICE范围时只记录警告
+func (ts *TradingService) adjustTriggerPrice(ex exchange.Exchange, symbol string, price float64) (string, error) {
+	symbolInfo, err := ts.getSymbolInfo(ex, symbol)
+	if err != nil {
+		return "", err
 	}
-
-	// 统一账户接口使用条件单接口
-	if apiType == "papi" {
-		// 统一账户条件单接口需要quantity参数
-		quantityStr := ""
-		if len(quantity) > 0 && quantity[0] != "" {
-			quantityStr = quantity[0]
-		} else {
-			return nil, fmt.Errorf("统一账户条件单需要quantity参数，请提供平仓数量")
-		}
-
-		// 使用条件单接口，STOP_MARKET类型需要stopPrice和quantity
-		condReq := &models.ConditionalOrderRequest{
-			Symbol:       symbol,
-			Side:         "BUY", // 止损是买入平仓（做空的反向操作）
-			StrategyType: "STOP_MARKET",
-			StopPrice:    stopPriceStr,
-			Quantity:     quantityStr,
-			ReduceOnly:   "true", // 只减仓，确保平仓
-			PositionSide: ts.config.Trading.PositionSide,
-			WorkingType:  ts.config.Trading.StopLoss.WorkingType,
-			PriceProtect: "TRUE",
-		}
-
-		logger.Infof("创建止损条件单（做空，统一账户）: %s, 开仓价格: %.8f, 止损价格: %s, 数量: %s, 止损百分比: %.2f%%, 策略类型: STOP_MARKET",
-			symbol, entryPrice, stopPriceStr, quantityStr, ts.config.Trading.StopLoss.Percent)
-
-		condResp, err := ts.client.CreateConditionalOrder(condReq)
-		if err != nil {
-			return nil, err
-		}
-
-		// 转换为OrderResponse以保持兼容性
-		return convertConditionalOrderToOrderResponse(condResp), nil
-	} else {
-		// fapi接口使用普通订单接口
-		req := &models.OrderRequest{
-			Symbol:        symbol,
-			Side:          "BUY", // 止损是买入平仓（做空的反向操作）
-			StopPrice:     stopPriceStr,
-			ClosePosition: "true", // 平仓，自动平掉整个持仓（不需要quantity）
-			PositionSide:  ts.config.Trading.PositionSide,
-			WorkingType:   ts.config.Trading.StopLoss.WorkingType,
-			PriceProtect:  "true",
-			Type:          "STOP_MARKET",
-		}
-
-		logger.Infof("创建止损订单（做空，fapi）: %s, 开仓价格: %.8f, 止损价格: %s, 止损百分比: %.2f%%, 订单类型: STOP_MARKET",
-			symbol, entryPrice, stopPriceStr, ts.config.Trading.StopLoss.Percent)
-		return ts.client.CreateOrder(req)
+	priceStr, err := binance.ValidateAndAdjustPrice(price, symbolInfo)
+	if err != nil {
+		return "", err
 	}
+	ts.warnPercentPrice(ex, symbolInfo, priceStr)
+	return priceStr, nil
 }
 
-// CreateTakeProfitOrder 创建止盈订单（做空时，价格下跌触发止盈）
-// quantity: 平仓数量（仅papi需要，可选参数）
-func (ts *TradingService) CreateTakeProfitOrder(symbol string, entryPrice float64, quantity ...string) (*models.OrderResponse, error) {
-	if !ts.config.Trading.TakeProfit.Enabled {
-		return nil, fmt.Errorf("止盈功能未启用")
+// warnPercentPrice 交易对设置了PERCENT_PRICE时，按标记价格（不支持查询时用最新价格）检查触发价格是否在范围内
+// PERCENT_PRICE限制的是委托价格，不限制市价止盈止损单的触发价格，超出时只记录警告，仍然提交
+func (ts *TradingService) warnPercentPrice(ex exchange.Exchange, symbolInfo *models.Symbol, priceStr string) {
+	if binance.GetFilter(symbolInfo, models.FilterTypePercentPrice) == nil {
+		return
 	}
-
-	// 计算止盈价格（做空时，止盈价低于开仓价）
-	takeProfitPercent := ts.config.Trading.TakeProfit.Percent / 100.0
-	takeProfitPrice := entryPrice * (1 - takeProfitPercent) // 做空时价格下跌触发止盈
-
-	// 获取交易所信息，用于获取交易对精度规则
-	exchangeInfo, err := ts.client.GetExchangeInfo()
+	markPrice, err := markPrice(ex, symbolInfo.Symbol)
 	if err != nil {
-		return nil, fmt.Errorf("获取交易所信息失败: %w", err)
+		logger.Warnf("获取 %s 标记价格失败，跳过PERCENT_PRICE检查: %v", symbolInfo.Symbol, err)
+		return
 	}
-
-	// 获取交易对信息
-	symbolInfo, err := binance.GetSymbolInfo(exchangeInfo, symbol)
+	price, err := strconv.ParseFloat(priceStr, 64)
 	if err != nil {
-		return nil, fmt.Errorf("获取交易对信息失败: %w", err)
+		return
+	}
+	if err := binance.CheckPercentPrice(price, markPrice, symbolInfo); err != nil {
+		logger.Warnf("%s 止盈止损触发%v，市价触发单不受该过滤器限制，继续提交", symbolInfo.Symbol, err)
 	}
+}
 
-	// 调整stopPrice精度
-	stopPriceStr, err := binance.ValidateAndAdjustPrice(takeProfitPrice, symbolInfo)
+// markPrice 获取标记价格，交易所不支持查询标记价格时使用最新价格
+func markPrice(ex exchange.Exchange, symbol string) (float64, error) {
+	if provider, ok := ex.(exchange.FundingRateProvider); ok {
+		if index, err := provider.GetPremiumIndex(symbol); err == nil {
+			if price, err := strconv.ParseFloat(index.MarkPrice, 64); err == nil && price > 0 {
+				return price, nil
+			}
+		}
+	}
+	ticker, err := ex.GetTickerPrice(symbol)
 	if err != nil {
-		return nil, fmt.Errorf("调整止盈价格精度失败: %w", err)
+		return 0, err
 	}
+	return strconv.ParseFloat(ticker.Price, 64)
+}
 
-	// 检查API类型，统一账户接口使用条件单接口
-	apiType := ts.config.Binance.APIType
-	if apiType == "" {
-		apiType = "fapi"
-	}
+// CreateOrdersWithStopLossAndTakeProfit 按策略规则开仓并同时设置止损和止盈（按USDT金额）
+func (ts *TradingService) CreateOrdersWithStopLossAndTakeProfit(symbol string, notionalUSDT string) (*OrderSet, error) {
+	return ts.createOrderSet(ts.exchange, "", ts.ResolveStrategy(symbol, 0), notionalUSDT, nil)
+}
 
-	// 统一账户接口使用条件单接口
-	if apiType == "papi" {
-		// 统一账户条件单接口需要quantity参数
-		quantityStr := ""
-		if len(quantity) > 0 && quantity[0] != "" {
-			quantityStr = quantity[0]
-		} else {
-			return nil, fmt.Errorf("统一账户条件单需要quantity参数，请提供平仓数量")
-		}
+// CreateOrdersOnExchange 在指定交易所按策略开仓并设置止损和止盈，exchangeName留空使用主交易所
+// notionalUSDT: 留空使用策略中的金额
+func (ts *TradingService) CreateOrdersOnExchange(exchangeName string, strategy *Strategy, notionalUSDT string) (*OrderSet, error) {
+	ex, err := ts.GetExchange(exchangeName)
+	if err != nil {
+		return nil, err
+	}
+	return ts.createOrderSet(ex, "", strategy, notionalUSDT, nil)
+}
 
-		// 使用条件单接口，TAKE_PROFIT_MARKET类型需要stopPrice和quantity
-		condReq := &models.ConditionalOrderRequest{
-			Symbol:       symbol,
-			Side:         "BUY", // 止盈是买入平仓（做空的反向操作）
-			StrategyType: "TAKE_PROFIT_MARKET",
-			StopPrice:    stopPriceStr,
-			Quantity:     quantityStr,
-			ReduceOnly:   "true", // 只减仓，确保平仓
-			PositionSide: ts.config.Trading.PositionSide,
-			WorkingType:  ts.config.Trading.TakeProfit.WorkingType,
-			PriceProtect: "TRUE",
-		}
+// createOrderSet 在指定交易所执行开仓+止损+止盈流程
+// account: 下单的币安账户名称（主交易所和其他交易所为空）
+// precheck: 多账户下单前已按合计金额完成的检查，为nil时在下单前自行检查
+func (ts *TradingService) createOrderSet(ex exchange.Exchange, account string, strategy *Strategy, notionalUSDT string, precheck *entryPrecheck) (*OrderSet, error) {
+	if notionalUSDT == "" {
+		notionalUSDT = strategy.Notional
+	}
+	symbol := strategy.Symbol
 
-		logger.Infof("创建止盈条件单（做空，统一账户）: %s, 开仓价格: %.8f, 止盈价格: %s, 数量: %s, 止盈百分比: %.2f%%, 策略类型: TAKE_PROFIT_MARKET",
-			symbol, entryPrice, stopPriceStr, quantityStr, ts.config.Trading.TakeProfit.Percent)
+	orderSet := &OrderSet{
+		Symbol:    symbol,
+		Exchange:  ex.Name(),
+		Account:   account,
+		Direction: strategy.Direction,
+		Rule:      strategy.Rule,
+	}
 
-		condResp, err := ts.client.CreateConditionalOrder(condReq)
-		if err != nil {
-			return nil, err
-		}
+	// 开仓前估算预计持仓期间的资金费，超过上限时跳过开仓
+	fundingCheck, err := ts.checkFunding(ex, strategy)
+	orderSet.Funding = fundingCheck
+	if err != nil {
+		ts.publishRiskLimit(orderSet, err, fundingCheck)
+		return nil, err
+	}
 
-		// 转换为OrderResponse以保持兼容性
-		return convertConditionalOrderToOrderResponse(condResp), nil
+	// 开仓前检查盘口深度和价差，深度不足时按配置跳过开仓或减少开仓金额
+	var check *LiquidityCheck
+	if precheck != nil {
+		check, err = precheck.Liquidity, precheck.LiquidityErr
 	} else {
-		// fapi接口使用普通订单接口
-		req := &models.OrderRequest{
-			Symbol:        symbol,
-			Side:          "BUY", // 止盈是买入平仓（做空的反向操作）
-			StopPrice:     stopPriceStr,
-			ClosePosition: "true", // 平仓，自动平掉整个持仓（不需要quantity）
-			PositionSide:  ts.config.Trading.PositionSide,
-			WorkingType:   ts.config.Trading.TakeProfit.WorkingType,
-			PriceProtect:  "true",
-			Type:          "TAKE_PROFIT_MARKET",
+		check, err = ts.checkLiquidity(ex, strategy, notionalUSDT)
+	}
+	orderSet.Liquidity = check
+	if err != nil {
+		var skipErr *LiquiditySkipError
+		if errors.As(err, &skipErr) {
+			ts.publishRiskLimit(orderSet, err, check)
+		} else {
+			ts.publishError(orderSet, err)
 		}
-
-		logger.Infof("创建止盈订单（做空，fapi）: %s, 开仓价格: %.8f, 止盈价格: %s, 止盈百分比: %.2f%%, 订单类型: TAKE_PROFIT_MARKET",
-			symbol, entryPrice, stopPriceStr, ts.config.Trading.TakeProfit.Percent)
-		return ts.client.CreateOrder(req)
+		return nil, err
 	}
-}
-
-// CreateOrdersWithStopLossAndTakeProfit 创建卖单（做空）并同时设置止损和止盈（按USDT金额）
-func (ts *TradingService) CreateOrdersWithStopLossAndTakeProfit(symbol string, notionalUSDT string) (*OrderSet, error) {
-	orderSet := &OrderSet{
-		Symbol: symbol,
+	if check != nil && check.Decision == LiquidityDownsize {
+		notionalUSDT = strconv.FormatFloat(check.Notional, 'f', 2, 64)
 	}
 
-	// 创建卖单（做空，按USDT金额）
-	sellOrder, err := ts.CreateMarketSellOrder(symbol, notionalUSDT)
+	// 按配置的开仓方式创建开仓单（按USDT金额）
+	sellOrder, reference, err := ts.createEntryOrder(ex, strategy, notionalUSDT)
+	metrics.Orders.Inc(ex.Name(), metrics.OrderTypeEntry, metrics.OrderResult(err))
 	if err != nil {
-		return nil, fmt.Errorf("创建卖单失败: %w", err)
+		err = fmt.Errorf("创建开仓单失败: %w", err)
+		ts.publishError(orderSet, err)
+		return nil, err
 	}
 	orderSet.SellOrder = sellOrder
+	ts.journal.Record(orderSet, strategy.OnboardDate)
+	ts.publishOrder(events.TypeOrderPlaced, orderSet, sellOrder, "开仓单")
+	if sellOrder.Status == "FILLED" {
+		ts.publishOrder(events.TypeOrderFilled, orderSet, sellOrder, "开仓单")
+	}
+	if strategy.OnboardDate > 0 {
+		if sinceOnboard := time.Since(time.UnixMilli(strategy.OnboardDate)); sinceOnboard >= 0 {
+			metrics.OnboardToFill.Observe(sinceOnboard.Seconds())
+		}
+	}
 
 	// 获取实际成交价格作为开仓价格
 	entryPrice := 0.0
@@ -340,7 +401,7 @@ func (ts *TradingService) CreateOrdersWithStopLossAndTakeProfit(symbol string, n
 
 	// 如果无法从订单响应获取价格，尝试获取当前价格
 	if entryPrice == 0 {
-		tickerPrice, err := ts.client.GetTickerPrice(symbol)
+		tickerPrice, err := ex.GetTickerPrice(symbol)
 		if err != nil {
 			logger.Warnf("获取当前价格失败: %v，将使用订单响应中的价格", err)
 		} else if tickerPrice.Price != "" {
@@ -350,11 +411,23 @@ func (ts *TradingService) CreateOrdersWithStopLossAndTakeProfit(symbol string, n
 		}
 	}
 
+	// 已成交但无法获取开仓价格时无法计算止盈止损价，未启用止损时保留仓位，否则按 on_failure 处理仓位
 	if entryPrice == 0 {
-		return nil, fmt.Errorf("无法获取开仓价格")
+		err := fmt.Errorf("无法获取开仓价格")
+		logger.Errorf("%s %v，无法创建止盈止损订单", symbol, err)
+		if !strategy.StopLossEnabled {
+			orderSet.Status = OrderSetNoStopLoss
+			if strategy.TakeProfitEnabled {
+				orderSet.TakeProfitError = err
+				ts.publishBracketFailed(orderSet, fmt.Errorf("创建止盈订单失败: %w", err))
+			}
+			return orderSet, nil
+		}
+		ts.handleStopLossFailure(ex, strategy, orderSet, 0, "", err)
+		return orderSet, nil
 	}
 
-	// 获取成交数量（用于统一账户条件单）
+	// 获取成交数量（用于不支持closePosition的条件单接口）
 	executedQty := ""
 	qtyFloat := 0.0
 
@@ -388,151 +461,139 @@ func (ts *TradingService) CreateOrdersWithStopLossAndTakeProfit(symbol string, n
 		}
 	}
 
-	// 如果使用统一账户接口，需要验证并调整quantity精度
-	apiType := ts.config.Binance.APIType
-	if apiType == "" {
-		apiType = "fapi"
-	}
-
-	if apiType == "papi" {
-		if qtyFloat <= 0 {
-			return nil, fmt.Errorf("无法获取有效的成交数量，ExecutedQty=%s, OrigQty=%s, CumQuote=%s",
-				sellOrder.ExecutedQty, sellOrder.OrigQty, sellOrder.CumQuote)
-		}
-
-		// 确保数量为正数（使用绝对值）
-		if qtyFloat < 0 {
-			qtyFloat = -qtyFloat
-		}
-
-		// 获取交易所信息，用于获取交易对精度规则
-		exchangeInfo, err := ts.client.GetExchangeInfo()
-		if err != nil {
-			return nil, fmt.Errorf("获取交易所信息失败: %w", err)
-		}
-
-		// 获取交易对信息
-		symbolInfo, err := binance.GetSymbolInfo(exchangeInfo, symbol)
+	// 验证并调整quantity精度；支持closePosition的接口（fapi）不依赖数量，失败时只记录警告
+	if qtyFloat > 0 {
+		symbolInfo, err := ts.getSymbolInfo(ex, symbol)
 		if err != nil {
-			return nil, fmt.Errorf("获取交易对信息失败: %w", err)
-		}
-
-		// 调整quantity精度
-		adjustedQty, err := binance.ValidateAndAdjustQuantity(qtyFloat, symbolInfo)
-		if err != nil {
-			return nil, fmt.Errorf("调整数量精度失败: %w (原始数量: %.8f)", err, qtyFloat)
+			logger.Warnf("获取交易对精度规则失败，止盈止损将不携带数量: %v", err)
+		} else if adjustedQty, err := binance.ValidateAndAdjustOrderQuantity(qtyFloat, "STOP_MARKET", symbolInfo); err != nil {
+			logger.Warnf("调整数量精度失败: %v (原始数量: %.8f)", err, qtyFloat)
+		} else {
+			executedQty = adjustedQty
+			logger.Infof("调整数量精度: %.8f -> %s", qtyFloat, executedQty)
 		}
+	} else {
+		logger.Warnf("无法获取有效的成交数量，ExecutedQty=%s, OrigQty=%s, CumQuote=%s",
+			sellOrder.ExecutedQty, sellOrder.OrigQty, sellOrder.CumQuote)
+	}
 
-		executedQty = adjustedQty
-		logger.Infof("调整数量精度: %.8f -> %s", qtyFloat, executedQty)
+	// 成交均价相对下单前参考价格的滑点超过上限时按配置平仓
+	if !ts.checkSlippage(ex, strategy, orderSet, reference, entryPrice, executedQty) {
+		return orderSet, nil
 	}
 
-	// 创建止损订单
-	if ts.config.Trading.StopLoss.Enabled {
-		var stopLossOrder *models.OrderResponse
-		var err error
-		if apiType == "papi" && executedQty != "" {
-			stopLossOrder, err = ts.CreateStopLossOrder(symbol, entryPrice, executedQty)
-		} else {
-			stopLossOrder, err = ts.CreateStopLossOrder(symbol, entryPrice)
-		}
-		if err != nil {
-			logger.Errorf("创建止损订单失败: %v", err)
-			orderSet.StopLossError = err
-		} else {
-			orderSet.StopLossOrder = stopLossOrder
-		}
+	// 创建止损订单，失败时重试，仍然失败时按配置市价平仓
+	if !ts.protectEntry(ex, strategy, orderSet, entryPrice, qtyFloat, executedQty) {
+		return orderSet, nil
 	}
 
-	// 创建止盈订单
-	if ts.config.Trading.TakeProfit.Enabled {
-		var takeProfitOrder *models.OrderResponse
-		var err error
-		if apiType == "papi" && executedQty != "" {
-			takeProfitOrder, err = ts.CreateTakeProfitOrder(symbol, entryPrice, executedQty)
-		} else {
-			takeProfitOrder, err = ts.CreateTakeProfitOrder(symbol, entryPrice)
-		}
-		if err != nil {
+	// 创建止盈订单（配置了分档止盈时每档一个只减仓的止盈单）
+	if strategy.TakeProfitEnabled {
+		if err := ts.createTakeProfitOrders(ex, strategy, orderSet, entryPrice, qtyFloat, executedQty); err != nil {
 			logger.Errorf("创建止盈订单失败: %v", err)
 			orderSet.TakeProfitError = err
-		} else {
-			orderSet.TakeProfitOrder = takeProfitOrder
+			ts.publishBracketFailed(orderSet, fmt.Errorf("创建止盈订单失败: %w", err))
 		}
 	}
 
+	ts.brackets.Track(ex, orderSet)
 	return orderSet, nil
 }
 
-// QueryOrder 查询订单
+// SetEventBus 设置事件总线，下单、成交、止盈止损触发和错误时发布事件
+func (ts *TradingService) SetEventBus(bus *events.Bus) {
+	ts.bus = bus
+	ts.brackets.bus = bus
+}
+
+// StartBracketMonitor 启动止盈止损单状态轮询，直到ctx取消
+func (ts *TradingService) StartBracketMonitor(ctx context.Context, interval time.Duration) {
+	go ts.brackets.Run(ctx, interval)
+}
+
+// publishOrder 发布订单事件
+func (ts *TradingService) publishOrder(eventType string, orderSet *OrderSet, order *models.OrderResponse, label string) {
+	message := fmt.Sprintf("%s %s已创建，类型: %s，方向: %s", orderSet.Symbol, label, order.Type, order.Side)
+	if eventType == events.TypeOrderFilled {
+		message = fmt.Sprintf("%s %s已成交，数量: %s，均价: %s", orderSet.Symbol, label, order.ExecutedQty, order.AvgPrice)
+	}
+	ts.bus.Publish(events.Event{
+		Type:     eventType,
+		Symbol:   orderSet.Symbol,
+		Exchange: orderSet.Exchange,
+		Account:  orderSet.Account,
+		Message:  message,
+		Data:     order,
+	})
+}
+
+// publishError 发布下单错误事件
+func (ts *TradingService) publishError(orderSet *OrderSet, err error) {
+	ts.bus.Publish(events.Event{
+		Type:     events.TypeError,
+		Symbol:   orderSet.Symbol,
+		Exchange: orderSet.Exchange,
+		Account:  orderSet.Account,
+		Message:  err.Error(),
+	})
+}
+
+// lockSymbol 锁定某个账户的某个币对，返回解锁函数
+// 移动止损撤销重建止损单的间隙，仓位保护检查可能看到缺少止损单并补建，导致同时存在两个止损单
+func (ts *TradingService) lockSymbol(account, symbol string) func() {
+	value, _ := ts.symbolLocks.LoadOrStore(account+"/"+symbol, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// publishRiskLimit 发布触发风控限制跳过开仓的事件，data为检查结果
+func (ts *TradingService) publishRiskLimit(orderSet *OrderSet, err error, data interface{}) {
+	ts.bus.Publish(events.Event{
+		Type:     events.TypeRiskLimitBreached,
+		Symbol:   orderSet.Symbol,
+		Exchange: orderSet.Exchange,
+		Account:  orderSet.Account,
+		Message:  err.Error(),
+		Data:     data,
+	})
+}
+
+// publishBracketFailed 发布止损/止盈单创建失败事件
+func (ts *TradingService) publishBracketFailed(orderSet *OrderSet, err error) {
+	ts.bus.Publish(events.Event{
+		Type:     events.TypeBracketFailed,
+		Symbol:   orderSet.Symbol,
+		Exchange: orderSet.Exchange,
+		Account:  orderSet.Account,
+		Message:  err.Error(),
+	})
+}
+
+// QueryOrder 查询订单（主交易所）
 func (ts *TradingService) QueryOrder(symbol string, orderID int64) (*models.OrderResponse, error) {
-	params := &models.OrderQueryParams{
-		Symbol:  symbol,
-		OrderID: orderID,
+	querier, ok := ts.exchange.(exchange.OrderQuerier)
+	if !ok {
+		return nil, fmt.Errorf("交易所 %s 不支持查询订单", ts.exchange.Name())
 	}
-	return ts.client.QueryOrder(params)
+	return querier.QueryOrder(symbol, orderID)
 }
 
 // GetNegativePositions 查询收益为负的仓位并排序
-func (ts *TradingService) GetNegativePositions() (*models.NegativePositionResponse, error) {
-	// 查询所有持仓（symbol为空表示查询所有）
-	positionRisks, err := ts.client.GetPositionRisk("")
+// account: 账户名称，留空查询所有账户
+func (ts *TradingService) GetNegativePositions(account string) (*models.NegativePositionResponse, error) {
+	accounts, err := ts.selectAccounts(account)
 	if err != nil {
-		return nil, fmt.Errorf("查询持仓失败: %w", err)
+		return nil, err
 	}
 
-	// 转换为Position模型并筛选负收益仓位
 	negativePositions := make([]models.Position, 0)
-	for _, pr := range positionRisks {
-		// 解析持仓数量
-		positionAmt, err := strconv.ParseFloat(pr.PositionAmt, 64)
-		if err != nil || positionAmt == 0 {
-			// 跳过持仓数量为0的仓位
-			continue
-		}
-
-		// 解析未实现盈亏
-		unRealizedProfit, err := strconv.ParseFloat(pr.UnRealizedProfit, 64)
+	for _, acc := range accounts {
+		positions, err := ts.getNegativePositions(acc)
 		if err != nil {
-			logger.Warnf("解析未实现盈亏失败: %s, symbol: %s", pr.UnRealizedProfit, pr.Symbol)
-			continue
-		}
-
-		// 只保留负收益的仓位
-		if unRealizedProfit >= 0 {
-			continue
-		}
-
-		// 解析其他字段
-		entryPrice, _ := strconv.ParseFloat(pr.EntryPrice, 64)
-		markPrice, _ := strconv.ParseFloat(pr.MarkPrice, 64)
-		liquidationPrice, _ := strconv.ParseFloat(pr.LiquidationPrice, 64)
-		leverage, _ := strconv.ParseFloat(pr.Leverage, 64)
-		notional, _ := strconv.ParseFloat(pr.Notional, 64)
-
-		// 计算收益率百分比
-		profitPercent := 0.0
-		if entryPrice > 0 && notional > 0 {
-			// 收益率 = 未实现盈亏 / 持仓名义价值 * 100
-			profitPercent = (unRealizedProfit / notional) * 100
+			return nil, fmt.Errorf("账户 %s: %w", acc.Name, err)
 		}
-
-		position := models.Position{
-			Symbol:           pr.Symbol,
-			PositionAmt:      positionAmt,
-			EntryPrice:       entryPrice,
-			MarkPrice:        markPrice,
-			UnRealizedProfit: unRealizedProfit,
-			LiquidationPrice: liquidationPrice,
-			Leverage:         leverage,
-			MarginType:       pr.MarginType,
-			PositionSide:     pr.PositionSide,
-			Notional:         notional,
-			UpdateTime:       pr.UpdateTime,
-			ProfitPercent:    profitPercent,
-		}
-
-		negativePositions = append(negativePositions, position)
+		negativePositions = append(negativePositions, positions...)
 	}
 
 	// 按亏损金额从大到小排序（未实现盈亏越小，亏损越大）
@@ -548,12 +609,69 @@ func (ts *TradingService) GetNegativePositions() (*models.NegativePositionRespon
 	}, nil
 }
 
-// OrderSet 订单集合（卖单+止损+止盈）
+// RefreshPositionMetrics 查询所有账户的持仓，更新持仓名义价值和未实现盈亏指标
+// 查询失败的账户保留上一次的值
+func (ts *TradingService) RefreshPositionMetrics() {
+	for _, acc := range ts.accounts {
+		positionRisks, err := acc.Exchange.GetPositionRisk("")
+		if err != nil {
+			logger.Warnf("更新持仓指标失败，账户 %s: %v", acc.Name, err)
+			continue
+		}
+
+		metrics.PositionNotional.DeleteMatching(acc.Name)
+		metrics.PositionUnrealizedPnL.DeleteMatching(acc.Name)
+		for _, pr := range positionRisks {
+			positionAmt, err := strconv.ParseFloat(pr.PositionAmt, 64)
+			if err != nil || positionAmt == 0 {
+				continue
+			}
+			notional, _ := strconv.ParseFloat(pr.Notional, 64)
+			unRealizedProfit, _ := strconv.ParseFloat(pr.UnRealizedProfit, 64)
+			metrics.PositionNotional.Set(notional, acc.Name, pr.Symbol, pr.PositionSide)
+			metrics.PositionUnrealizedPnL.Set(unRealizedProfit, acc.Name, pr.Symbol, pr.PositionSide)
+		}
+	}
+}
+
+// getNegativePositions 查询单个账户收益为负的仓位
+func (ts *TradingService) getNegativePositions(acc *Account) ([]models.Position, error) {
+	positions, err := ts.getPositions(acc)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只保留负收益的仓位
+	negativePositions := make([]models.Position, 0)
+	for _, position := range positions {
+		if position.UnRealizedProfit < 0 {
+			negativePositions = append(negativePositions, position)
+		}
+	}
+	return negativePositions, nil
+}
+
+// OrderSet 订单集合（开仓单+止损+止盈）
 type OrderSet struct {
-	Symbol          string
-	SellOrder       *models.OrderResponse // 做空卖单
-	StopLossOrder   *models.OrderResponse
-	TakeProfitOrder *models.OrderResponse
-	StopLossError   error
-	TakeProfitError error
+	Symbol           string
+	Exchange         string                // 下单的交易所
+	Account          string                // 下单的币安账户（其他交易所为空）
+	Direction        string                // 开仓方向 SHORT/LONG
+	Rule             string                // 生效的策略规则，空表示默认配置
+	SellOrder        *models.OrderResponse // 开仓单（做空为卖单，做多为买单）
+	StopLossOrder    *models.OrderResponse
+	TakeProfitOrder  *models.OrderResponse
+	TakeProfitOrders []*models.OrderResponse // 分档止盈单（按档从近到远）
+	StopLossError    error
+	TakeProfitError  error
+	Status           string                // 保护状态（protected/no_stop_loss/unprotected/unwound/unwind_failed/slippage_unwound）
+	UnwindOrder      *models.OrderResponse // 止损单创建失败后的市价平仓单
+	UnwindError      error                 // 市价平仓失败的错误
+	Liquidity        *LiquidityCheck       // 开仓前的流动性检查，未检查时为nil
+	Funding          *FundingCheck         // 开仓前的资金费估算，未检查时为nil
+}
+
+// Open 开仓后仓位是否仍然保留（未因止损单创建失败或滑点超限而平仓）
+func (o *OrderSet) Open() bool {
+	return o.Status != OrderSetUnwound && o.Status != OrderSetSlippageUnwound
 }
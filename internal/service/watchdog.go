@@ -0,0 +1,483 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"new_listing_trade/internal/api/binance"
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/exchange"
+	"new_listing_trade/internal/logger"
+	"new_listing_trade/internal/models"
+)
+
+// DefaultWatchdogInterval 默认的仓位保护检查间隔
+const DefaultWatchdogInterval = time.Minute
+
+// watchdogGracePeriod 持仓更新后多久内不检查（开仓流程正在创建止损/止盈单）
+const watchdogGracePeriod = 30 * time.Second
+
+// quantityTolerance 条件单数量与持仓数量的相对误差在此范围内视为一致
+const quantityTolerance = 1e-6
+
+// 仓位保护问题
+const (
+	ProtectionMissingStopLoss   = "missing_stop_loss"   // 缺少止损单
+	ProtectionMissingTakeProfit = "missing_take_profit" // 缺少止盈单
+	ProtectionQuantityDrift     = "quantity_drift"      // 条件单数量与持仓数量不一致
+)
+
+// protectionLabels 仓位保护问题的中文说明
+var protectionLabels = map[string]string{
+	ProtectionMissingStopLoss:   "缺少止损单",
+	ProtectionMissingTakeProfit: "缺少止盈单",
+	ProtectionQuantityDrift:     "条件单数量与持仓不一致",
+}
+
+// 止损类条件单（其余条件单视为止盈）
+var stopLossOrderTypes = map[string]bool{
+	"STOP":                 true,
+	"STOP_MARKET":          true,
+	"TRAILING_STOP_MARKET": true,
+}
+
+// PositionProtection 单个持仓的保护检查结果
+type PositionProtection struct {
+	Exchange         string   `json:"exchange"`
+	Account          string   `json:"account"`
+	Symbol           string   `json:"symbol"`
+	PositionSide     string   `json:"position_side"`
+	PositionAmt      float64  `json:"position_amt"`
+	EntryPrice       float64  `json:"entry_price"`
+	StopLossOrders   []int64  `json:"stop_loss_orders,omitempty"`   // 覆盖该持仓的止损单ID
+	TakeProfitOrders []int64  `json:"take_profit_orders,omitempty"` // 覆盖该持仓的止盈单ID
+	Issues           []string `json:"issues,omitempty"`             // 发现的问题
+	Repaired         []string `json:"repaired,omitempty"`           // 已修复的问题
+	Errors           []string `json:"errors,omitempty"`             // 修复失败的原因
+}
+
+// Protected 是否没有问题（或问题均已修复）
+func (p *PositionProtection) Protected() bool {
+	return len(p.Issues) == len(p.Repaired)
+}
+
+// summary 问题和修复结果的说明
+func (p *PositionProtection) summary() string {
+	parts := make([]string, 0, len(p.Issues)+len(p.Errors))
+	repaired := toStringSet(p.Repaired)
+	for _, issue := range p.Issues {
+		if repaired[issue] {
+			parts = append(parts, protectionLabels[issue]+"（已修复）")
+		} else {
+			parts = append(parts, protectionLabels[issue])
+		}
+	}
+	parts = append(parts, p.Errors...)
+	return strings.Join(parts, "；")
+}
+
+// ProtectionOptions 仓位保护检查选项
+type ProtectionOptions struct {
+	Account string // 账户，留空检查所有账户
+	Repair  bool   // 按实际开仓价和持仓数量补建缺少的止损/止盈单
+	Resize  bool   // 撤销数量与持仓不一致的条件单，按持仓数量在原触发价重建
+}
+
+// CheckProtection 对比持仓和挂单（包括条件单），找出缺少止损/止盈单或条件单数量与持仓不一致的仓位
+// 只返回有问题的持仓；某个账户查询失败时跳过该账户并返回错误
+func (ts *TradingService) CheckProtection(opts ProtectionOptions) ([]PositionProtection, error) {
+	accounts, err := ts.selectAccounts(opts.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PositionProtection, 0)
+	var errs []string
+	for _, acc := range accounts {
+		accountResults, err := ts.checkAccountProtection(acc, opts)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("账户 %s: %v", acc.Name, err))
+			continue
+		}
+		results = append(results, accountResults...)
+	}
+	sortProtections(results)
+	if len(errs) > 0 {
+		return results, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return results, nil
+}
+
+// checkAccountProtection 检查单个账户的持仓
+func (ts *TradingService) checkAccountProtection(acc *Account, opts ProtectionOptions) ([]PositionProtection, error) {
+	lister, ok := acc.Exchange.(exchange.OpenOrderLister)
+	if !ok {
+		return nil, fmt.Errorf("交易所 %s 不支持查询挂单", acc.Exchange.Name())
+	}
+	positionRisks, err := acc.Exchange.GetPositionRisk("")
+	if err != nil {
+		return nil, fmt.Errorf("查询持仓失败: %w", err)
+	}
+	openOrders, err := lister.GetOpenOrders("")
+	if err != nil {
+		return nil, fmt.Errorf("查询挂单失败: %w", err)
+	}
+
+	var results []PositionProtection
+	for _, pr := range positionRisks {
+		positionAmt, err := strconv.ParseFloat(pr.PositionAmt, 64)
+		if err != nil || positionAmt == 0 {
+			continue
+		}
+		if pr.UpdateTime > 0 && time.Since(time.UnixMilli(pr.UpdateTime)) < watchdogGracePeriod {
+			continue
+		}
+		result := ts.checkPosition(acc, pr, positionAmt, openOrders, opts)
+		if len(result.Issues) > 0 {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// checkPosition 检查单个持仓，按选项修复
+func (ts *TradingService) checkPosition(acc *Account, pr models.PositionRisk, positionAmt float64, openOrders []models.OrderResponse, opts ProtectionOptions) PositionProtection {
+	entryPrice, _ := strconv.ParseFloat(pr.EntryPrice, 64)
+	result := PositionProtection{
+		Exchange:     acc.Exchange.Name(),
+		Account:      acc.Name,
+		Symbol:       pr.Symbol,
+		PositionSide: pr.PositionSide,
+		PositionAmt:  positionAmt,
+		EntryPrice:   entryPrice,
+	}
+
+	// 按持仓方向确定平仓方向，止盈止损比例沿用币对匹配的策略
+	strategy := ts.ResolveStrategy(pr.Symbol, 0)
+	strategy.Direction = DirectionLong
+	if positionAmt < 0 {
+		strategy.Direction = DirectionShort
+	}
+	strategy.PositionSide = pr.PositionSide
+	if strategy.PositionSide == "" {
+		strategy.PositionSide = "BOTH"
+	}
+	quantity := math.Abs(positionAmt)
+
+	var drifted []models.OrderResponse
+	ladder := len(strategy.TakeProfitLadder) > 0
+	ladderQty, ladderOrders := 0.0, 0
+	for _, order := range openOrders {
+		if !coversPosition(&order, pr, strategy.CloseSide()) {
+			continue
+		}
+		if stopLossOrderTypes[order.Type] {
+			result.StopLossOrders = append(result.StopLossOrders, order.OrderID)
+		} else {
+			result.TakeProfitOrders = append(result.TakeProfitOrders, order.OrderID)
+		}
+		// closePosition单始终平掉整个持仓，不检查数量
+		if order.ClosePosition {
+			continue
+		}
+		origQty, err := strconv.ParseFloat(order.OrigQty, 64)
+		if err != nil {
+			continue
+		}
+		// 分档止盈单各自只平一部分，按数量之和检查
+		if ladder && !stopLossOrderTypes[order.Type] {
+			ladderQty += origQty
+			ladderOrders++
+			continue
+		}
+		if math.Abs(origQty-quantity) > quantity*quantityTolerance {
+			drifted = append(drifted, order)
+		}
+	}
+	ladderDrift := ladderOrders > 0 && math.Abs(ladderQty-quantity) > quantity*quantityTolerance
+
+	var missing []string
+	if strategy.StopLossEnabled && len(result.StopLossOrders) == 0 {
+		missing = append(missing, ProtectionMissingStopLoss)
+	}
+	if strategy.TakeProfitEnabled && len(result.TakeProfitOrders) == 0 {
+		missing = append(missing, ProtectionMissingTakeProfit)
+	}
+	result.Issues = append(result.Issues, missing...)
+	if len(drifted) > 0 || ladderDrift {
+		result.Issues = append(result.Issues, ProtectionQuantityDrift)
+	}
+	if len(result.Issues) == 0 || (!opts.Repair && !opts.Resize) {
+		return result
+	}
+
+	quantityStr, err := ts.adjustQuantity(acc.Exchange, pr.Symbol, quantity)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	orderSet := &OrderSet{Symbol: pr.Symbol, Exchange: acc.Exchange.Name(), Account: acc.Name, Direction: strategy.Direction, Rule: strategy.Rule}
+
+	// 与移动止损互斥，加锁后重新确认止损单确实缺少（挂单快照可能是在移动止损撤销重建的间隙查询的）
+	unlock := ts.lockSymbol(acc.Name, pr.Symbol)
+	defer unlock()
+	if opts.Repair && len(missing) > 0 && missing[0] == ProtectionMissingStopLoss && ts.hasStopLoss(acc, pr, strategy.CloseSide()) {
+		logger.Infof("账户 %s 的 %s 已有止损单，不再补建", acc.Name, pr.Symbol)
+		result.Repaired = append(result.Repaired, ProtectionMissingStopLoss)
+		missing = missing[1:]
+	}
+
+	if opts.Repair && entryPrice > 0 {
+		for _, issue := range missing {
+			if issue == ProtectionMissingTakeProfit && ladder {
+				ts.repairTakeProfitLadder(acc, strategy, orderSet, entryPrice, quantity, &result)
+				continue
+			}
+			var order *models.OrderResponse
+			label := "止损单"
+			if issue == ProtectionMissingStopLoss {
+				order, err = ts.createStopLossOrder(acc.Exchange, strategy, entryPrice, quantityStr)
+			} else {
+				label = "止盈单"
+				order, err = ts.createTakeProfitOrder(acc.Exchange, strategy, entryPrice, quantityStr)
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("补建%s失败: %v", label, err))
+				continue
+			}
+			logger.Infof("账户 %s 已补建 %s 的%s: 触发价格 %s，数量 %s", acc.Name, pr.Symbol, label, order.StopPrice, quantityStr)
+			result.Repaired = append(result.Repaired, issue)
+			ts.publishOrder(events.TypeOrderPlaced, orderSet, order, label)
+		}
+	}
+
+	if opts.Resize && (len(drifted) > 0 || ladderDrift) {
+		resized := true
+		for i := range drifted {
+			if err := ts.resizeTriggerOrder(acc, &drifted[i], quantityStr); err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				resized = false
+			}
+		}
+		if ladderDrift {
+			// 无法确定哪些档已成交，不自动调整
+			result.Errors = append(result.Errors, "止盈阶梯数量与持仓不一致，需要手动处理")
+			resized = false
+		}
+		if resized {
+			result.Repaired = append(result.Repaired, ProtectionQuantityDrift)
+		}
+	}
+	return result
+}
+
+// hasStopLoss 重新查询该币对的挂单，持仓当前是否有止损单
+func (ts *TradingService) hasStopLoss(acc *Account, pr models.PositionRisk, closeSide string) bool {
+	lister, ok := acc.Exchange.(exchange.OpenOrderLister)
+	if !ok {
+		return false
+	}
+	openOrders, err := lister.GetOpenOrders(pr.Symbol)
+	if err != nil {
+		logger.Warnf("重新查询 %s 挂单失败: %v", pr.Symbol, err)
+		return false
+	}
+	for i := range openOrders {
+		if stopLossOrderTypes[openOrders[i].Type] && coversPosition(&openOrders[i], pr, closeSide) {
+			return true
+		}
+	}
+	return false
+}
+
+// repairTakeProfitLadder 按分档止盈配置补建止盈单
+func (ts *TradingService) repairTakeProfitLadder(acc *Account, strategy *Strategy, orderSet *OrderSet, entryPrice, quantity float64, result *PositionProtection) {
+	orders, err := ts.createTakeProfitLadder(acc.Exchange, strategy, entryPrice, quantity)
+	for i, order := range orders {
+		ts.publishOrder(events.TypeOrderPlaced, orderSet, order, fmt.Sprintf("第%d档止盈单", i+1))
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("补建分档止盈单失败: %v", err))
+	}
+	if len(orders) > 0 {
+		logger.Infof("账户 %s 已补建 %s 的 %d 档止盈单", acc.Name, orderSet.Symbol, len(orders))
+		result.Repaired = append(result.Repaired, ProtectionMissingTakeProfit)
+	}
+}
+
+// coversPosition 条件单是否用于平掉该持仓（同币对、平仓方向、持仓方向一致）
+func coversPosition(order *models.OrderResponse, pr models.PositionRisk, closeSide string) bool {
+	if order.Symbol != pr.Symbol || !exchange.IsStopOrderType(order.Type) || order.Side != closeSide {
+		return false
+	}
+	return order.PositionSide == "" || pr.PositionSide == "" || order.PositionSide == pr.PositionSide
+}
+
+// resizeTriggerOrder 撤销条件单并在原触发价按新数量重建（支持时使用closePosition）
+func (ts *TradingService) resizeTriggerOrder(acc *Account, order *models.OrderResponse, quantity string) error {
+	newOrder, err := ts.replaceTriggerOrder(acc, order, order.StopPrice, quantity)
+	if err != nil {
+		return err
+	}
+	logger.Infof("账户 %s 已按持仓数量重建 %s 的条件单: #%d -> #%d，数量 %s -> %s", acc.Name, order.Symbol, order.OrderID, newOrder.OrderID, order.OrigQty, quantity)
+	return nil
+}
+
+// replaceTriggerOrder 撤销条件单并按新的触发价和数量重建（支持时使用closePosition）
+// 触发价变化且重建失败时尝试按原触发价恢复，避免仓位失去保护
+func (ts *TradingService) replaceTriggerOrder(acc *Account, order *models.OrderResponse, stopPrice, quantity string) (*models.OrderResponse, error) {
+	if err := acc.Exchange.CancelOrder(order.Symbol, order); err != nil {
+		return nil, fmt.Errorf("撤销条件单 #%d 失败: %w", order.OrderID, err)
+	}
+
+	req := &exchange.TriggerOrderRequest{
+		Symbol:        order.Symbol,
+		Side:          order.Side,
+		PositionSide:  order.PositionSide,
+		StopPrice:     stopPrice,
+		Quantity:      quantity,
+		ClosePosition: true,
+		WorkingType:   order.WorkingType,
+	}
+	label := "止盈单"
+	create := acc.Exchange.CreateTakeProfitOrder
+	if stopLossOrderTypes[order.Type] {
+		label = "止损单"
+		create = acc.Exchange.CreateStopOrder
+	}
+	newOrder, err := create(req)
+	if err != nil {
+		err = fmt.Errorf("重建%s失败（原条件单 #%d 已撤销）: %w", label, order.OrderID, err)
+		if stopPrice != order.StopPrice {
+			req.StopPrice = order.StopPrice
+			if restored, restoreErr := create(req); restoreErr == nil {
+				logger.Warnf("%v，已按原触发价 %s 恢复为 #%d", err, order.StopPrice, restored.OrderID)
+				ts.publishOrder(events.TypeOrderPlaced, &OrderSet{Symbol: order.Symbol, Exchange: acc.Exchange.Name(), Account: acc.Name}, restored, label)
+			}
+		}
+		return nil, err
+	}
+	ts.publishOrder(events.TypeOrderPlaced, &OrderSet{Symbol: order.Symbol, Exchange: acc.Exchange.Name(), Account: acc.Name}, newOrder, label)
+	return newOrder, nil
+}
+
+// adjustQuantity 按市价单和止盈止损条件单的数量规则（MARKET_LOT_SIZE）调整数量精度
+func (ts *TradingService) adjustQuantity(ex exchange.Exchange, symbol string, quantity float64) (string, error) {
+	symbolInfo, err := ts.getSymbolInfo(ex, symbol)
+	if err != nil {
+		return "", err
+	}
+	return binance.ValidateAndAdjustOrderQuantity(quantity, "MARKET", symbolInfo)
+}
+
+// PositionWatchdog 定期检查持仓的止损/止盈单，缺少时补建，并在状态变化时发布 bracket_drift 事件
+type PositionWatchdog struct {
+	ts       *TradingService
+	cfg      config.WatchdogConfig
+	mu       sync.Mutex
+	reported map[string]string // 已报告的问题，key为 账户/币对/持仓方向，value为问题说明
+	last     []PositionProtection
+}
+
+// NewPositionWatchdog 创建仓位保护检查
+func NewPositionWatchdog(ts *TradingService, cfg config.WatchdogConfig) *PositionWatchdog {
+	return &PositionWatchdog{ts: ts, cfg: cfg, reported: make(map[string]string)}
+}
+
+// Run 定时检查，直到ctx取消
+func (w *PositionWatchdog) Run(ctx context.Context) {
+	interval := w.cfg.Interval
+	if interval <= 0 {
+		interval = DefaultWatchdogInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check 检查一次所有账户的持仓
+func (w *PositionWatchdog) Check() []PositionProtection {
+	results, err := w.ts.CheckProtection(ProtectionOptions{Repair: !w.cfg.DryRun, Resize: w.cfg.Resize && !w.cfg.DryRun})
+	if err != nil {
+		logger.Warnf("仓位保护检查失败: %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last = results
+
+	seen := make(map[string]bool, len(results))
+	for i := range results {
+		result := &results[i]
+		key := fmt.Sprintf("%s/%s/%s", result.Account, result.Symbol, result.PositionSide)
+		seen[key] = true
+
+		// 有修复动作时总是发布；只有问题时相同的问题只报告一次
+		summary := result.summary()
+		if len(result.Repaired) == 0 && w.reported[key] == summary {
+			continue
+		}
+		w.reported[key] = summary
+		if result.Protected() {
+			// 已修复的问题下次出现时重新报告
+			delete(w.reported, key)
+		}
+
+		logger.Warnf("仓位保护异常: 账户 %s, %s, 持仓 %g: %s", result.Account, result.Symbol, result.PositionAmt, summary)
+		w.ts.bus.Publish(events.Event{
+			Type:     events.TypeBracketDrift,
+			Symbol:   result.Symbol,
+			Exchange: result.Exchange,
+			Account:  result.Account,
+			Message:  fmt.Sprintf("%s 持仓 %g: %s", result.Symbol, result.PositionAmt, summary),
+			Data:     result,
+		})
+	}
+	for key := range w.reported {
+		if !seen[key] {
+			delete(w.reported, key)
+		}
+	}
+	return results
+}
+
+// Last 最近一次检查发现问题的持仓
+func (w *PositionWatchdog) Last() []PositionProtection {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]PositionProtection(nil), w.last...)
+}
+
+// toStringSet 列表转为集合
+func toStringSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// sortProtections 按账户、币对排序
+func sortProtections(results []PositionProtection) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Account != results[j].Account {
+			return results[i].Account < results[j].Account
+		}
+		return results[i].Symbol < results[j].Symbol
+	})
+}
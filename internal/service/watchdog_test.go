@@ -0,0 +1,100 @@
+package service
+
+import (
+	"testing"
+
+	"new_listing_trade/internal/config"
+	"new_listing_trade/internal/events"
+	"new_listing_trade/internal/models"
+)
+
+func TestCheckProtectionRepair(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	ex.positions = []models.PositionRisk{
+		{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", PositionSide: "BOTH"},
+		{Symbol: "XYZUSDT", PositionAmt: "0"},
+	}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+
+	results, err := ts.CheckProtection(ProtectionOptions{})
+	if err != nil {
+		t.Fatalf("检查失败: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Issues) != 2 || ex.stopAttempts != 0 {
+		t.Fatalf("应发现缺少止损和止盈单且不补建: %+v", results)
+	}
+
+	results, _ = ts.CheckProtection(ProtectionOptions{Repair: true})
+	if len(results) != 1 || !results[0].Protected() || ex.stopAttempts != 1 || ex.takeProfits != 1 {
+		t.Fatalf("应补建止损和止盈单: %+v", results)
+	}
+}
+
+func TestCheckProtectionDrift(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "-6", EntryPrice: "10", PositionSide: "BOTH"}}
+	ex.openOrders = []models.OrderResponse{
+		// 部分平仓后数量没有更新的止损单
+		{OrderID: 1, Symbol: "ABCUSDT", Type: "STOP_MARKET", Side: "BUY", PositionSide: "BOTH", OrigQty: "10", StopPrice: "10.5"},
+		{OrderID: 2, Symbol: "ABCUSDT", Type: "TAKE_PROFIT_MARKET", Side: "BUY", PositionSide: "BOTH", ClosePosition: true, StopPrice: "9.5"},
+		// 其他币对和开仓方向的条件单不计入
+		{OrderID: 3, Symbol: "ABCUSDT", Type: "STOP_MARKET", Side: "SELL", OrigQty: "1"},
+	}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+
+	results, _ := ts.CheckProtection(ProtectionOptions{Repair: true})
+	if len(results) != 1 || len(results[0].Issues) != 1 || results[0].Issues[0] != ProtectionQuantityDrift || len(results[0].Repaired) != 0 {
+		t.Fatalf("应只报告数量不一致: %+v", results)
+	}
+
+	results, _ = ts.CheckProtection(ProtectionOptions{Resize: true})
+	if len(results) != 1 || !results[0].Protected() {
+		t.Fatalf("应按持仓数量重建止损单: %+v", results)
+	}
+	if len(ex.canceled) != 1 || ex.canceled[0] != 1 || ex.stopAttempts != 1 {
+		t.Errorf("应撤销并重建止损单 #1: canceled=%v attempts=%d", ex.canceled, ex.stopAttempts)
+	}
+}
+
+func TestCheckProtectionSkipsStopAddedByRatchet(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	pr := models.PositionRisk{Symbol: "ABCUSDT", PositionAmt: "-10", EntryPrice: "10", PositionSide: "BOTH"}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+
+	// 挂单快照是在移动止损撤销旧止损单之后查询的，加锁后新止损单已创建
+	ex.openOrders = []models.OrderResponse{{OrderID: 5, Symbol: "ABCUSDT", Type: "STOP_MARKET", Side: "BUY", PositionSide: "BOTH", ClosePosition: true, StopPrice: "10"}}
+	result := ts.checkPosition(ts.accounts[0], pr, -10, nil, ProtectionOptions{Repair: true})
+	if ex.stopAttempts != 0 {
+		t.Errorf("已有止损单时不应补建，实际补建 %d 次", ex.stopAttempts)
+	}
+	if !result.Protected() || ex.takeProfits != 1 {
+		t.Errorf("应只补建止盈单: %+v", result)
+	}
+}
+
+func TestPositionWatchdogReportsOnce(t *testing.T) {
+	ex := &fakeGuardExchange{price: "10"}
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "5", EntryPrice: "10", PositionSide: "BOTH"}}
+	ts := newGuardTestService(t, ex, config.StopLossConfig{})
+	bus := events.NewBus(10)
+	ts.SetEventBus(bus)
+
+	watchdog := NewPositionWatchdog(ts, config.WatchdogConfig{Enabled: true, DryRun: true})
+	watchdog.Check()
+	watchdog.Check()
+	if recent := bus.Recent(events.ParseFilter(events.TypeBracketDrift), 10); len(recent) != 1 {
+		t.Errorf("相同的问题只应报告一次，实际: %d", len(recent))
+	}
+
+	// 仓位平掉后再次出现问题时重新报告
+	ex.positions = nil
+	watchdog.Check()
+	ex.positions = []models.PositionRisk{{Symbol: "ABCUSDT", PositionAmt: "5", EntryPrice: "10", PositionSide: "BOTH"}}
+	watchdog.Check()
+	if recent := bus.Recent(events.ParseFilter(events.TypeBracketDrift), 10); len(recent) != 2 {
+		t.Errorf("问题重新出现时应再次报告，实际: %d", len(recent))
+	}
+	if len(watchdog.Last()) != 1 || ex.stopAttempts != 0 {
+		t.Errorf("dry_run 时不应补建")
+	}
+}